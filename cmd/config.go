@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/yiyuanh/snare/internal/config"
+	"github.com/yiyuanh/snare/internal/pipeline"
+)
+
+// loadConfig reads --config, or the .snare.yaml in the project root, and fills
+// in every flag the user didn't set from it. Flags that were set are recorded
+// in cfg.Flags so they also win over the file's per-path overrides.
+// It returns nil when there is no config file.
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	path := flagConfig
+	if path == "" {
+		dir, err := filepath.Abs(flagDir)
+		if err != nil {
+			return nil, fmt.Errorf("resolving directory: %w", err)
+		}
+		root, err := pipeline.FindProjectRoot(dir)
+		if err != nil {
+			return nil, nil // no project root, so no config either; the pipeline reports this
+		}
+		if path = config.Find(root); path == "" {
+			return nil, nil
+		}
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	changed := cmd.Flags().Changed
+
+	if changed("model") {
+		cfg.Flags.Model = &flagModel
+	} else if cfg.Model != nil {
+		flagModel = *cfg.Model
+	}
+	if changed("timeout") {
+		cfg.Flags.Timeout = &flagTimeout
+	} else if cfg.Timeout != nil {
+		flagTimeout = *cfg.Timeout
+	}
+	if changed("max-tests") {
+		cfg.Flags.MaxTests = &flagMaxTests
+	} else if cfg.MaxTests != nil {
+		flagMaxTests = *cfg.MaxTests
+	}
+	if changed("no-judge") {
+		judge := !flagNoJudge
+		cfg.Flags.Judge = &judge
+	} else if cfg.Judge != nil {
+		flagNoJudge = !*cfg.Judge
+	}
+	if changed("likely-bug-threshold") {
+		cfg.Flags.Thresholds.LikelyBug = &flagLikelyBug
+	} else if cfg.Thresholds.LikelyBug != nil {
+		flagLikelyBug = *cfg.Thresholds.LikelyBug
+	}
+
+	if !changed("provider") && !changed("bedrock") && cfg.Provider != "" {
+		flagProvider = cfg.Provider
+	}
+	if !changed("base-url") && cfg.BaseURL != "" {
+		flagBaseURL = cfg.BaseURL
+	}
+	if !changed("telemetry") && cfg.Telemetry != "" {
+		// Relative to the config file, so it works from any directory
+		flagTelemetry = cfg.Telemetry
+		if !filepath.IsAbs(flagTelemetry) {
+			flagTelemetry = filepath.Join(filepath.Dir(path), flagTelemetry)
+		}
+	}
+	if !changed("budget") && cfg.Budget != "" {
+		flagBudget = cfg.Budget
+	}
+	if !changed("include") && len(cfg.Include) > 0 {
+		flagInclude = cfg.Include
+	}
+	if !changed("exclude") && len(cfg.Exclude) > 0 {
+		flagExclude = cfg.Exclude
+	}
+	if !changed("include-func") && cfg.IncludeFunc != "" {
+		flagIncludeFunc = cfg.IncludeFunc
+	}
+	if !changed("exclude-func") && cfg.ExcludeFunc != "" {
+		flagExcludeFunc = cfg.ExcludeFunc
+	}
+	if !changed("no-default-excludes") && cfg.NoDefaultExcludes {
+		flagNoDefaultExcludes = true
+	}
+	if !changed("js-runner") && cfg.JSRunner != "" {
+		flagJSRunner = cfg.JSRunner
+	}
+	if !changed("jobs") && cfg.Jobs != nil {
+		flagJobs = *cfg.Jobs
+	}
+	if !changed("gen-jobs") && cfg.GenJobs != nil {
+		flagGenJobs = *cfg.GenJobs
+	}
+	return cfg, nil
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yiyuanh/snare/internal/color"
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+// formatTokens abbreviates a token count: 950, 12.3k, 1.20M.
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.2fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// formatCost returns the dollar cost, or "n/a" when the model has no known pricing.
+func formatCost(u model.LLMUsage, priced bool) string {
+	if !priced {
+		return "n/a"
+	}
+	return fmt.Sprintf("$%.4f", u.CostUSD)
+}
+
+// usageSummary is a one-line summary of LLM usage for report headers.
+func usageSummary(cost *model.CostReport) string {
+	s := fmt.Sprintf("%d calls, %s tokens", cost.Total.Calls, formatTokens(cost.Total.TotalTokens()))
+	if cost.Priced {
+		s += fmt.Sprintf(", $%.2f", cost.Total.CostUSD)
+	}
+	return s
+}
+
+// printCostSection prints token usage, cost and latency by stage and by function.
+func printCostSection(cost *model.CostReport) {
+	if cost == nil || cost.Total.Calls == 0 {
+		return
+	}
+
+	header := fmt.Sprintf("── LLM COST (%s) ──────────────────────", cost.Model)
+	fmt.Println(color.Apply(color.Dim, header))
+	if cost.BudgetExceeded {
+		fmt.Println(color.Apply(color.Yellow, fmt.Sprintf("  Budget of %s exceeded; remaining LLM calls were skipped.", cost.Budget)))
+	}
+
+	width := len("Function")
+	for _, f := range cost.ByFunc {
+		if len(f.FuncName) > width {
+			width = len(f.FuncName)
+		}
+	}
+	row := func(name string, u model.LLMUsage) {
+		fmt.Printf("  %-*s  %5d  %9s  %9s  %9s  %10s  %9s\n", width, name, u.Calls,
+			formatTokens(u.InputTokens), formatTokens(u.OutputTokens), formatTokens(u.CacheReadTokens+u.CacheWriteTokens),
+			formatCost(u, cost.Priced), u.Latency.Round(time.Millisecond))
+	}
+	fmt.Printf("  %-*s  %5s  %9s  %9s  %9s  %10s  %9s\n", width, "Stage", "Calls", "Input", "Output", "Cache", "Cost", "Latency")
+	for _, s := range cost.ByStage {
+		row(s.Stage, s.LLMUsage)
+	}
+	row("total", cost.Total)
+	fmt.Println()
+
+	if len(cost.ByFunc) > 0 {
+		fmt.Printf("  %-*s  %5s  %9s  %9s  %9s  %10s  %9s\n", width, "Function", "Calls", "Input", "Output", "Cache", "Cost", "Latency")
+		for _, f := range cost.ByFunc {
+			row(f.FuncName, f.LLMUsage)
+		}
+		fmt.Println()
+	}
+}
+
+// printGitHubCost outputs the cost breakdown as a collapsed markdown section.
+func printGitHubCost(cost *model.CostReport) {
+	if cost == nil || cost.Total.Calls == 0 {
+		return
+	}
+
+	fmt.Println("<details>")
+	fmt.Printf("<summary>LLM cost: %s</summary>\n", usageSummary(cost))
+	fmt.Println()
+	if cost.BudgetExceeded {
+		fmt.Printf("**Budget of %s exceeded; remaining LLM calls were skipped.**\n\n", cost.Budget)
+	}
+	fmt.Println("| Stage / function | Calls | Input | Output | Cache | Cost | Latency |")
+	fmt.Println("|------------------|-------|-------|--------|-------|------|---------|")
+	row := func(name string, u model.LLMUsage) {
+		fmt.Printf("| %s | %d | %s | %s | %s | %s | %s |\n", name, u.Calls,
+			formatTokens(u.InputTokens), formatTokens(u.OutputTokens), formatTokens(u.CacheReadTokens+u.CacheWriteTokens),
+			formatCost(u, cost.Priced), u.Latency.Round(time.Millisecond))
+	}
+	for _, s := range cost.ByStage {
+		row(s.Stage, s.LLMUsage)
+	}
+	for _, f := range cost.ByFunc {
+		row("`"+f.FuncName+"`", f.LLMUsage)
+	}
+	row("**total**", cost.Total)
+	fmt.Println()
+	fmt.Println("</details>")
+	fmt.Println()
+}
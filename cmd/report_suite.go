@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yiyuanh/snare/internal/color"
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+// coverageString formats killed/total as "k/n killed (p%)".
+func coverageString(killed, survived int) string {
+	total := killed + survived
+	if total == 0 {
+		return "no mutants evaluated"
+	}
+	return fmt.Sprintf("%d/%d killed (%.0f%%)", killed, total, float64(killed)*100/float64(total))
+}
+
+// partitionSuiteResults splits suite results into survived, killed and not evaluated.
+func partitionSuiteResults(results []model.SuiteResult) (survived, killed, notEvaluated []model.SuiteResult) {
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			notEvaluated = append(notEvaluated, r)
+		case r.Killed:
+			killed = append(killed, r)
+		default:
+			survived = append(survived, r)
+		}
+	}
+	return survived, killed, notEvaluated
+}
+
+// printSuiteReport prints the mutation coverage report for --mode=suite.
+func printSuiteReport(result *model.PipelineResult) {
+	fmt.Println()
+	fmt.Println(color.Apply(color.Bold, "═══════════════════════════════════════════════"))
+	fmt.Println(color.Apply(color.Bold, "  snare — Mutation Coverage Report"))
+	fmt.Println(color.Apply(color.Bold, "═══════════════════════════════════════════════"))
+	fmt.Println()
+
+	fmt.Printf("  Mutation coverage:  %s\n", color.Apply(color.Bold, coverageString(result.MutantsKilled, result.MutantsSurvived)))
+	fmt.Println("  ──────────────────────────────────")
+	fmt.Printf("  Files analyzed:     %d\n", result.FilesAnalyzed)
+	fmt.Printf("  Functions analyzed: %d\n", result.FuncsAnalyzed)
+	fmt.Printf("  Mutants generated:  %d\n", result.MutantsGenerated)
+	fmt.Printf("  Duration:           %s\n", result.Duration.Round(time.Millisecond))
+	if result.Cost != nil {
+		fmt.Printf("  LLM usage:          %s\n", usageSummary(result.Cost))
+	}
+	fmt.Println()
+
+	fmt.Println(color.Apply(color.Bold, "── BY FUNCTION ─────────────────────────────────"))
+	fmt.Println()
+	width := 0
+	for _, fc := range result.FuncCoverage {
+		if len(fc.FuncName) > width {
+			width = len(fc.FuncName)
+		}
+	}
+	for _, fc := range result.FuncCoverage {
+		line := fmt.Sprintf("  %-*s  %s", width, fc.FuncName, coverageString(fc.Killed, fc.Survived))
+		if fc.NotEvaluated > 0 {
+			line += color.Apply(color.Dim, fmt.Sprintf("  [%d not evaluated]", fc.NotEvaluated))
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+
+	survived, killed, notEvaluated := partitionSuiteResults(result.SuiteResults)
+
+	header := fmt.Sprintf("── SURVIVED MUTANTS (%d) ───────────────────────", len(survived))
+	fmt.Println(color.Apply(color.Red+color.Bold, header))
+	fmt.Println()
+	if len(survived) == 0 {
+		fmt.Println("  The project's tests killed every evaluated mutant.")
+		fmt.Println()
+	} else {
+		fmt.Println("  These mutations could be introduced without the project's tests noticing.")
+		fmt.Println()
+		for i, r := range survived {
+			fmt.Printf("  %d. [%s] %s\n", i+1, r.Mutant.FuncName, r.Mutant.Description)
+			if r.Site != "" {
+				fmt.Printf("     at %s\n", r.Site)
+			}
+			fmt.Printf("     - original:  %s\n", strings.TrimSpace(r.Mutant.Original))
+			fmt.Printf("     + mutated:   %s\n", strings.TrimSpace(r.Mutant.Mutated))
+			fmt.Println()
+		}
+	}
+
+	header = fmt.Sprintf("── KILLED MUTANTS (%d) ─────────────────────────", len(killed))
+	fmt.Println(color.Apply(color.Green, header))
+	fmt.Println()
+	for i, r := range killed {
+		fmt.Printf("  %d. [%s] %s\n", i+1, r.Mutant.FuncName, r.Mutant.Description)
+	}
+	if len(killed) > 0 {
+		fmt.Println()
+	}
+
+	if len(notEvaluated) > 0 {
+		header = fmt.Sprintf("── NOT EVALUATED (%d) ──────────────────────────", len(notEvaluated))
+		fmt.Println(color.Apply(color.Dim, header))
+		for _, r := range notEvaluated {
+			fmt.Printf("  %s\n", color.Apply(color.Dim, fmt.Sprintf("[%s] %s: %s", r.Mutant.FuncName, r.Mutant.Description, r.Error)))
+		}
+		fmt.Println()
+	}
+
+	printSkippedSection(result.Skipped)
+	printGenerationErrorsSection(result.GenerationErrors)
+	printCostSection(result.Cost)
+}
+
+// printGitHubSuite outputs the suite-mode report as PR-comment markdown.
+func printGitHubSuite(result *model.PipelineResult) {
+	fmt.Println("## snare — Mutation Coverage Report")
+	fmt.Println()
+	fmt.Printf("**Mutation coverage:** %s\n", coverageString(result.MutantsKilled, result.MutantsSurvived))
+	fmt.Println()
+
+	if len(result.FuncCoverage) > 0 {
+		fmt.Println("| Function | Killed | Survived | Not evaluated |")
+		fmt.Println("|----------|--------|----------|---------------|")
+		for _, fc := range result.FuncCoverage {
+			fmt.Printf("| `%s` | %d | %d | %d |\n", fc.FuncName, fc.Killed, fc.Survived, fc.NotEvaluated)
+		}
+		fmt.Println()
+	}
+
+	survived, _, _ := partitionSuiteResults(result.SuiteResults)
+	if len(survived) > 0 {
+		fmt.Println("### Survived Mutants")
+		fmt.Println()
+		for _, r := range survived {
+			fmt.Printf("> **[%s] %s**\n", r.Mutant.FuncName, r.Mutant.Description)
+			fmt.Printf("> `%s` → `%s`", strings.TrimSpace(r.Mutant.Original), strings.TrimSpace(r.Mutant.Mutated))
+			if r.Site != "" {
+				fmt.Printf(" (%s)", r.Site)
+			}
+			fmt.Println()
+			fmt.Println()
+		}
+	}
+
+	printGitHubSkipped(result.Skipped)
+	printGitHubGenerationErrors(result.GenerationErrors)
+	printGitHubCost(result.Cost)
+
+	fmt.Println("---")
+	fmt.Println("*Generated by [snare](https://github.com/yiyuanh/snare)*")
+}
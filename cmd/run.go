@@ -4,12 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/yiyuanh/snare/internal/color"
+	"github.com/yiyuanh/snare/internal/lang"
+	"github.com/yiyuanh/snare/internal/llm"
 	"github.com/yiyuanh/snare/internal/pipeline"
 	"github.com/yiyuanh/snare/pkg/model"
 )
@@ -22,33 +25,90 @@ var runCmd = &cobra.Command{
 }
 
 var (
-	flagStaged    bool
-	flagCommit    string
-	flagDir       string
-	flagModel     string
-	flagMaxTests  int
-	flagVerbose   bool
-	flagDryRun    bool
-	flagTimeout   time.Duration
-	flagBedrock   bool
-	flagJSON      bool
-	flagFormat    string
-	flagTelemetry string
+	flagStaged         bool
+	flagCommit         string
+	flagBase           string
+	flagRange          string
+	flagPatch          string
+	flagPatchParent    string
+	flagDir            string
+	flagModel          string
+	flagMaxTests       int
+	flagVerbose        bool
+	flagDryRun         bool
+	flagTimeout        time.Duration
+	flagBedrock        bool
+	flagJSON           bool
+	flagFormat         string
+	flagTelemetry      string
+	flagJobs           int
+	flagMode           string
+	flagSuiteTimeout   time.Duration
+	flagGenJobs        int
+	flagRPM            int
+	flagMaxRetries     int
+	flagProvider       string
+	flagBaseURL        string
+	flagRecord         string
+	flagReplay         string
+	flagCacheDir       string
+	flagNoCache        bool
+	flagRepairAttempts int
+	flagBudget         string
+	flagConfig         string
+	flagNoJudge        bool
+	flagLikelyBug      float64
+	flagJSRunner       string
+
+	flagInclude           []string
+	flagExclude           []string
+	flagIncludeFunc       string
+	flagExcludeFunc       string
+	flagNoDefaultExcludes bool
 )
 
 func init() {
 	runCmd.Flags().BoolVar(&flagStaged, "staged", false, "Only analyze staged changes")
 	runCmd.Flags().StringVar(&flagCommit, "commit", "", "Analyze changes from a specific commit")
+	runCmd.Flags().StringVar(&flagBase, "base", "", "Analyze HEAD against its merge-base with this ref (e.g. origin/main)")
+	runCmd.Flags().StringVar(&flagRange, "range", "", "Analyze a revision range: A..B, or A...B to diff from their merge-base")
+	runCmd.Flags().StringVar(&flagPatch, "patch", "", "Analyze a patch file instead of git history (- for stdin)")
+	runCmd.Flags().StringVar(&flagPatchParent, "patch-parent", "HEAD", "Revision or directory with the pre-patch sources (used with --patch)")
 	runCmd.Flags().StringVar(&flagDir, "dir", ".", "Working directory (defaults to current)")
 	runCmd.Flags().StringVar(&flagModel, "model", "us.anthropic.claude-opus-4-6-v1", "Claude model to use")
 	runCmd.Flags().IntVar(&flagMaxTests, "max-tests", 0, "Maximum number of tests to generate (0 = unlimited)")
 	runCmd.Flags().BoolVarP(&flagVerbose, "verbose", "v", false, "Enable verbose output")
 	runCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "Generate tests but don't execute them")
 	runCmd.Flags().DurationVar(&flagTimeout, "timeout", 30*time.Second, "Timeout for each test execution")
-	runCmd.Flags().BoolVar(&flagBedrock, "bedrock", false, "Use Amazon Bedrock instead of the Anthropic API")
+	runCmd.Flags().BoolVar(&flagBedrock, "bedrock", false, "Use Amazon Bedrock instead of the Anthropic API (same as --provider bedrock)")
+	runCmd.Flags().StringVar(&flagProvider, "provider", llm.ProviderAnthropic, "LLM provider: anthropic, bedrock, or openai (any OpenAI-compatible server)")
+	runCmd.Flags().StringVar(&flagBaseURL, "base-url", "", "Endpoint for --provider openai, e.g. http://localhost:11434/v1 for Ollama (default $OPENAI_BASE_URL or the OpenAI API)")
 	runCmd.Flags().BoolVar(&flagJSON, "json", false, "Output results as JSON")
 	runCmd.Flags().StringVar(&flagFormat, "format", "text", "Output format: text, json, github")
 	runCmd.Flags().StringVar(&flagTelemetry, "telemetry", "", "Path to telemetry SQLite database for enriched analysis")
+	runCmd.Flags().IntVarP(&flagJobs, "jobs", "j", 1, "Number of test executions to run concurrently")
+	runCmd.Flags().StringVar(&flagMode, "mode", pipeline.ModeCatching, "Mode: catching (generated tests) or suite (project's own tests vs each mutant)")
+	runCmd.Flags().DurationVar(&flagSuiteTimeout, "suite-timeout", 5*time.Minute, "Timeout for each project test suite run (suite mode)")
+	runCmd.Flags().IntVar(&flagGenJobs, "gen-jobs", 4, "Number of functions to generate tests for concurrently")
+	runCmd.Flags().IntVar(&flagRPM, "rpm", 0, "Maximum LLM requests per minute (0 = unlimited)")
+	runCmd.Flags().IntVar(&flagMaxRetries, "max-retries", 5, "Retries with exponential backoff for rate-limited or failed LLM requests")
+	runCmd.Flags().StringVar(&flagRecord, "record", "", "Record every LLM prompt and response into this cassette directory")
+	runCmd.Flags().StringVar(&flagReplay, "replay", "", "Answer LLM calls from this cassette directory instead of the network")
+	runCmd.Flags().StringVar(&flagCacheDir, "cache-dir", "", "Directory for cached generation results (default: user cache dir)")
+	runCmd.Flags().BoolVar(&flagNoCache, "no-cache", false, "Regenerate every function instead of reusing cached results")
+	runCmd.Flags().IntVar(&flagRepairAttempts, "repair-attempts", 2, "Times to regenerate a test that fails on the original code, feeding back the error (0 = off)")
+	runCmd.Flags().StringVar(&flagBudget, "budget", "", "Stop making LLM calls once this much is spent: tokens (500k, 2M) or dollars ($5)")
+	runCmd.Flags().StringVar(&flagConfig, "config", "", "Config file (default: .snare.yaml in the project root)")
+	runCmd.Flags().BoolVar(&flagNoJudge, "no-judge", false, "Score results with the rule-based assessors only, without the LLM judge")
+	runCmd.Flags().Float64Var(&flagLikelyBug, "likely-bug-threshold", 0.5, "Minimum assessment (-1 to 1) for a weak catch to be reported as a likely bug")
+	runCmd.Flags().StringSliceVar(&flagInclude, "include", nil, "Only analyze files matching these globs (repeatable, e.g. 'internal/**')")
+	runCmd.Flags().StringSliceVar(&flagExclude, "exclude", nil, "Skip files matching these globs (repeatable, e.g. 'integration/')")
+	runCmd.Flags().StringVar(&flagIncludeFunc, "include-func", "", "Only analyze functions whose qualified name (e.g. Server.Start) matches this regexp")
+	runCmd.Flags().StringVar(&flagExcludeFunc, "exclude-func", "", "Skip functions whose qualified name matches this regexp")
+	runCmd.Flags().BoolVar(&flagNoDefaultExcludes, "no-default-excludes", false, "Also analyze generated (*.pb.go, zz_generated*), vendored and mock files")
+	runCmd.Flags().StringVar(&flagJSRunner, "js-runner", "auto", "Test runner for TypeScript/JavaScript: jest, vitest, node (node:test), or auto to detect from package.json")
+	runCmd.MarkFlagsMutuallyExclusive("staged", "commit", "base", "range", "patch")
+	runCmd.MarkFlagsMutuallyExclusive("record", "replay")
 	rootCmd.AddCommand(runCmd)
 }
 
@@ -61,9 +121,46 @@ func outputFormat() string {
 }
 
 func runJiT(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	provider := flagProvider
+	if flagBedrock {
+		if cmd.Flags().Changed("provider") && provider != llm.ProviderBedrock {
+			return fmt.Errorf("--bedrock conflicts with --provider %s", provider)
+		}
+		provider = llm.ProviderBedrock
+	}
+
 	apiKey := os.Getenv("ANTHROPIC_API_KEY")
-	if apiKey == "" && !flagBedrock {
-		return fmt.Errorf("ANTHROPIC_API_KEY environment variable is required (or use --bedrock)")
+	if apiKey == "" && provider == llm.ProviderAnthropic && flagReplay == "" {
+		return fmt.Errorf("ANTHROPIC_API_KEY environment variable is required (or use --bedrock or --provider openai)")
+	}
+
+	if flagMode != pipeline.ModeCatching && flagMode != pipeline.ModeSuite {
+		return fmt.Errorf("invalid --mode %q (want %s or %s)", flagMode, pipeline.ModeCatching, pipeline.ModeSuite)
+	}
+
+	switch flagJSRunner {
+	case "auto", lang.RunnerJest, lang.RunnerVitest, lang.RunnerNode:
+	default:
+		return fmt.Errorf("invalid --js-runner %q (want jest, vitest, node or auto)", flagJSRunner)
+	}
+
+	budget, err := llm.ParseBudget(flagBudget)
+	if err != nil {
+		return fmt.Errorf("--budget: %w", err)
+	}
+
+	includeFunc, err := compileFuncFilter("include-func", flagIncludeFunc)
+	if err != nil {
+		return err
+	}
+	excludeFunc, err := compileFuncFilter("exclude-func", flagExcludeFunc)
+	if err != nil {
+		return err
 	}
 
 	// Disable color for non-text formats
@@ -73,17 +170,44 @@ func runJiT(cmd *cobra.Command, args []string) error {
 	}
 
 	opts := pipeline.Options{
-		Dir:         flagDir,
-		Staged:      flagStaged,
-		Commit:      flagCommit,
-		Model:       flagModel,
-		MaxTests:    flagMaxTests,
-		Verbose:     flagVerbose && format == "text",
-		DryRun:      flagDryRun,
-		Timeout:     flagTimeout,
-		APIKey:      apiKey,
-		Bedrock:     flagBedrock,
-		TelemetryDB: flagTelemetry,
+		Dir:            flagDir,
+		Staged:         flagStaged,
+		Commit:         flagCommit,
+		Base:           flagBase,
+		Range:          flagRange,
+		Patch:          flagPatch,
+		PatchParent:    flagPatchParent,
+		Model:          flagModel,
+		MaxTests:       flagMaxTests,
+		Verbose:        flagVerbose && format == "text",
+		DryRun:         flagDryRun,
+		Timeout:        flagTimeout,
+		APIKey:         apiKey,
+		Provider:       provider,
+		BaseURL:        flagBaseURL,
+		Record:         flagRecord,
+		Replay:         flagReplay,
+		CacheDir:       flagCacheDir,
+		NoCache:        flagNoCache,
+		RepairAttempts: flagRepairAttempts,
+		Budget:         budget,
+		TelemetryDB:    flagTelemetry,
+		Jobs:           flagJobs,
+		Mode:           flagMode,
+		SuiteTimeout:   flagSuiteTimeout,
+		GenJobs:        flagGenJobs,
+		RPM:            flagRPM,
+		MaxRetries:     flagMaxRetries,
+		NoJudge:        flagNoJudge,
+		LikelyBug:      flagLikelyBug,
+		Config:         cfg,
+		JSRunner:       flagJSRunner,
+
+		Include:           flagInclude,
+		Exclude:           flagExclude,
+		NoDefaultExcludes: flagNoDefaultExcludes,
+		IncludeFunc:       includeFunc,
+		ExcludeFunc:       excludeFunc,
 	}
 
 	p := pipeline.New(opts)
@@ -96,14 +220,35 @@ func runJiT(cmd *cobra.Command, args []string) error {
 	case "json":
 		return printJSON(result)
 	case "github":
-		printGitHub(result)
+		if result.Mode == pipeline.ModeSuite {
+			printGitHubSuite(result)
+		} else {
+			printGitHub(result)
+		}
 	default:
-		printReport(result, opts)
+		if result.Mode == pipeline.ModeSuite && !opts.DryRun {
+			printSuiteReport(result)
+		} else {
+			printReport(result, opts)
+		}
 	}
 	return nil
 }
 
-// aggregateByCatch groups test results into CatchSummary entries by FuncName:MutantID.
+// compileFuncFilter compiles a function-name filter flag; "" means no filter.
+func compileFuncFilter(flag, expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", flag, err)
+	}
+	return re, nil
+}
+
+// aggregateByCatch groups test results into CatchSummary entries by FuncName:MutantID,
+// where FuncName is the qualified identity (e.g. "Server.Start").
 func aggregateByCatch(results []model.TestResult) []model.CatchSummary {
 	type key struct{ funcName, mutantID string }
 	order := []key{}
@@ -124,6 +269,9 @@ func aggregateByCatch(results []model.TestResult) []model.CatchSummary {
 		if r.IsCatching {
 			s.IsWeakCatch = true
 		}
+		if r.LikelyBug {
+			s.IsLikelyBug = true
+		}
 		if r.Assessment > s.Assessment {
 			s.Assessment = r.Assessment
 		}
@@ -155,7 +303,7 @@ func printGitHub(result *model.PipelineResult) {
 
 	var likelyBugs, weakCatches []model.CatchSummary
 	for _, s := range summaries {
-		if s.IsWeakCatch && s.Assessment > 0.5 {
+		if s.IsLikelyBug {
 			likelyBugs = append(likelyBugs, s)
 		} else if s.IsWeakCatch {
 			weakCatches = append(weakCatches, s)
@@ -200,6 +348,10 @@ func printGitHub(result *model.PipelineResult) {
 		fmt.Println()
 	}
 
+	printGitHubSkipped(result.Skipped)
+	printGitHubGenerationErrors(result.GenerationErrors)
+	printGitHubCost(result.Cost)
+
 	fmt.Println("---")
 	fmt.Println("*Generated by [snare](https://github.com/yiyuanh/snare)*")
 }
@@ -215,7 +367,7 @@ func printReport(result *model.PipelineResult, opts pipeline.Options) {
 
 	if !opts.DryRun {
 		fmt.Printf("  Weak catches:     %s\n", color.Apply(color.Bold, fmt.Sprintf("%d found", result.WeakCatches)))
-		fmt.Printf("  Likely bugs:      %s\n", color.Apply(color.Bold, fmt.Sprintf("%d (assessment > 0.5)", result.StrongCatches)))
+		fmt.Printf("  Likely bugs:      %s\n", color.Apply(color.Bold, fmt.Sprintf("%d (assessment > %g)", result.StrongCatches, result.LikelyBugThreshold)))
 		fmt.Println("  ──────────────────────────────────")
 	}
 
@@ -229,17 +381,23 @@ func printReport(result *model.PipelineResult, opts pipeline.Options) {
 	}
 
 	fmt.Printf("  Duration:           %s\n", result.Duration.Round(time.Millisecond))
+	if result.Cost != nil {
+		fmt.Printf("  LLM usage:          %s\n", usageSummary(result.Cost))
+	}
 	fmt.Println()
 
 	if opts.DryRun {
 		printDryRunReport(summaries, opts)
+		printSkippedSection(result.Skipped)
+		printGenerationErrorsSection(result.GenerationErrors)
+		printCostSection(result.Cost)
 		return
 	}
 
 	// Partition summaries into likely bugs, weak catches, no catch
 	var likelyBugs, weakCatches, noCatch []model.CatchSummary
 	for _, s := range summaries {
-		if s.IsWeakCatch && s.Assessment > 0.5 {
+		if s.IsLikelyBug {
 			likelyBugs = append(likelyBugs, s)
 		} else if s.IsWeakCatch {
 			weakCatches = append(weakCatches, s)
@@ -257,6 +415,9 @@ func printReport(result *model.PipelineResult, opts pipeline.Options) {
 	printWeakCatchesSection(weakCatches, opts)
 	printNoCatchSection(noCatch)
 	printFilteredSection(result.Results)
+	printSkippedSection(result.Skipped)
+	printGenerationErrorsSection(result.GenerationErrors)
+	printCostSection(result.Cost)
 }
 
 func printDryRunReport(summaries []model.CatchSummary, opts pipeline.Options) {
@@ -299,10 +460,7 @@ func printLikelyBugsSection(likelyBugs []model.CatchSummary, opts pipeline.Optio
 	fmt.Println()
 
 	for i, s := range likelyBugs {
-		assessStr := fmt.Sprintf("%.2f", s.Assessment)
-		if s.Assessment > 0.5 {
-			assessStr = color.Apply(color.Red, assessStr)
-		}
+		assessStr := color.Apply(color.Red, fmt.Sprintf("%.2f", s.Assessment))
 		fmt.Printf("  %d. [%s] %s (assessment: %s)\n", i+1, s.Mutant.FuncName, s.Mutant.Description, assessStr)
 		fmt.Printf("     Risk: %s\n", s.Risk.Description)
 		if s.BehaviorChange != "" {
@@ -315,7 +473,11 @@ func printLikelyBugsSection(likelyBugs []model.CatchSummary, opts pipeline.Optio
 		if opts.Verbose {
 			for _, t := range s.Tests {
 				if t.IsCatching {
-					fmt.Printf("     Test: %s (assessment: %.2f)\n", t.Test.TestName, t.Assessment)
+					status := ""
+					if m := testNotes(t); m != "" {
+						status = ", " + m
+					}
+					fmt.Printf("     Test: %s (assessment: %.2f%s)\n", t.Test.TestName, t.Assessment, status)
 					fmt.Println()
 					fmt.Println(t.Test.TestCode)
 					fmt.Println()
@@ -352,7 +514,11 @@ func printWeakCatchesSection(weakCatches []model.CatchSummary, opts pipeline.Opt
 		if opts.Verbose {
 			for _, t := range s.Tests {
 				if t.IsCatching {
-					fmt.Printf("     Test: %s\n", t.Test.TestName)
+					status := ""
+					if m := testNotes(t); m != "" {
+						status = " (" + m + ")"
+					}
+					fmt.Printf("     Test: %s%s\n", t.Test.TestName, status)
 				}
 			}
 		}
@@ -360,6 +526,33 @@ func printWeakCatchesSection(weakCatches []model.CatchSummary, opts pipeline.Opt
 	}
 }
 
+// testNotes summarizes how a test's execution went, for verbose output.
+func testNotes(t model.TestResult) string {
+	var notes []string
+	if m := mutantStatus(t); m != "" {
+		notes = append(notes, m)
+	}
+	if t.RepairAttempts > 0 {
+		notes = append(notes, fmt.Sprintf("repaired after %d attempt(s)", t.RepairAttempts))
+	}
+	return strings.Join(notes, ", ")
+}
+
+// mutantStatus describes the outcome of the mutant leg for a test, if it ran.
+func mutantStatus(t model.TestResult) string {
+	if !t.MutantApplied {
+		return ""
+	}
+	status := "mutant survived"
+	if t.KillsMutant {
+		status = "kills mutant"
+	}
+	if t.MutantSite != "" {
+		status += " at " + t.MutantSite
+	}
+	return status
+}
+
 func printNoCatchSection(noCatch []model.CatchSummary) {
 	header := fmt.Sprintf("── NO CATCH (%d) ───────────────────────────────", len(noCatch))
 	fmt.Println(color.Apply(color.Green, header))
@@ -394,3 +587,65 @@ func printFilteredSection(results []model.TestResult) {
 	}
 	fmt.Println()
 }
+
+func printGenerationErrorsSection(errs []model.GenerationError) {
+	if len(errs) == 0 {
+		return
+	}
+
+	header := fmt.Sprintf("── GENERATION FAILED (%d) ──────────────────────", len(errs))
+	fmt.Println(color.Apply(color.Yellow, header))
+	fmt.Println("  No tests were generated for these functions; they were not checked.")
+	for _, e := range errs {
+		fmt.Printf("  %s\n", color.Apply(color.Dim, fmt.Sprintf("%s (%s): %s", e.FuncName, e.FilePath, e.Error)))
+	}
+	fmt.Println()
+}
+
+// printSkippedSection lists changed files that were not analyzed, with the reason.
+func printSkippedSection(skipped []model.SkippedFile) {
+	if len(skipped) == 0 {
+		return
+	}
+
+	header := fmt.Sprintf("── SKIPPED (%d) ────────────────────────────────", len(skipped))
+	fmt.Println(color.Apply(color.Dim, header))
+	for _, s := range skipped {
+		fmt.Printf("  %s\n", color.Apply(color.Dim, fmt.Sprintf("%s: %s", s.FilePath, s.Reason)))
+	}
+	fmt.Println()
+}
+
+// printGitHubSkipped lists files that were not analyzed as a collapsed markdown section.
+func printGitHubSkipped(skipped []model.SkippedFile) {
+	if len(skipped) == 0 {
+		return
+	}
+
+	fmt.Println("<details>")
+	fmt.Printf("<summary>Skipped files (%d)</summary>\n", len(skipped))
+	fmt.Println()
+	for _, s := range skipped {
+		fmt.Printf("- `%s`: %s\n", s.FilePath, s.Reason)
+	}
+	fmt.Println()
+	fmt.Println("</details>")
+	fmt.Println()
+}
+
+// printGitHubGenerationErrors lists functions that were not checked as a collapsed markdown section.
+func printGitHubGenerationErrors(errs []model.GenerationError) {
+	if len(errs) == 0 {
+		return
+	}
+
+	fmt.Println("<details>")
+	fmt.Printf("<summary>Generation failed (%d)</summary>\n", len(errs))
+	fmt.Println()
+	for _, e := range errs {
+		fmt.Printf("- `%s` (%s): %s\n", e.FuncName, e.FilePath, e.Error)
+	}
+	fmt.Println()
+	fmt.Println("</details>")
+	fmt.Println()
+}
@@ -11,11 +11,23 @@ import (
 
 // FuncInfo holds information about a function declaration found by AST parsing.
 type FuncInfo struct {
-	Name      string
-	Signature string
-	Body      string
-	StartLine int
-	EndLine   int
+	Name          string
+	QualifiedName string // receiver type plus name for methods (e.g. "Server.Start"), else Name
+	Signature     string
+	Body          string
+	StartLine     int
+	EndLine       int
+}
+
+// IsGenerated reports whether Go source carries the standard
+// "// Code generated ... DO NOT EDIT." marker (see ast.IsGenerated). Only the
+// header is parsed, so files with syntax errors further down are still detected.
+func IsGenerated(src []byte) bool {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return false
+	}
+	return ast.IsGenerated(file)
 }
 
 // ParseFunctions parses a Go source file and returns info about all function declarations.
@@ -94,14 +106,40 @@ func ParseFunctions(fset *token.FileSet, src []byte) (pkg string, imports []stri
 			format.Node(&bodyBuf, fset, funcDecl.Body)
 		}
 
+		qualified := funcDecl.Name.Name
+		if funcDecl.Recv != nil && len(funcDecl.Recv.List) > 0 {
+			if recv := receiverTypeName(funcDecl.Recv.List[0].Type); recv != "" {
+				qualified = recv + "." + qualified
+			}
+		}
+
 		funcs = append(funcs, FuncInfo{
-			Name:      funcDecl.Name.Name,
-			Signature: sigBuf.String(),
-			Body:      bodyBuf.String(),
-			StartLine: startPos.Line,
-			EndLine:   endPos.Line,
+			Name:          funcDecl.Name.Name,
+			QualifiedName: qualified,
+			Signature:     sigBuf.String(),
+			Body:          bodyBuf.String(),
+			StartLine:     startPos.Line,
+			EndLine:       endPos.Line,
 		})
 	}
 
 	return pkg, imports, typeDefs, funcs, nil
 }
+
+// receiverTypeName returns the base type name of a method receiver,
+// dropping pointers and type parameters: *List[T] -> List.
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	case *ast.ParenExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(t.X)
+	}
+	return ""
+}
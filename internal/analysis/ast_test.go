@@ -106,6 +106,36 @@ func (s *Server) Start() error {
 	}
 }
 
+func TestParseFunctions_QualifiedName(t *testing.T) {
+	src := []byte(`package example
+
+type A struct{}
+type B struct{}
+type List[T any] struct{}
+
+func (a *A) String() string { return "a" }
+func (b B) String() string { return "b" }
+func (l *List[T]) Len() int { return 0 }
+func Free() {}
+`)
+
+	fset := token.NewFileSet()
+	_, _, _, funcs, err := ParseFunctions(fset, src)
+	if err != nil {
+		t.Fatalf("ParseFunctions returned error: %v", err)
+	}
+
+	want := []string{"A.String", "B.String", "List.Len", "Free"}
+	if len(funcs) != len(want) {
+		t.Fatalf("len(funcs) = %d, want %d", len(funcs), len(want))
+	}
+	for i, w := range want {
+		if funcs[i].QualifiedName != w {
+			t.Errorf("funcs[%d].QualifiedName = %q, want %q", i, funcs[i].QualifiedName, w)
+		}
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && searchString(s, substr)
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go/token"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/yiyuanh/snare/pkg/model"
@@ -15,25 +16,41 @@ type FuncIdentifier interface {
 	IdentifyChangedFuncs(filePath string, source []byte, hunks []model.Hunk) ([]model.ChangedFunc, error)
 }
 
+// Reasons a changed file is skipped without analysis.
+const (
+	SkipGenerated = "generated file (Code generated ... DO NOT EDIT)"
+	SkipIgnored   = "marked " + IgnoreDirective
+)
+
 // MapChangedFuncs takes file diffs and identifies which functions were changed.
 // It analyzes both the new code and parent code to populate dual-version info.
-func MapChangedFuncs(diffs []model.FileDiff) ([]model.ChangedFunc, error) {
+// Generated files and files marked with IgnoreDirective are skipped and
+// returned with the reason; functions marked with IgnoreDirective are dropped.
+func MapChangedFuncs(diffs []model.FileDiff) ([]model.ChangedFunc, []model.SkippedFile, error) {
 	var result []model.ChangedFunc
+	var skipped []model.SkippedFile
 
 	for _, fd := range diffs {
-		funcs, err := analyzeFile(fd)
+		funcs, reason, err := analyzeFile(fd)
 		if err != nil {
-			return nil, fmt.Errorf("analyzing %s: %w", fd.NewName, err)
+			return nil, nil, fmt.Errorf("analyzing %s: %w", fd.NewName, err)
+		}
+		if reason != "" {
+			skipped = append(skipped, model.SkippedFile{FilePath: fd.NewName, Reason: reason})
+			continue
 		}
 		result = append(result, funcs...)
 	}
-	return result, nil
+	return result, skipped, nil
 }
 
 // MapChangedFuncsWithLang uses the FuncIdentifier interface to identify changed functions.
-// This supports languages beyond Go (e.g., Python).
-func MapChangedFuncsWithLang(diffs []model.FileDiff, language FuncIdentifier) ([]model.ChangedFunc, error) {
+// This supports languages beyond Go (e.g., Python). Like MapChangedFuncs, it
+// skips generated files and anything marked with IgnoreDirective, which is
+// looked for in comments written with syntax.
+func MapChangedFuncsWithLang(diffs []model.FileDiff, language FuncIdentifier, syntax CommentSyntax) ([]model.ChangedFunc, []model.SkippedFile, error) {
 	var result []model.ChangedFunc
+	var skipped []model.SkippedFile
 
 	for _, fd := range diffs {
 		// Read the new source from disk or from the diff
@@ -44,15 +61,21 @@ func MapChangedFuncsWithLang(diffs []model.FileDiff, language FuncIdentifier) ([
 			var err error
 			newSrc, err = os.ReadFile(fd.NewName)
 			if err != nil {
-				return nil, fmt.Errorf("reading %s: %w", fd.NewName, err)
+				return nil, nil, fmt.Errorf("reading %s: %w", fd.NewName, err)
 			}
 		}
 
+		if reason := skipReason(newSrc, hasGeneratedMarker(newSrc), syntax); reason != "" {
+			skipped = append(skipped, model.SkippedFile{FilePath: fd.NewName, Reason: reason})
+			continue
+		}
+
 		// Use the language interface to identify changed functions in the new source
 		changedFuncs, err := language.IdentifyChangedFuncs(fd.NewName, newSrc, fd.Hunks)
 		if err != nil {
-			return nil, fmt.Errorf("identifying changed functions in %s: %w", fd.NewName, err)
+			return nil, nil, fmt.Errorf("identifying changed functions in %s: %w", fd.NewName, err)
 		}
+		changedFuncs = dropIgnored(changedFuncs, newSrc, syntax)
 
 		// If parent source is available, also parse it to populate parent fields.
 		// Use a broad hunk covering the entire file so all functions are extracted
@@ -62,16 +85,16 @@ func MapChangedFuncsWithLang(diffs []model.FileDiff, language FuncIdentifier) ([
 			allHunks := []model.Hunk{{NewStartLine: 1, NewLineCount: parentLines}}
 			parentFuncs, err := language.IdentifyChangedFuncs(fd.NewName, fd.ParentSource, allHunks)
 			if err == nil {
-				// Build parent lookup by name
+				// Build parent lookup by qualified identity
 				parentMap := make(map[string]model.ChangedFunc)
 				for _, pf := range parentFuncs {
-					parentMap[pf.Name] = pf
+					parentMap[pf.ID()] = pf
 				}
 
 				// Populate parent info and filter out new functions
 				var filtered []model.ChangedFunc
 				for _, cf := range changedFuncs {
-					if pf, ok := parentMap[cf.Name]; ok {
+					if pf, ok := parentMap[cf.ID()]; ok {
 						cf.ParentSignature = pf.Signature
 						cf.ParentBody = pf.Body
 						filtered = append(filtered, cf)
@@ -84,19 +107,52 @@ func MapChangedFuncsWithLang(diffs []model.FileDiff, language FuncIdentifier) ([
 
 		result = append(result, changedFuncs...)
 	}
-	return result, nil
+	return result, skipped, nil
 }
 
-func analyzeFile(fd model.FileDiff) ([]model.ChangedFunc, error) {
-	src, err := os.ReadFile(fd.NewName)
-	if err != nil {
-		return nil, fmt.Errorf("reading file: %w", err)
+// skipReason returns why a file should not be analyzed, or "" if it should.
+func skipReason(src []byte, generated bool, syntax CommentSyntax) string {
+	switch {
+	case generated:
+		return SkipGenerated
+	case FileIgnored(src, syntax):
+		return SkipIgnored
+	}
+	return ""
+}
+
+// generatedMarker is the standard marker for generated files
+// (https://go.dev/s/generatedcode), in a "//" or "#" line comment.
+var generatedMarker = regexp.MustCompile(`(?m)^(//|#) Code generated .* DO NOT EDIT\.$`)
+
+// hasGeneratedMarker reports whether src carries the generated-code marker.
+// It is used for languages without a parser in this package; Go files are
+// checked with ast.IsGenerated.
+func hasGeneratedMarker(src []byte) bool {
+	return generatedMarker.Match(src)
+}
+
+// analyzeFile returns the changed functions in a Go file, or the reason the
+// file was skipped.
+func analyzeFile(fd model.FileDiff) ([]model.ChangedFunc, string, error) {
+	// Prefer the new source from the diff (--commit/--base), falling back to disk
+	src := fd.NewSource
+	if len(src) == 0 {
+		var err error
+		src, err = os.ReadFile(fd.NewName)
+		if err != nil {
+			return nil, "", fmt.Errorf("reading file: %w", err)
+		}
+	}
+
+	if reason := skipReason(src, IsGenerated(src), GoComments); reason != "" {
+		return nil, reason, nil
 	}
 
 	fset := token.NewFileSet()
 	pkg, imports, typeDefs, funcs, err := ParseFunctions(fset, src)
 	if err != nil {
-		return nil, fmt.Errorf("parsing AST: %w", err)
+		return nil, "", fmt.Errorf("parsing AST: %w", err)
 	}
 
 	// Parse parent source if available
@@ -109,22 +165,23 @@ func analyzeFile(fd model.FileDiff) ([]model.ChangedFunc, error) {
 		}
 	}
 
-	// Build lookup for parent functions by name
+	// Build lookup for parent functions by qualified identity, so methods
+	// with the same name on different receivers don't collide
 	parentFuncMap := make(map[string]FuncInfo)
 	for _, pf := range parentFuncs {
-		parentFuncMap[pf.Name] = pf
+		parentFuncMap[pf.QualifiedName] = pf
 	}
 
 	var result []model.ChangedFunc
 
 	for _, fn := range funcs {
 		overlapping := findOverlappingHunks(fn, fd.Hunks)
-		if len(overlapping) == 0 {
+		if len(overlapping) == 0 || FuncIgnored(src, fn.StartLine, GoComments) {
 			continue
 		}
 
 		// Skip newly added functions (no parent version) — no regression possible
-		parentFunc, hasParent := parentFuncMap[fn.Name]
+		parentFunc, hasParent := parentFuncMap[fn.QualifiedName]
 		if !hasParent && len(fd.ParentSource) > 0 {
 			continue
 		}
@@ -135,16 +192,17 @@ func analyzeFile(fd model.FileDiff) ([]model.ChangedFunc, error) {
 		}
 
 		cf := model.ChangedFunc{
-			FilePath:    fd.NewName,
-			Package:     pkg,
-			Name:        fn.Name,
-			Signature:   fn.Signature,
-			Body:        fn.Body,
-			StartLine:   fn.StartLine,
-			EndLine:     fn.EndLine,
-			Imports:     imports,
-			TypeDefs:    typeDefs,
-			DiffContext: strings.Join(diffParts, "\n"),
+			FilePath:      fd.NewName,
+			Package:       pkg,
+			Name:          fn.Name,
+			QualifiedName: fn.QualifiedName,
+			Signature:     fn.Signature,
+			Body:          fn.Body,
+			StartLine:     fn.StartLine,
+			EndLine:       fn.EndLine,
+			Imports:       imports,
+			TypeDefs:      typeDefs,
+			DiffContext:   strings.Join(diffParts, "\n"),
 		}
 
 		// Populate parent info if available
@@ -155,7 +213,18 @@ func analyzeFile(fd model.FileDiff) ([]model.ChangedFunc, error) {
 
 		result = append(result, cf)
 	}
-	return result, nil
+	return result, "", nil
+}
+
+// dropIgnored removes functions marked with IgnoreDirective in src.
+func dropIgnored(funcs []model.ChangedFunc, src []byte, syntax CommentSyntax) []model.ChangedFunc {
+	var kept []model.ChangedFunc
+	for _, fn := range funcs {
+		if !FuncIgnored(src, fn.StartLine, syntax) {
+			kept = append(kept, fn)
+		}
+	}
+	return kept
 }
 
 // findOverlappingHunks returns hunks whose new-file line range overlaps
@@ -1,6 +1,7 @@
 package analysis
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/yiyuanh/snare/pkg/model"
@@ -84,3 +85,54 @@ func TestFindOverlappingHunks_ExactBoundary(t *testing.T) {
 		t.Fatalf("len(result) = %d, want 1", len(result))
 	}
 }
+
+func TestAnalyzeFile_MatchesParentByReceiver(t *testing.T) {
+	parent := []byte(`package example
+
+type A struct{}
+type B struct{}
+
+func (a *A) String() string {
+	return "a"
+}
+
+func (b *B) String() string {
+	return "b"
+}
+`)
+	newSrc := []byte(`package example
+
+type A struct{}
+type B struct{}
+
+func (a *A) String() string {
+	return "a"
+}
+
+func (b *B) String() string {
+	return "B"
+}
+`)
+
+	fd := model.FileDiff{
+		OldName:      "example.go",
+		NewName:      "/fake/example.go",
+		ParentSource: parent,
+		NewSource:    newSrc,
+		Hunks:        []model.Hunk{{NewStartLine: 11, NewLineCount: 1, Content: "+\treturn \"B\""}},
+	}
+
+	funcs, _, err := analyzeFile(fd)
+	if err != nil {
+		t.Fatalf("analyzeFile: %v", err)
+	}
+	if len(funcs) != 1 {
+		t.Fatalf("len(funcs) = %d, want 1", len(funcs))
+	}
+	if funcs[0].QualifiedName != "B.String" {
+		t.Errorf("QualifiedName = %q, want %q", funcs[0].QualifiedName, "B.String")
+	}
+	if !strings.Contains(funcs[0].ParentBody, `"b"`) {
+		t.Errorf("ParentBody = %q, want B's parent body", funcs[0].ParentBody)
+	}
+}
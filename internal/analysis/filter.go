@@ -0,0 +1,147 @@
+package analysis
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+// IgnoreDirective in a comment directly above a function skips that function;
+// in a file's leading comments it skips the whole file. For example
+// "//snare:ignore" or "/* snare:ignore */" in Go or "# snare:ignore" in
+// Python.
+const IgnoreDirective = "snare:ignore"
+
+// CommentSyntax is what finding IgnoreDirective takes from a language: how
+// comments are written and what may stand between a function and the
+// comment above it.
+type CommentSyntax struct {
+	Line string // line comment marker, e.g. "//" or "#"
+
+	// BlockStart and BlockEnd delimit block comments, e.g. "/*" and "*/";
+	// they are empty if the language has none.
+	BlockStart, BlockEnd string
+
+	// Attributes are prefixes of lines skipped above a function: "@" for
+	// decorators and annotations, "#[" for Rust attributes.
+	Attributes []string
+}
+
+// GoComments is the comment syntax of Go, which MapChangedFuncs analyzes.
+var GoComments = CommentSyntax{Line: "//", BlockStart: "/*", BlockEnd: "*/"}
+
+// FileIgnored reports whether the comments at the top of src, before any
+// code, contain the ignore directive.
+func FileIgnored(src []byte, syntax CommentSyntax) bool {
+	for _, c := range syntax.comments(src) {
+		if c.blank {
+			continue
+		}
+		if !c.comment {
+			return false
+		}
+		if isIgnoreDirective(c.text) {
+			return true
+		}
+	}
+	return false
+}
+
+// FuncIgnored reports whether the comment block directly above the 1-based
+// line (skipping decorators and attributes) contains the ignore directive.
+func FuncIgnored(src []byte, line int, syntax CommentSyntax) bool {
+	lines := syntax.comments(src)
+	for i := line - 2; i >= 0 && i < len(lines); i-- {
+		c := lines[i]
+		if !c.comment && syntax.isAttribute(c.code) {
+			continue
+		}
+		if !c.comment {
+			return false
+		}
+		if isIgnoreDirective(c.text) {
+			return true
+		}
+	}
+	return false
+}
+
+// commentLine is one line of source as the ignore directive lookup sees it.
+type commentLine struct {
+	comment bool   // the line holds only comments
+	blank   bool   // the line is empty
+	text    string // the comment's text, if comment
+	code    string // the trimmed line, if not comment
+}
+
+// comments classifies each line of src. A line is a comment if it holds a
+// line comment, a block comment or part of one, and nothing else.
+func (s CommentSyntax) comments(src []byte) []commentLine {
+	lines := strings.Split(string(src), "\n")
+	result := make([]commentLine, len(lines))
+	inBlock := false
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+		if inBlock {
+			text, rest, closed := strings.Cut(l, s.BlockEnd)
+			inBlock = !closed
+			result[i] = commentLine{comment: strings.TrimSpace(rest) == "", text: blockText(text), code: l}
+			continue
+		}
+		switch {
+		case l == "":
+			result[i] = commentLine{blank: true}
+		case s.Line != "" && strings.HasPrefix(l, s.Line):
+			result[i] = commentLine{comment: true, text: strings.TrimSpace(l[len(s.Line):])}
+		case s.BlockStart != "" && strings.HasPrefix(l, s.BlockStart):
+			text, rest, closed := strings.Cut(l[len(s.BlockStart):], s.BlockEnd)
+			inBlock = !closed
+			result[i] = commentLine{comment: strings.TrimSpace(rest) == "", text: blockText(text), code: l}
+		default:
+			result[i] = commentLine{code: l}
+		}
+	}
+	return result
+}
+
+// blockText trims a line of a block comment, including the "*" that lines
+// of doc comments start with.
+func blockText(text string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "*"))
+}
+
+// isAttribute reports whether a line of code is a decorator or attribute.
+func (s CommentSyntax) isAttribute(line string) bool {
+	for _, prefix := range s.Attributes {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIgnoreDirective accepts the directive alone or followed by a reason.
+func isIgnoreDirective(text string) bool {
+	rest, ok := strings.CutPrefix(text, IgnoreDirective)
+	return ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t')
+}
+
+// FilterFuncs keeps the functions whose qualified name (e.g. "Server.Start")
+// matches include, if set, and does not match exclude, if set.
+func FilterFuncs(funcs []model.ChangedFunc, include, exclude *regexp.Regexp) []model.ChangedFunc {
+	if include == nil && exclude == nil {
+		return funcs
+	}
+	var kept []model.ChangedFunc
+	for _, fn := range funcs {
+		if include != nil && !include.MatchString(fn.ID()) {
+			continue
+		}
+		if exclude != nil && exclude.MatchString(fn.ID()) {
+			continue
+		}
+		kept = append(kept, fn)
+	}
+	return kept
+}
@@ -0,0 +1,187 @@
+package analysis
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+// Comment syntaxes as the languages in internal/lang declare them.
+var (
+	pythonComments = CommentSyntax{Line: "#", Attributes: []string{"@"}}
+	rustComments   = CommentSyntax{Line: "//", BlockStart: "/*", BlockEnd: "*/", Attributes: []string{"#["}}
+)
+
+func TestFileIgnored(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		syntax CommentSyntax
+		want   bool
+	}{
+		{"go header", "// Copyright 2025\n//snare:ignore\n\npackage x\n", GoComments, true},
+		{"with reason", "//snare:ignore generated by hand\npackage x\n", GoComments, true},
+		{"block", "/* snare:ignore */\npackage x\n", GoComments, true},
+		{"after license block", "/*\n * Copyright 2025\n */\n// snare:ignore\npackage x\n", GoComments, true},
+		{"in license block", "/*\n * Copyright 2025\n * snare:ignore\n */\npackage x\n", GoComments, true},
+		{"python", "#!/usr/bin/env python\n# snare:ignore\nimport os\n", pythonComments, true},
+		{"python slashes", "// snare:ignore\nimport os\n", pythonComments, false},
+		{"rust", "// snare:ignore\nuse std::fmt;\n", rustComments, true},
+		{"rust inner attribute", "#![allow(dead_code)]\n// snare:ignore\nuse std::fmt;\n", rustComments, false},
+		{"after code", "package x\n\n//snare:ignore\nfunc f() {}\n", GoComments, false},
+		{"lookalike", "//snare:ignored\npackage x\n", GoComments, false},
+		{"none", "package x\n", GoComments, false},
+	}
+	for _, tt := range tests {
+		if got := FileIgnored([]byte(tt.src), tt.syntax); got != tt.want {
+			t.Errorf("%s: FileIgnored = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFuncIgnored(t *testing.T) {
+	src := []byte(`package x
+
+// f does things.
+//snare:ignore
+func f() {}
+
+//snare:ignore
+
+func g() {}
+
+func h() {}
+
+/* snare:ignore */
+func i() {}
+`)
+	if !FuncIgnored(src, 5, GoComments) {
+		t.Error("f should be ignored")
+	}
+	if FuncIgnored(src, 9, GoComments) {
+		t.Error("g is separated from the directive by a blank line")
+	}
+	if FuncIgnored(src, 11, GoComments) {
+		t.Error("h should not be ignored")
+	}
+	if !FuncIgnored(src, 14, GoComments) {
+		t.Error("a block comment directive should apply")
+	}
+
+	py := []byte("# snare:ignore\n@cache\n@trace\ndef f():\n    pass\n")
+	if !FuncIgnored(py, 4, pythonComments) {
+		t.Error("directive above decorators should apply")
+	}
+}
+
+func TestFuncIgnored_Rust(t *testing.T) {
+	src := []byte(`// snare:ignore
+#[inline]
+#[must_use]
+fn f() -> i32 { 1 }
+
+#[snare::ignore]
+fn g() -> i32 { 2 }
+
+/* snare:ignore */
+fn h() -> i32 { 3 }
+
+#[cfg(feature = "x")] // snare:ignore
+fn i() -> i32 { 4 }
+`)
+	if !FuncIgnored(src, 4, rustComments) {
+		t.Error("directive above attributes should apply")
+	}
+	if FuncIgnored(src, 7, rustComments) {
+		t.Error("an attribute is not a comment")
+	}
+	if !FuncIgnored(src, 10, rustComments) {
+		t.Error("a block comment directive should apply")
+	}
+	if FuncIgnored(src, 13, rustComments) {
+		t.Error("a comment after code on the same line should not apply")
+	}
+}
+
+func TestMapChangedFuncs_Ignore(t *testing.T) {
+	src := "package x\n\n//snare:ignore\nfunc A() int {\n\treturn 2\n}\n\nfunc B() int {\n\treturn 2\n}\n"
+	parent := "package x\n\n//snare:ignore\nfunc A() int {\n\treturn 1\n}\n\nfunc B() int {\n\treturn 1\n}\n"
+	fd := model.FileDiff{
+		NewName:      "x.go",
+		NewSource:    []byte(src),
+		ParentSource: []byte(parent),
+		Hunks:        []model.Hunk{{NewStartLine: 1, NewLineCount: 10}},
+	}
+	funcs, _, err := MapChangedFuncs([]model.FileDiff{fd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(funcs) != 1 || funcs[0].Name != "B" {
+		t.Errorf("funcs = %v, want only B", funcs)
+	}
+
+	fd.NewSource = []byte("//snare:ignore\n" + src)
+	funcs, skipped, _ := MapChangedFuncs([]model.FileDiff{fd})
+	if len(funcs) != 0 || len(skipped) != 1 || skipped[0].Reason != SkipIgnored {
+		t.Errorf("ignored file: funcs %v, skipped %v", funcs, skipped)
+	}
+}
+
+func TestFilterFuncs(t *testing.T) {
+	funcs := []model.ChangedFunc{
+		{Name: "Start", QualifiedName: "Server.Start"},
+		{Name: "Stop", QualifiedName: "Server.Stop"},
+		{Name: "parse"},
+	}
+	names := func(fs []model.ChangedFunc) []string {
+		var out []string
+		for _, f := range fs {
+			out = append(out, f.ID())
+		}
+		return out
+	}
+
+	if got := FilterFuncs(funcs, nil, nil); len(got) != 3 {
+		t.Errorf("no filters: %v", names(got))
+	}
+	if got := FilterFuncs(funcs, regexp.MustCompile(`^Server\.`), nil); len(got) != 2 {
+		t.Errorf("include Server.*: %v", names(got))
+	}
+	if got := FilterFuncs(funcs, regexp.MustCompile(`^Server\.`), regexp.MustCompile(`Stop$`)); len(got) != 1 || got[0].ID() != "Server.Start" {
+		t.Errorf("include Server.* exclude Stop: %v", names(got))
+	}
+}
+
+func TestMapChangedFuncs_SkipsGenerated(t *testing.T) {
+	src := "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage x\n\nfunc A() int {\n\treturn 2\n}\n"
+	fd := model.FileDiff{
+		NewName:      "x.pb.go",
+		NewSource:    []byte(src),
+		ParentSource: []byte(strings.Replace(src, "2", "1", 1)),
+		Hunks:        []model.Hunk{{NewStartLine: 6, NewLineCount: 1}},
+	}
+	funcs, skipped, err := MapChangedFuncs([]model.FileDiff{fd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(funcs) != 0 || len(skipped) != 1 || skipped[0].Reason != SkipGenerated || skipped[0].FilePath != "x.pb.go" {
+		t.Errorf("funcs %v, skipped %v", funcs, skipped)
+	}
+
+	// The marker must be a whole line comment, not a mention in code
+	fd.NewSource = []byte("package x\n\nvar s = \"// Code generated by x. DO NOT EDIT.\"\n")
+	if _, skipped, _ := MapChangedFuncs([]model.FileDiff{fd}); len(skipped) != 0 {
+		t.Errorf("string literal treated as marker: %v", skipped)
+	}
+}
+
+func TestHasGeneratedMarker(t *testing.T) {
+	if !hasGeneratedMarker([]byte("# Code generated by tool. DO NOT EDIT.\nimport os\n")) {
+		t.Error("python marker not detected")
+	}
+	if hasGeneratedMarker([]byte("# Code generated by hand, edit freely\n")) {
+		t.Error("false positive")
+	}
+}
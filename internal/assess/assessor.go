@@ -3,7 +3,7 @@ package assess
 import (
 	"context"
 
-	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/yiyuanh/snare/internal/llm"
 	"github.com/yiyuanh/snare/pkg/model"
 )
 
@@ -24,16 +24,17 @@ func NewChain(assessors ...Assessor) *Chain {
 
 // DefaultCatchingChain returns the assessment chain for the catching workflow.
 // It includes rule-based pattern matching and optionally an LLM judge.
-func DefaultCatchingChain(client *anthropic.Client, modelID string, ctx context.Context, verbose bool, commitMessage string) *Chain {
+func DefaultCatchingChain(provider llm.Provider, modelID string, ctx context.Context, verbose bool, commitMessage string) *Chain {
 	assessors := []Assessor{
 		&CompilationFilter{},
 		&CatchingAssessor{},
+		&MutantKillAssessor{},
 		&FalsePositivePatterns{},
 		&TruePositivePatterns{},
 	}
 
-	if client != nil {
-		assessors = append(assessors, NewLLMJudge(client, modelID, ctx, verbose, commitMessage))
+	if provider != nil {
+		assessors = append(assessors, NewLLMJudge(provider, modelID, ctx, verbose, commitMessage))
 	}
 
 	return NewChain(assessors...)
@@ -45,6 +46,7 @@ func DefaultRuleOnlyChain() *Chain {
 	return NewChain(
 		&CompilationFilter{},
 		&CatchingAssessor{},
+		&MutantKillAssessor{},
 		&FalsePositivePatterns{},
 		&TruePositivePatterns{},
 	)
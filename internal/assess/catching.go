@@ -49,6 +49,22 @@ func (a *CatchingAssessor) Assess(result *model.TestResult) {
 	result.Assessment = 0.5 // neutral starting point for weak catch
 }
 
+// MutantKillAssessor weighs whether the test also fails on the risk mutant.
+// A catching test that kills its mutant guards the risk it was written for;
+// one that survives the mutant is failing on new code for some other reason.
+type MutantKillAssessor struct{}
+
+func (a *MutantKillAssessor) Assess(result *model.TestResult) {
+	if result.FilteredReason != "" || !result.IsCatching || !result.MutantApplied {
+		return
+	}
+	if result.KillsMutant {
+		result.Assessment += 0.1
+	} else {
+		result.Assessment -= 0.2
+	}
+}
+
 // FalsePositivePatterns implements patterns from the catching paper Table 2.
 // Each pattern checks for common false positive indicators and reduces assessment.
 type FalsePositivePatterns struct{}
@@ -168,3 +168,43 @@ func TestFalsePositivePatterns_UndefinedVariable(t *testing.T) {
 		t.Errorf("FilteredReason = %q, want %q", r.FilteredReason, "undefined variable (likely rename)")
 	}
 }
+
+func TestMutantKillAssessor_KillsMutant(t *testing.T) {
+	killed := model.TestResult{
+		PassParent:    true,
+		FailDiff:      true,
+		MutantApplied: true,
+		KillsMutant:   true,
+		DiffOutput:    "FAIL",
+	}
+	survived := killed
+	survived.KillsMutant = false
+
+	evaluated := DefaultRuleOnlyChain().Evaluate([]model.TestResult{killed, survived})
+
+	if !evaluated[0].IsCatching || !evaluated[1].IsCatching {
+		t.Fatal("IsCatching should be true for both results")
+	}
+	if evaluated[0].Assessment <= evaluated[1].Assessment {
+		t.Errorf("Assessment for killed mutant (%f) should exceed survived mutant (%f)",
+			evaluated[0].Assessment, evaluated[1].Assessment)
+	}
+}
+
+func TestMutantKillAssessor_NotApplied(t *testing.T) {
+	results := []model.TestResult{
+		{
+			PassParent:  true,
+			FailDiff:    true,
+			MutantError: "original snippet not found in source",
+			DiffOutput:  "FAIL",
+		},
+	}
+
+	evaluated := DefaultRuleOnlyChain().Evaluate(results)
+
+	// Without a mutant leg the rule-based score is left at the weak catch baseline
+	if evaluated[0].Assessment != 0.5 {
+		t.Errorf("Assessment = %f, want 0.5 when mutant was not applied", evaluated[0].Assessment)
+	}
+}
@@ -2,17 +2,16 @@ package assess
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 
-	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/yiyuanh/snare/internal/llm"
 	"github.com/yiyuanh/snare/pkg/model"
 )
 
-// LLMJudge uses Claude to assess whether a weak catch is a true or false positive.
+// LLMJudge uses an LLM to assess whether a weak catch is a true or false positive.
 type LLMJudge struct {
-	client        *anthropic.Client
+	provider      llm.Provider
 	model         string
 	ctx           context.Context
 	verbose       bool
@@ -20,9 +19,9 @@ type LLMJudge struct {
 }
 
 // NewLLMJudge creates a new LLM-based assessor.
-func NewLLMJudge(client *anthropic.Client, modelID string, ctx context.Context, verbose bool, commitMessage string) *LLMJudge {
+func NewLLMJudge(provider llm.Provider, modelID string, ctx context.Context, verbose bool, commitMessage string) *LLMJudge {
 	return &LLMJudge{
-		client:        client,
+		provider:      provider,
 		model:         modelID,
 		ctx:           ctx,
 		verbose:       verbose,
@@ -36,6 +35,27 @@ type judgeResponse struct {
 	Question       string  `json:"question"`
 }
 
+var (
+	minAssessment = -1.0
+	maxAssessment = 1.0
+)
+
+// judgeTool is the tool the judge calls to submit its verdict; its schema
+// mirrors judgeResponse.
+var judgeTool = &llm.Tool{
+	Name:        "submit_assessment",
+	Description: "Submit the assessment of whether the test failure is an unexpected bug or an intended behavior change.",
+	Schema: &llm.Schema{
+		Type:     "object",
+		Required: []string{"assessment", "behavior_change", "question"},
+		Properties: map[string]*llm.Schema{
+			"assessment":      {Type: "number", Minimum: &minAssessment, Maximum: &maxAssessment, Description: "1.0 = clearly a bug, -1.0 = clearly intended, 0 = ambiguous"},
+			"behavior_change": {Type: "string", MinLength: 1, Description: "One sentence describing the behavioral change detected"},
+			"question":        {Type: "string", MinLength: 1, Description: "One 'Is it expected that...' question for the developer"},
+		},
+	},
+}
+
 func (j *LLMJudge) Assess(result *model.TestResult) {
 	// Only assess weak catches (tests that pass on parent and fail on new code)
 	if result.FilteredReason != "" || !result.IsCatching {
@@ -44,13 +64,13 @@ func (j *LLMJudge) Assess(result *model.TestResult) {
 
 	prompt := buildJudgePrompt(result, j.commitMessage)
 
-	resp, err := j.client.Messages.New(j.ctx, anthropic.MessageNewParams{
-		Model:     anthropic.Model(j.model),
+	ctx := llm.WithLabel(j.ctx, llm.StageJudge, result.Mutant.FuncName)
+	jr, err := llm.CompleteJSON[judgeResponse](ctx, j.provider, llm.Request{
+		Model:     j.model,
+		Prompt:    prompt,
 		MaxTokens: 1024,
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
-		},
-	})
+		Tool:      judgeTool,
+	}, nil)
 	if err != nil {
 		if j.verbose {
 			fmt.Printf("  [judge] LLM assessment failed for %s: %v\n", result.Test.TestName, err)
@@ -58,34 +78,6 @@ func (j *LLMJudge) Assess(result *model.TestResult) {
 		return // Keep existing assessment on failure
 	}
 
-	var text string
-	for _, block := range resp.Content {
-		if block.Type == "text" {
-			text = block.Text
-			break
-		}
-	}
-
-	text = strings.TrimSpace(text)
-	// Strip code fences if present
-	if strings.HasPrefix(text, "```json") {
-		text = strings.TrimPrefix(text, "```json")
-	} else if strings.HasPrefix(text, "```") {
-		text = strings.TrimPrefix(text, "```")
-	}
-	if strings.HasSuffix(text, "```") {
-		text = strings.TrimSuffix(text, "```")
-	}
-	text = strings.TrimSpace(text)
-
-	var jr judgeResponse
-	if err := json.Unmarshal([]byte(text), &jr); err != nil {
-		if j.verbose {
-			fmt.Printf("  [judge] Failed to parse judge response for %s: %v\n", result.Test.TestName, err)
-		}
-		return
-	}
-
 	// Combine rule-based and LLM scores: weighted average (60% LLM, 40% rules)
 	ruleScore := result.Assessment
 	llmScore := jr.Assessment
@@ -151,7 +143,15 @@ func buildJudgePrompt(result *model.TestResult, commitMessage string) string {
 
 	sb.WriteString("## Risk being tested\n")
 	sb.WriteString(result.Mutant.Description)
-	sb.WriteString("\n\n")
+	sb.WriteString("\n")
+	if result.MutantApplied {
+		if result.KillsMutant {
+			sb.WriteString("The test also fails when this risk is injected into the old code as a mutant, so it guards this risk.\n")
+		} else {
+			sb.WriteString("The test still passes when this risk is injected into the old code as a mutant, so the failure on new code is unrelated to this risk.\n")
+		}
+	}
+	sb.WriteString("\n")
 
 	if commitMessage != "" {
 		sb.WriteString("## Commit Context\n")
@@ -173,7 +173,7 @@ Analyze whether this test failure represents:
 - An **expected/intentional** behavior change (negative score)
 - Unclear/ambiguous (score near 0)
 
-Respond with ONLY a JSON object:
+Submit your verdict by calling the submit_assessment tool with this input (if the tool is unavailable, respond with ONLY this JSON object):
 {
   "assessment": <float from -1.0 to 1.0>,
   "behavior_change": "<one-sentence description of what behavioral change was detected>",
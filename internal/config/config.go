@@ -0,0 +1,136 @@
+// Package config loads the project configuration file (.snare.yaml).
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yiyuanh/snare/internal/glob"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the config file names looked for in the project root, in order.
+var FileNames = []string{".snare.yaml", ".snare.yml"}
+
+// Settings are the options that can be overridden per path. Nil fields are unset.
+type Settings struct {
+	Model      *string        `yaml:"model"`
+	Timeout    *time.Duration `yaml:"timeout"`
+	MaxTests   *int           `yaml:"max_tests"`
+	Judge      *bool          `yaml:"judge"`
+	Thresholds Thresholds     `yaml:"thresholds"`
+}
+
+// Thresholds tune how results are classified.
+type Thresholds struct {
+	LikelyBug *float64 `yaml:"likely_bug"` // minimum assessment for a weak catch to count as a likely bug
+}
+
+// Override applies Settings to files under a path (a glob, or a directory with a trailing slash).
+type Override struct {
+	Path     string `yaml:"path"`
+	Settings `yaml:",inline"`
+}
+
+// Config is the contents of a .snare.yaml file.
+type Config struct {
+	Settings `yaml:",inline"`
+
+	Provider  string   `yaml:"provider"`
+	BaseURL   string   `yaml:"base_url"`
+	Include   []string `yaml:"include"`
+	Exclude   []string `yaml:"exclude"`
+	Telemetry string   `yaml:"telemetry"`
+	Budget    string   `yaml:"budget"`
+	Jobs      *int     `yaml:"jobs"`
+	GenJobs   *int     `yaml:"gen_jobs"`
+	JSRunner  string   `yaml:"js_runner"` // jest, vitest, node or auto
+
+	IncludeFunc       string `yaml:"include_func"` // regexp over qualified function names
+	ExcludeFunc       string `yaml:"exclude_func"`
+	NoDefaultExcludes bool   `yaml:"no_default_excludes"`
+
+	Overrides []Override `yaml:"overrides"`
+
+	// Path is the file the config was loaded from.
+	Path string `yaml:"-"`
+	// Flags holds settings given on the command line; they win over the
+	// file, including its per-path overrides.
+	Flags Settings `yaml:"-"`
+}
+
+// Find returns the path of the config file in root, or "" if there is none.
+func Find(root string) string {
+	for _, name := range FileNames {
+		path := filepath.Join(root, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Load reads and validates a config file. Unknown keys are errors, so typos
+// don't silently fall back to defaults.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	cfg.Path = path
+
+	for i, o := range cfg.Overrides {
+		if o.Path == "" {
+			return nil, fmt.Errorf("parsing %s: overrides[%d] has no path", path, i)
+		}
+	}
+	return &cfg, nil
+}
+
+// For returns the settings for a project-relative file path: top-level
+// values, then each matching override in file order, then command-line flags.
+// A nil config yields only empty settings.
+func (c *Config) For(relPath string) Settings {
+	if c == nil {
+		return Settings{}
+	}
+	s := c.Settings
+	for _, o := range c.Overrides {
+		if glob.Match(o.Path, relPath) {
+			s = s.Merge(o.Settings)
+		}
+	}
+	return s.Merge(c.Flags)
+}
+
+// Merge returns s with every field set in o replacing s's value.
+func (s Settings) Merge(o Settings) Settings {
+	if o.Model != nil {
+		s.Model = o.Model
+	}
+	if o.Timeout != nil {
+		s.Timeout = o.Timeout
+	}
+	if o.MaxTests != nil {
+		s.MaxTests = o.MaxTests
+	}
+	if o.Judge != nil {
+		s.Judge = o.Judge
+	}
+	if o.Thresholds.LikelyBug != nil {
+		s.Thresholds.LikelyBug = o.Thresholds.LikelyBug
+	}
+	return s
+}
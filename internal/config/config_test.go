@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `model: claude-sonnet-4-5
+timeout: 30s
+max_tests: 6
+judge: true
+thresholds:
+  likely_bug: 0.6
+include: ["internal/**"]
+exclude: ["*.pb.go", "vendor/"]
+telemetry: telemetry.db
+jobs: 4
+overrides:
+  - path: integration/
+    timeout: 5m
+    judge: false
+  - path: integration/slow/
+    timeout: 10m
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".snare.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeConfig(t, sample)
+	path := Find(dir)
+	if path != filepath.Join(dir, ".snare.yaml") {
+		t.Fatalf("Find = %q", path)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *cfg.Model != "claude-sonnet-4-5" || *cfg.Timeout != 30*time.Second || *cfg.MaxTests != 6 {
+		t.Errorf("settings = %q %v %d", *cfg.Model, *cfg.Timeout, *cfg.MaxTests)
+	}
+	if *cfg.Thresholds.LikelyBug != 0.6 || *cfg.Jobs != 4 || cfg.Telemetry != "telemetry.db" {
+		t.Errorf("thresholds/jobs/telemetry not loaded: %+v", cfg)
+	}
+	if len(cfg.Exclude) != 2 || len(cfg.Overrides) != 2 {
+		t.Errorf("exclude = %v, overrides = %v", cfg.Exclude, cfg.Overrides)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown key":      "modle: x\n",
+		"override no path": "overrides:\n  - timeout: 1m\n",
+		"bad duration":     "timeout: soon\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(Find(writeConfig(t, content))); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestLoad_Empty(t *testing.T) {
+	cfg, err := Load(Find(writeConfig(t, "")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := cfg.For("a.go"); s.Model != nil || s.Timeout != nil {
+		t.Errorf("empty config has settings: %+v", s)
+	}
+}
+
+func TestFind_None(t *testing.T) {
+	if path := Find(t.TempDir()); path != "" {
+		t.Errorf("Find = %q, want none", path)
+	}
+}
+
+func TestFor(t *testing.T) {
+	cfg, err := Load(Find(writeConfig(t, sample)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := cfg.For("internal/a.go")
+	if *s.Timeout != 30*time.Second || !*s.Judge {
+		t.Errorf("top-level: timeout %v judge %v", *s.Timeout, *s.Judge)
+	}
+
+	s = cfg.For("integration/db/store.go")
+	if *s.Timeout != 5*time.Minute || *s.Judge || *s.MaxTests != 6 {
+		t.Errorf("integration/: timeout %v judge %v max %d", *s.Timeout, *s.Judge, *s.MaxTests)
+	}
+
+	// Later overrides win, earlier ones still apply
+	s = cfg.For("integration/slow/big.go")
+	if *s.Timeout != 10*time.Minute || *s.Judge {
+		t.Errorf("integration/slow/: timeout %v judge %v", *s.Timeout, *s.Judge)
+	}
+
+	// Flags win over the file, including overrides
+	timeout := time.Minute
+	cfg.Flags.Timeout = &timeout
+	if s := cfg.For("integration/slow/big.go"); *s.Timeout != time.Minute {
+		t.Errorf("flag timeout = %v, want 1m", *s.Timeout)
+	}
+}
+
+func TestFor_NilConfig(t *testing.T) {
+	var cfg *Config
+	if s := cfg.For("a.go"); s.Model != nil {
+		t.Errorf("nil config: %+v", s)
+	}
+}
+
+func TestLoad_ErrorNamesFile(t *testing.T) {
+	path := Find(writeConfig(t, "max_tests: many\n"))
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("error %v should name %s", err, path)
+	}
+}
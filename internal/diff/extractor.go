@@ -1,18 +1,51 @@
 package diff
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/bluekeyes/go-gitdiff/gitdiff"
+	"github.com/yiyuanh/snare/internal/glob"
+	"github.com/yiyuanh/snare/internal/lang"
 	"github.com/yiyuanh/snare/pkg/model"
 )
 
+// DefaultExcludes are generated, vendored and mock files, which rarely
+// deserve generated tests.
+var DefaultExcludes = []string{
+	"*.pb.go",
+	"*.pb.gw.go",
+	"*_pb2.py",
+	"*_pb2_grpc.py",
+	"zz_generated*",
+	"vendor/",
+	"mocks/",
+	"mock_*.go",
+	"*_mock.go",
+	"*.d.ts",
+	"*.min.js",
+	"node_modules/",
+}
+
 // Extractor extracts and parses git diffs.
 type Extractor struct {
 	Dir string
+
+	// Include, if set, limits diffs to files matching one of these globs;
+	// files matching any Exclude glob are dropped. Patterns match
+	// repo-relative paths (see package glob).
+	Include []string
+	Exclude []string
+	// Defaults are excluded like Exclude, but since nobody asked for them
+	// on this run, each file they drop is recorded in Skipped.
+	Defaults []string
+
+	// Skipped lists the files the last extraction dropped because of Defaults.
+	Skipped []model.SkippedFile
 }
 
 // NewExtractor creates a new diff extractor for the given directory.
@@ -61,6 +94,160 @@ func (e *Extractor) Extract(staged bool, commit string) ([]model.FileDiff, error
 	return diffs, nil
 }
 
+// ExtractRange diffs two revisions and parses the output. Parent sources are
+// read from the from revision and new sources from the to revision, so the
+// working tree is never consulted.
+func (e *Extractor) ExtractRange(from, to string) ([]model.FileDiff, error) {
+	args := []string{"diff", from, to}
+	raw, err := e.git(args...)
+	if err != nil {
+		return nil, fmt.Errorf("git diff: %w", err)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	diffs, err := e.parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range diffs {
+		if diffs[i].OldName != "" {
+			parentSrc, err := e.git("show", from+":"+diffs[i].OldName)
+			if err == nil {
+				diffs[i].ParentSource = []byte(parentSrc)
+			}
+		}
+		newSrc, err := e.getSourceAtCommit(diffs[i].NewName, to)
+		if err != nil {
+			continue
+		}
+		diffs[i].NewSource = newSrc
+	}
+
+	return diffs, nil
+}
+
+// ExtractPatch parses a patch that is not in the repository's history, such as
+// one exported from a review system. Parent sources are read from parent, which
+// is either a directory holding the pre-patch tree or a git revision. New
+// sources are produced by applying the patch to the parent sources, so the
+// working tree does not need to have the patch applied.
+func (e *Extractor) ExtractPatch(raw string, parent string) ([]model.FileDiff, error) {
+	files, _, err := e.parseFiles(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	readParent := func(name string) ([]byte, error) {
+		if info, err := os.Stat(parent); err == nil && info.IsDir() {
+			return os.ReadFile(filepath.Join(parent, name))
+		}
+		out, err := e.git("show", parent+":"+name)
+		return []byte(out), err
+	}
+
+	var result []model.FileDiff
+	for _, f := range files {
+		fd := e.toFileDiff(f)
+
+		var parentSrc []byte
+		if !f.IsNew && f.OldName != "" {
+			parentSrc, err = readParent(f.OldName)
+			if err != nil {
+				return nil, fmt.Errorf("reading parent of %s from %s: %w", f.OldName, parent, err)
+			}
+			fd.ParentSource = parentSrc
+		}
+
+		var newSrc bytes.Buffer
+		if err := gitdiff.Apply(&newSrc, bytes.NewReader(parentSrc), f); err != nil {
+			return nil, fmt.Errorf("applying patch to %s: %w", fd.NewName, err)
+		}
+		fd.NewSource = newSrc.Bytes()
+
+		result = append(result, fd)
+	}
+	return result, nil
+}
+
+// GetPatchMessage returns the commit message from a patch header
+// (as written by git format-patch), or "" if the patch has none.
+func GetPatchMessage(raw string) string {
+	_, preamble, err := gitdiff.Parse(strings.NewReader(raw))
+	if err != nil || strings.TrimSpace(preamble) == "" {
+		return ""
+	}
+	header, err := gitdiff.ParsePatchHeader(preamble)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(header.Message())
+}
+
+// MergeBase returns the best common ancestor of two revisions.
+func (e *Extractor) MergeBase(a, b string) (string, error) {
+	out, err := e.git("merge-base", a, b)
+	if err != nil {
+		return "", fmt.Errorf("git merge-base: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ResolveRange turns a revision range into the from/to revisions to diff.
+// "A..B" diffs A against B, "A...B" diffs the merge-base of A and B against B,
+// and an empty side defaults to HEAD (as in git).
+func (e *Extractor) ResolveRange(spec string) (from, to string, err error) {
+	sep := ".."
+	symmetric := false
+	if strings.Contains(spec, "...") {
+		sep = "..."
+		symmetric = true
+	}
+	parts := strings.SplitN(spec, sep, 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid range %q (want A..B or A...B)", spec)
+	}
+	from, to = parts[0], parts[1]
+	if from == "" {
+		from = "HEAD"
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	if symmetric {
+		from, err = e.MergeBase(from, to)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return from, to, nil
+}
+
+// GetRangeMessage returns the messages of all commits in from..to, newest first,
+// for use as context when a diff spans several commits.
+func (e *Extractor) GetRangeMessage(from, to string) (string, error) {
+	out, err := e.git("log", "--format=%B", from+".."+to)
+	if err != nil {
+		return "", fmt.Errorf("git log: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// git runs a git command in the extractor's directory and returns stdout.
+func (e *Extractor) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = e.Dir
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("%s: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
 func (e *Extractor) runGitDiff(staged bool, commit string) (string, error) {
 	var args []string
 	switch {
@@ -143,12 +330,28 @@ func (e *Extractor) GetCommitMessage(commit string) (string, error) {
 }
 
 func (e *Extractor) parse(raw string) ([]model.FileDiff, error) {
-	files, _, err := gitdiff.Parse(strings.NewReader(raw))
+	files, _, err := e.parseFiles(raw)
 	if err != nil {
-		return nil, fmt.Errorf("parsing diff: %w", err)
+		return nil, err
 	}
 
 	var result []model.FileDiff
+	for _, f := range files {
+		result = append(result, e.toFileDiff(f))
+	}
+	return result, nil
+}
+
+// parseFiles parses a unified diff and keeps supported, non-test source files
+// that pass the include/exclude globs and have at least one text fragment. It also returns the patch preamble.
+func (e *Extractor) parseFiles(raw string) ([]*gitdiff.File, string, error) {
+	files, preamble, err := gitdiff.Parse(strings.NewReader(raw))
+	if err != nil {
+		return nil, "", fmt.Errorf("parsing diff: %w", err)
+	}
+
+	e.Skipped = nil
+	var result []*gitdiff.File
 	for _, f := range files {
 		name := f.NewName
 		if name == "" {
@@ -156,66 +359,77 @@ func (e *Extractor) parse(raw string) ([]model.FileDiff, error) {
 		}
 
 		// Filter to supported source files, exclude test files
-		if !isSourceFile(name) {
+		if !lang.IsSourceFile(name) {
 			continue
 		}
-		if isTestFile(name) {
+		if !e.wanted(name) {
 			continue
 		}
-
-		fd := model.FileDiff{
-			OldName: f.OldName,
-			NewName: name,
+		if len(f.TextFragments) == 0 {
+			continue
+		}
+		if pattern := e.defaultExclude(name); pattern != "" {
+			e.Skipped = append(e.Skipped, model.SkippedFile{FilePath: filepath.Join(e.Dir, name), Reason: "default exclude " + pattern})
+			continue
 		}
+		result = append(result, f)
+	}
+	return result, preamble, nil
+}
 
-		for _, frag := range f.TextFragments {
-			hunk := model.Hunk{
-				OldStartLine: int(frag.OldPosition),
-				OldLineCount: int(frag.OldLines),
-				NewStartLine: int(frag.NewPosition),
-				NewLineCount: int(frag.NewLines),
-			}
+// toFileDiff converts a parsed gitdiff file into a FileDiff with an absolute NewName.
+func (e *Extractor) toFileDiff(f *gitdiff.File) model.FileDiff {
+	name := f.NewName
+	if name == "" {
+		name = f.OldName
+	}
 
-			var lines []string
-			for _, line := range frag.Lines {
-				prefix := " "
-				switch line.Op {
-				case gitdiff.OpAdd:
-					prefix = "+"
-				case gitdiff.OpDelete:
-					prefix = "-"
-				}
-				lines = append(lines, prefix+line.Line)
-			}
-			hunk.Content = strings.Join(lines, "\n")
-			fd.Hunks = append(fd.Hunks, hunk)
+	fd := model.FileDiff{
+		OldName: f.OldName,
+		// Resolve to absolute path for later file reading
+		NewName: filepath.Join(e.Dir, name),
+	}
+
+	for _, frag := range f.TextFragments {
+		hunk := model.Hunk{
+			OldStartLine: int(frag.OldPosition),
+			OldLineCount: int(frag.OldLines),
+			NewStartLine: int(frag.NewPosition),
+			NewLineCount: int(frag.NewLines),
 		}
 
-		if len(fd.Hunks) > 0 {
-			// Resolve to absolute path for later file reading
-			fd.NewName = filepath.Join(e.Dir, name)
-			result = append(result, fd)
+		var lines []string
+		for _, line := range frag.Lines {
+			prefix := " "
+			switch line.Op {
+			case gitdiff.OpAdd:
+				prefix = "+"
+			case gitdiff.OpDelete:
+				prefix = "-"
+			}
+			lines = append(lines, prefix+line.Line)
 		}
+		hunk.Content = strings.Join(lines, "\n")
+		fd.Hunks = append(fd.Hunks, hunk)
 	}
-	return result, nil
+	return fd
 }
 
-// isSourceFile returns true if the file is a supported source file.
-func isSourceFile(name string) bool {
-	return strings.HasSuffix(name, ".go") || strings.HasSuffix(name, ".py")
+// wanted reports whether a repo-relative path passes the include/exclude globs.
+func (e *Extractor) wanted(name string) bool {
+	if len(e.Include) > 0 && !glob.MatchAny(e.Include, name) {
+		return false
+	}
+	return !glob.MatchAny(e.Exclude, name)
 }
 
-// isTestFile returns true if the file is a test file in any supported language.
-func isTestFile(name string) bool {
-	if strings.HasSuffix(name, "_test.go") {
-		return true
-	}
-	base := filepath.Base(name)
-	if strings.HasPrefix(base, "test_") && strings.HasSuffix(base, ".py") {
-		return true
-	}
-	if strings.HasSuffix(name, "_test.py") {
-		return true
+// defaultExclude returns the first of e.Defaults that matches a repo-relative
+// path, or "".
+func (e *Extractor) defaultExclude(name string) string {
+	for _, pattern := range e.Defaults {
+		if glob.Match(pattern, name) {
+			return pattern
+		}
 	}
-	return false
+	return ""
 }
@@ -1,7 +1,13 @@
 package diff
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/yiyuanh/snare/pkg/model"
 )
 
 func TestParse_GoFilesOnly(t *testing.T) {
@@ -61,6 +67,42 @@ index 1234567..abcdefg 100644
 	}
 }
 
+func TestParse_IncludeExclude(t *testing.T) {
+	var raw strings.Builder
+	for _, name := range []string{"internal/a.go", "internal/api/user.pb.go", "vendor/x/y.go", "cmd/main.go", "internal/mocks/store.go"} {
+		raw.WriteString("diff --git a/" + name + " b/" + name + "\n--- a/" + name + "\n+++ b/" + name + "\n@@ -1 +1,2 @@\n package x\n+// c\n")
+	}
+	names := func(e *Extractor) []string {
+		result, err := e.parse(raw.String())
+		if err != nil {
+			t.Fatalf("parse returned error: %v", err)
+		}
+		var out []string
+		for _, fd := range result {
+			out = append(out, fd.OldName)
+		}
+		return out
+	}
+
+	if got := names(&Extractor{Dir: "/fake"}); len(got) != 5 {
+		t.Errorf("no filters: %v", got)
+	}
+	defaults := &Extractor{Dir: "/fake", Defaults: DefaultExcludes}
+	if got := names(defaults); strings.Join(got, ",") != "internal/a.go,cmd/main.go" {
+		t.Errorf("default excludes: %v", got)
+	}
+	if len(defaults.Skipped) != 3 || defaults.Skipped[0] != (model.SkippedFile{FilePath: "/fake/internal/api/user.pb.go", Reason: "default exclude *.pb.go"}) {
+		t.Errorf("skipped = %+v, want the three default-excluded files", defaults.Skipped)
+	}
+	if got := names(&Extractor{Dir: "/fake", Include: []string{"internal/**"}, Defaults: DefaultExcludes}); strings.Join(got, ",") != "internal/a.go" {
+		t.Errorf("include internal/**: %v", got)
+	}
+	explicit := &Extractor{Dir: "/fake", Exclude: []string{"vendor/"}}
+	if got := names(explicit); len(got) != 4 || len(explicit.Skipped) != 0 {
+		t.Errorf("explicit exclude: %v, skipped %+v", got, explicit.Skipped)
+	}
+}
+
 func TestParse_HunkContent(t *testing.T) {
 	raw := `diff --git a/pkg/util.go b/pkg/util.go
 index 1234567..abcdefg 100644
@@ -99,3 +141,164 @@ index 1234567..abcdefg 100644
 		t.Error("hunk content is empty")
 	}
 }
+
+// initRepo creates a git repository with main.go committed on main, then a
+// feature branch with two more commits changing it. Returns the repo dir.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=snare", "GIT_AUTHOR_EMAIL=snare@example.com",
+			"GIT_COMMITTER_NAME=snare", "GIT_COMMITTER_EMAIL=snare@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0o644); err != nil {
+			t.Fatalf("writing main.go: %v", err)
+		}
+	}
+
+	run("init", "-q", "-b", "main")
+	write("package main\n\nfunc f() int {\n\treturn 1\n}\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "base")
+	run("checkout", "-q", "-b", "feature")
+	write("package main\n\nfunc f() int {\n\treturn 2\n}\n")
+	run("commit", "-q", "-am", "first change")
+	write("package main\n\nfunc f() int {\n\treturn 3\n}\n")
+	run("commit", "-q", "-am", "second change")
+	return dir
+}
+
+func TestExtractRange_MergeBase(t *testing.T) {
+	dir := initRepo(t)
+	e := NewExtractor(dir)
+
+	from, err := e.MergeBase("main", "HEAD")
+	if err != nil {
+		t.Fatalf("MergeBase: %v", err)
+	}
+
+	diffs, err := e.ExtractRange(from, "HEAD")
+	if err != nil {
+		t.Fatalf("ExtractRange: %v", err)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("len(diffs) = %d, want 1", len(diffs))
+	}
+	if !strings.Contains(string(diffs[0].ParentSource), "return 1") {
+		t.Errorf("ParentSource = %q, want merge-base version", diffs[0].ParentSource)
+	}
+	if !strings.Contains(string(diffs[0].NewSource), "return 3") {
+		t.Errorf("NewSource = %q, want HEAD version", diffs[0].NewSource)
+	}
+
+	msg, err := e.GetRangeMessage(from, "HEAD")
+	if err != nil {
+		t.Fatalf("GetRangeMessage: %v", err)
+	}
+	if !strings.Contains(msg, "first change") || !strings.Contains(msg, "second change") {
+		t.Errorf("GetRangeMessage = %q, want both commit messages", msg)
+	}
+}
+
+func TestResolveRange(t *testing.T) {
+	dir := initRepo(t)
+	e := NewExtractor(dir)
+
+	from, to, err := e.ResolveRange("main..feature")
+	if err != nil {
+		t.Fatalf("ResolveRange: %v", err)
+	}
+	if from != "main" || to != "feature" {
+		t.Errorf("ResolveRange(main..feature) = %q, %q", from, to)
+	}
+
+	from, to, err = e.ResolveRange("feature...main")
+	if err != nil {
+		t.Fatalf("ResolveRange: %v", err)
+	}
+	base, _ := e.MergeBase("feature", "main")
+	if from != base || to != "main" {
+		t.Errorf("ResolveRange(feature...main) = %q, %q, want %q, main", from, to, base)
+	}
+
+	if _, _, err := e.ResolveRange("main"); err == nil {
+		t.Error("expected error for range without separator")
+	}
+}
+
+const testPatch = `From 1234567890abcdef1234567890abcdef12345678 Mon Sep 17 00:00:00 2001
+From: Dev <dev@example.com>
+Date: Mon, 1 Jan 2024 00:00:00 +0000
+Subject: [PATCH] Return four
+
+---
+ main.go | 2 +-
+ 1 file changed, 1 insertion(+), 1 deletion(-)
+
+diff --git a/main.go b/main.go
+index 1234567..abcdefg 100644
+--- a/main.go
++++ b/main.go
+@@ -1,5 +1,5 @@
+ package main
+ 
+ func f() int {
+-	return 3
++	return 4
+ }
+`
+
+func TestExtractPatch_FromRevision(t *testing.T) {
+	dir := initRepo(t)
+	e := NewExtractor(dir)
+
+	diffs, err := e.ExtractPatch(testPatch, "HEAD")
+	if err != nil {
+		t.Fatalf("ExtractPatch: %v", err)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("len(diffs) = %d, want 1", len(diffs))
+	}
+	if !strings.Contains(string(diffs[0].ParentSource), "return 3") {
+		t.Errorf("ParentSource = %q, want HEAD version", diffs[0].ParentSource)
+	}
+	if !strings.Contains(string(diffs[0].NewSource), "return 4") {
+		t.Errorf("NewSource = %q, want patched version", diffs[0].NewSource)
+	}
+
+	if msg := GetPatchMessage(testPatch); msg != "Return four" {
+		t.Errorf("GetPatchMessage = %q, want %q", msg, "Return four")
+	}
+}
+
+func TestExtractPatch_FromDirectory(t *testing.T) {
+	parent := t.TempDir()
+	src := "package main\n\nfunc f() int {\n\treturn 3\n}\n"
+	if err := os.WriteFile(filepath.Join(parent, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatalf("writing parent: %v", err)
+	}
+
+	e := NewExtractor(t.TempDir())
+	diffs, err := e.ExtractPatch(testPatch, parent)
+	if err != nil {
+		t.Fatalf("ExtractPatch: %v", err)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("len(diffs) = %d, want 1", len(diffs))
+	}
+	if string(diffs[0].ParentSource) != src {
+		t.Errorf("ParentSource = %q, want %q", diffs[0].ParentSource, src)
+	}
+	if !strings.Contains(string(diffs[0].NewSource), "return 4") {
+		t.Errorf("NewSource = %q, want patched version", diffs[0].NewSource)
+	}
+}
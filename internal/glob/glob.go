@@ -0,0 +1,68 @@
+// Package glob matches slash-separated paths against gitignore-style patterns.
+package glob
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Match reports whether name matches pattern.
+//
+//   - "*", "?" and "[...]" match within one path segment, as in path.Match.
+//   - "**" matches any number of segments (including none).
+//   - A pattern without a slash matches at any depth: "*.pb.go" matches
+//     "api/v1/user.pb.go".
+//   - A trailing slash matches everything below a directory: "vendor/"
+//     matches "vendor/x/y.go" and "third_party/vendor/z.go".
+//   - Otherwise the pattern is anchored at the project root: "internal/**/*.go".
+func Match(pattern, name string) bool {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	name = strings.TrimPrefix(filepath.ToSlash(name), "./")
+
+	dir := strings.HasSuffix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return false
+	}
+
+	pat := strings.Split(pattern, "/")
+	if !strings.Contains(pattern, "/") {
+		pat = append([]string{"**"}, pat...)
+	}
+	if dir {
+		pat = append(pat, "*", "**")
+	}
+	return matchSegments(pat, strings.Split(name, "/"))
+}
+
+// MatchAny reports whether name matches any of the patterns.
+func MatchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if Match(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pat, segs []string) bool {
+	if len(pat) == 0 {
+		return len(segs) == 0
+	}
+	if pat[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(pat[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pat[0], segs[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pat[1:], segs[1:])
+}
@@ -0,0 +1,32 @@
+package glob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.pb.go", "api/v1/user.pb.go", true},
+		{"*.pb.go", "user.pb.go", true},
+		{"*.pb.go", "user.go", false},
+		{"zz_generated*", "pkg/apis/zz_generated.deepcopy.go", true},
+		{"vendor/", "vendor/github.com/x/y.go", true},
+		{"vendor/", "third_party/vendor/z.go", true},
+		{"vendor/", "vendor", false},
+		{"vendor/", "pkg/vendorish/a.go", false},
+		{"integration/", "integration/db/store.go", true},
+		{"internal/**/*.go", "internal/a/b/c.go", true},
+		{"internal/**/*.go", "internal/c.go", true},
+		{"internal/**/*.go", "cmd/internal/c.go", false},
+		{"internal/*.go", "internal/a/c.go", false},
+		{"./cmd/*.go", "cmd/run.go", true},
+		{"**/mocks/**", "pkg/mocks/store.go", true},
+		{"", "a.go", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package lang
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"go/parser"
 	"go/token"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/yiyuanh/snare/internal/analysis"
 	"github.com/yiyuanh/snare/pkg/model"
+	"golang.org/x/tools/go/packages"
 )
 
 // Go implements the Language interface for Go codebases.
@@ -22,6 +24,10 @@ func NewGo() *Go {
 	return &Go{}
 }
 
+func init() {
+	Register(NewGo())
+}
+
 func (g *Go) Name() string {
 	return "go"
 }
@@ -30,6 +36,38 @@ func (g *Go) FileExtensions() []string {
 	return []string{".go"}
 }
 
+func (g *Go) IsTestFile(path string) bool {
+	return strings.HasSuffix(path, "_test.go")
+}
+
+// TestFilePath puts the test next to the source file, in the same package.
+func (g *Go) TestFilePath(sourceFile string, testName string) string {
+	return filepath.Join(filepath.Dir(sourceFile), fmt.Sprintf("snare_%s_test.go", strings.ToLower(testName)))
+}
+
+func (g *Go) RootMarkers() []string {
+	return []string{"go.mod"}
+}
+
+func (g *Go) Prompt() PromptStyle {
+	return PromptStyle{
+		CodeFence:       "go",
+		PackageLabel:    "Package",
+		ImportPrefix:    "import ",
+		ShowSignature:   true,
+		TestFramework:   "Go test",
+		TestFileNote:    " with proper package declaration and imports",
+		TestNameExample: "TestFuncName_RiskDescription",
+		TestCodeExample: `"package pkg\n\nimport (\n\t\"testing\"\n)\n\nfunc TestFuncName_RiskDescription(t *testing.T) {\n\t// test body\n}"`,
+		Rules: []string{
+			"Each test must be a complete, self-contained Go test file",
+			`The package name in tests must be "{package}"`,
+			`Do not use any external test frameworks — only the standard "testing" package`,
+		},
+		Comments: analysis.GoComments,
+	}
+}
+
 func (g *Go) IdentifyChangedFuncs(filePath string, source []byte, hunks []model.Hunk) ([]model.ChangedFunc, error) {
 	fset := token.NewFileSet()
 	pkg, imports, typeDefs, funcs, err := analysis.ParseFunctions(fset, source)
@@ -52,16 +90,17 @@ func (g *Go) IdentifyChangedFuncs(filePath string, source []byte, hunks []model.
 		}
 
 		result = append(result, model.ChangedFunc{
-			FilePath:    filePath,
-			Package:     pkg,
-			Name:        fn.Name,
-			Signature:   fn.Signature,
-			Body:        fn.Body,
-			StartLine:   fn.StartLine,
-			EndLine:     fn.EndLine,
-			Imports:     imports,
-			TypeDefs:    typeDefs,
-			DiffContext: strings.Join(diffParts, "\n"),
+			FilePath:      filePath,
+			Package:       pkg,
+			Name:          fn.Name,
+			QualifiedName: fn.QualifiedName,
+			Signature:     fn.Signature,
+			Body:          fn.Body,
+			StartLine:     fn.StartLine,
+			EndLine:       fn.EndLine,
+			Imports:       imports,
+			TypeDefs:      typeDefs,
+			DiffContext:   strings.Join(diffParts, "\n"),
 		})
 	}
 	return result, nil
@@ -77,29 +116,106 @@ func overlapsHunks(fn analysis.FuncInfo, hunks []model.Hunk) bool {
 	return false
 }
 
-func (g *Go) ApplyMutant(originalSource []byte, original string, mutated string) ([]byte, error) {
-	result := strings.Replace(string(originalSource), original, mutated, 1)
-	if result == string(originalSource) {
-		return nil, fmt.Errorf("original snippet not found in source")
+func (g *Go) ApplyMutant(filePath string, source []byte, mutant model.Mutant) ([]byte, Site, error) {
+	result, site, err := replaceInFunc(g, filePath, source, mutant)
+	if err != nil {
+		return nil, site, err
 	}
 	// Validate that the mutated source is still valid Go
 	fset := token.NewFileSet()
 	if _, err := parser.ParseFile(fset, "", result, parser.AllErrors); err != nil {
-		return nil, fmt.Errorf("mutated code is not valid Go: %w", err)
+		return nil, site, fmt.Errorf("mutated code is not valid Go: %w", err)
 	}
-	return []byte(result), nil
+	return result, site, nil
 }
 
 func (g *Go) RunTest(dir string, testFile string, testFunc string, timeout time.Duration) (passed bool, output string, err error) {
 	// Determine the package directory from the test file
-	pkgDir := filepath.Dir(testFile)
+	return goTest(dir, filepath.Dir(testFile), timeout, "", "-v", "-run", fmt.Sprintf("^%s$", testFunc))
+}
+
+// RunSuite runs all tests in the package containing sourceFile.
+func (g *Go) RunSuite(dir string, sourceFile string, timeout time.Duration) (passed bool, output string, err error) {
+	return goTest(dir, filepath.Dir(sourceFile), timeout, "")
+}
+
+// RunTestOverlay runs a test in the module at root with `go test -overlay`,
+// so the module is neither copied nor modified.
+func (g *Go) RunTestOverlay(root string, overlay map[string][]byte, testFile, testFunc string, timeout time.Duration) (passed bool, output string, err error) {
+	overlayFile, cleanup, err := writeOverlay(root, overlay)
+	if err != nil {
+		return false, "", err
+	}
+	defer cleanup()
+	return goTest(root, filepath.Dir(testFile), timeout, overlayFile, "-v", "-run", fmt.Sprintf("^%s$", testFunc))
+}
+
+// RunSuiteOverlay runs the package's tests in the module at root with `go
+// test -overlay`.
+func (g *Go) RunSuiteOverlay(root string, overlay map[string][]byte, sourceFile string, timeout time.Duration) (passed bool, output string, err error) {
+	overlayFile, cleanup, err := writeOverlay(root, overlay)
+	if err != nil {
+		return false, "", err
+	}
+	defer cleanup()
+	return goTest(root, filepath.Dir(sourceFile), timeout, overlayFile)
+}
+
+// writeOverlay writes each file of overlay (paths relative to root) to a
+// temp dir, and an overlay JSON file for the go command that replaces the
+// real paths with them. cleanup removes the temp dir.
+func writeOverlay(root string, overlay map[string][]byte) (overlayFile string, cleanup func(), err error) {
+	tmpDir, err := os.MkdirTemp("", "snare-overlay-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("creating overlay dir: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(tmpDir) }
 
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("resolving module dir: %w", err)
+	}
+	replace := make(map[string]string, len(overlay))
+	i := 0
+	for rel, content := range overlay {
+		// Numbered, since files in different packages may share a name
+		path := filepath.Join(tmpDir, fmt.Sprintf("%d_%s", i, filepath.Base(rel)))
+		i++
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("writing overlay file: %w", err)
+		}
+		replace[filepath.Join(absRoot, rel)] = path
+	}
+
+	data, err := json.Marshal(struct{ Replace map[string]string }{replace})
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("encoding overlay: %w", err)
+	}
+	overlayFile = filepath.Join(tmpDir, "overlay.json")
+	if err := os.WriteFile(overlayFile, data, 0o644); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("writing overlay: %w", err)
+	}
+	return overlayFile, cleanup, nil
+}
+
+// goTest runs `go test` for the package in pkgDir (relative to dir), with
+// the -overlay file overlayFile unless it is "".
+func goTest(dir string, pkgDir string, timeout time.Duration, overlayFile string, extraArgs ...string) (passed bool, output string, err error) {
 	// Use "./" prefix so Go treats the path as a local directory, not a module import path
 	localPkg := "./" + pkgDir
 	if pkgDir == "." {
 		localPkg = "./"
 	}
-	args := []string{"test", "-v", "-count=1", fmt.Sprintf("-timeout=%s", timeout), "-run", fmt.Sprintf("^%s$", testFunc), localPkg}
+	args := []string{"test", "-count=1", fmt.Sprintf("-timeout=%s", timeout)}
+	if overlayFile != "" {
+		args = append(args, "-overlay="+overlayFile)
+	}
+	args = append(args, extraArgs...)
+	args = append(args, localPkg)
 	cmd := exec.Command("go", args...)
 	cmd.Dir = dir
 
@@ -108,8 +224,11 @@ func (g *Go) RunTest(dir string, testFile string, testFunc string, timeout time.
 	cmd.Stdout = &buf
 	cmd.Stderr = &buf
 
-	// Set up environment to ensure we use the temp dir's go.mod
-	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	// Set up environment to ensure we use the temp dir's go.mod. With an
+	// overlay, dir is the real module, whose go.mod must be left alone.
+	if overlayFile == "" {
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	}
 
 	err = cmd.Run()
 	output = buf.String()
@@ -129,3 +248,52 @@ func (g *Go) ValidateTestSyntax(testCode []byte) error {
 	_, err := parser.ParseFile(fset, "test.go", testCode, parser.AllErrors)
 	return err
 }
+
+// TypeCheck loads the package with go/packages, with source and the test
+// file given to the go command as an overlay, so nothing is written to disk.
+// Dependencies come from export data in the build cache; only the package
+// itself (and its tests, with a test file) is type-checked from source.
+func (g *Go) TypeCheck(root, sourceFile string, source []byte, testFile string, testCode []byte) ([]string, error) {
+	overlay := map[string][]byte{filepath.Join(root, sourceFile): source}
+	if testFile != "" {
+		overlay[filepath.Join(root, testFile)] = testCode
+	}
+	cfg := &packages.Config{
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedTypes | packages.NeedSyntax,
+		Dir:     root,
+		Tests:   testFile != "",
+		Overlay: overlay,
+	}
+	pkgs, err := packages.Load(cfg, "./"+filepath.ToSlash(filepath.Dir(sourceFile)))
+	if err != nil {
+		return nil, fmt.Errorf("loading package: %w", err)
+	}
+
+	// The go command's own errors repeat the type checker's (as compiler
+	// output for overlay temp files), so they are only reported if there are
+	// no others, as when the package can't be found. With tests, the
+	// package's errors also repeat in its test variant.
+	var typeErrs, listErrs []string
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			msg := e.Msg
+			if e.Pos != "" {
+				msg = strings.TrimPrefix(e.Pos, root+string(filepath.Separator)) + ": " + msg
+			}
+			if seen[msg] {
+				continue
+			}
+			seen[msg] = true
+			if e.Kind == packages.ListError {
+				listErrs = append(listErrs, msg)
+			} else {
+				typeErrs = append(typeErrs, msg)
+			}
+		}
+	}
+	if len(typeErrs) > 0 {
+		return typeErrs, nil
+	}
+	return listErrs, nil
+}
@@ -1,8 +1,13 @@
 package lang
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/yiyuanh/snare/pkg/model"
 )
 
 func TestApplyMutant_Success(t *testing.T) {
@@ -14,7 +19,7 @@ func Add(a, b int) int {
 `)
 
 	g := NewGo()
-	result, err := g.ApplyMutant(src, "a + b", "a - b")
+	result, _, err := g.ApplyMutant("example.go", src, model.Mutant{FuncName: "Add", Original: "a + b", Mutated: "a - b"})
 	if err != nil {
 		t.Fatalf("ApplyMutant returned error: %v", err)
 	}
@@ -36,7 +41,7 @@ func Add(a, b int) int {
 `)
 
 	g := NewGo()
-	_, err := g.ApplyMutant(src, "nonexistent snippet", "replacement")
+	_, _, err := g.ApplyMutant("example.go", src, model.Mutant{FuncName: "Add", Original: "nonexistent snippet", Mutated: "replacement"})
 	if err == nil {
 		t.Fatal("expected error for missing snippet, got nil")
 	}
@@ -55,7 +60,7 @@ func Add(a, b int) int {
 
 	g := NewGo()
 	// Replace with syntactically invalid Go
-	_, err := g.ApplyMutant(src, "return a + b", "return {{{invalid")
+	_, _, err := g.ApplyMutant("example.go", src, model.Mutant{FuncName: "Add", Original: "return a + b", Mutated: "return {{{invalid"})
 	if err == nil {
 		t.Fatal("expected error for invalid Go after mutation, got nil")
 	}
@@ -95,3 +100,130 @@ func TestBroken(t *testing.T) {
 		t.Error("expected error for invalid syntax, got nil")
 	}
 }
+
+func TestApplyMutant_ScopedToFunction(t *testing.T) {
+	src := []byte(`package example
+
+func Find(xs []int, x int) int {
+	for i := 0; i < len(xs); i++ {
+		if xs[i] == x {
+			return i
+		}
+	}
+	return -1
+}
+
+func Count(xs []int) int {
+	n := 0
+	for i := 0; i < len(xs); i++ {
+		n++
+	}
+	return n
+}
+`)
+
+	g := NewGo()
+	m := model.Mutant{FuncName: "Count", Original: "i < len(xs)", Mutated: "i <= len(xs)"}
+	result, site, err := g.ApplyMutant("example.go", src, m)
+	if err != nil {
+		t.Fatalf("ApplyMutant returned error: %v", err)
+	}
+	// Find's loop comes first in the file but must be left alone
+	if !strings.Contains(string(result), "for i := 0; i < len(xs); i++ {\n\t\tif") {
+		t.Error("mutant was applied to Find instead of Count")
+	}
+	if site.Func != "Count" || site.Line != 14 || site.Match != MatchExact {
+		t.Errorf("site = %+v, want Count line 14 exact", site)
+	}
+}
+
+func TestApplyMutant_ReformattedSnippet(t *testing.T) {
+	src := []byte("package example\n\nfunc Add(a, b int) int {\n\tif a>0 &&\n\t\tb>0 {\n\t\treturn a+b\n\t}\n\treturn 0\n}\n")
+
+	g := NewGo()
+	// The snippet as format.Node prints the body, on one line with spaces
+	result, site, err := g.ApplyMutant("example.go", src, model.Mutant{FuncName: "Add", Original: "if a > 0 && b > 0 {", Mutated: "if a > 0 || b > 0 {"})
+	if err != nil {
+		t.Fatalf("ApplyMutant returned error: %v", err)
+	}
+	if !strings.Contains(string(result), "if a > 0 || b > 0 {\n\t\treturn a+b") {
+		t.Errorf("result = %q", result)
+	}
+	if site.Line != 4 || site.Match != MatchToken {
+		t.Errorf("site = %+v, want line 4 token match", site)
+	}
+}
+
+func TestGo_TypeCheck(t *testing.T) {
+	root, src := writeCalcModule(t)
+	g := NewGo()
+	file := filepath.Join("calc", "calc.go")
+
+	if errs, err := g.TypeCheck(root, file, []byte(src), "", nil); err != nil || len(errs) > 0 {
+		t.Fatalf("unchanged package: errs %q, err %v", errs, err)
+	}
+
+	mutant := strings.Replace(src, "a + b", `a + "b"`, 1)
+	errs, err := g.TypeCheck(root, file, []byte(mutant), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 || !strings.HasPrefix(errs[0], filepath.Join("calc", "calc.go")+":4:") {
+		t.Errorf("mutant errs = %q, want one error at calc/calc.go:4", errs)
+	}
+
+	testFile := filepath.Join("calc", "snare_add_test.go")
+	test := "package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tif Add(1, 2, 3) != 6 {\n\t\tt.Fail()\n\t}\n}\n"
+	errs, err = g.TypeCheck(root, file, []byte(src), testFile, []byte(test))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0], "snare_add_test.go:6:") {
+		t.Errorf("test errs = %q, want one error in the test file", errs)
+	}
+	if _, err := os.Stat(filepath.Join(root, testFile)); !os.IsNotExist(err) {
+		t.Error("the test file was written to disk")
+	}
+}
+
+func TestGo_RunTestOverlay(t *testing.T) {
+	root, src := writeCalcModule(t)
+	g := NewGo()
+	file := filepath.Join("calc", "calc.go")
+	testFile := filepath.Join("calc", "snare_add_test.go")
+	test := "package calc\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {\n\tif Add(1, 2) != 3 {\n\t\tt.Fail()\n\t}\n}\n"
+
+	overlay := map[string][]byte{file: []byte(src), testFile: []byte(test)}
+	if passed, output, err := g.RunTestOverlay(root, overlay, testFile, "TestAdd", time.Minute); err != nil || !passed {
+		t.Fatalf("test on the original: passed=%v err=%v\n%s", passed, err, output)
+	}
+
+	overlay[file] = []byte(strings.Replace(src, "a + b", "a - b", 1))
+	if passed, output, err := g.RunTestOverlay(root, overlay, testFile, "TestAdd", time.Minute); err != nil || passed {
+		t.Fatalf("test on the mutant: passed=%v err=%v\n%s", passed, err, output)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, testFile)); !os.IsNotExist(err) {
+		t.Error("the test file was written to disk")
+	}
+	if got, _ := os.ReadFile(filepath.Join(root, file)); string(got) != src {
+		t.Errorf("the source was modified: %q", got)
+	}
+}
+
+// writeCalcModule writes a module with a one-function package calc to a temp
+// dir, returning the dir and the package's source.
+func writeCalcModule(t *testing.T) (root, src string) {
+	t.Helper()
+	root = t.TempDir()
+	src = "package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n"
+	for name, content := range map[string]string{"go.mod": "module example.com/calc\n\ngo 1.21\n", "calc/calc.go": src} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root, src
+}
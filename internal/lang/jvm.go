@@ -0,0 +1,347 @@
+package lang
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yiyuanh/snare/internal/analysis"
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+// JVM implements the Language interface for Java and Kotlin projects built
+// with Maven or Gradle. Generated tests are JUnit 5 classes in the source's
+// package under src/test, run one class at a time with mvn -Dtest= or
+// gradle test --tests.
+type JVM struct {
+	kotlin bool
+}
+
+func NewJava() *JVM {
+	return &JVM{}
+}
+
+func NewKotlin() *JVM {
+	return &JVM{kotlin: true}
+}
+
+func init() {
+	Register(NewJava())
+	Register(NewKotlin())
+}
+
+func (j *JVM) Name() string {
+	if j.kotlin {
+		return "kotlin"
+	}
+	return "java"
+}
+
+func (j *JVM) FileExtensions() []string {
+	if j.kotlin {
+		return []string{".kt"}
+	}
+	return []string{".java"}
+}
+
+// IsTestFile reports files under src/test and classes named like tests
+// (FooTest, FooTests, FooIT).
+func (j *JVM) IsTestFile(p string) bool {
+	p = "/" + filepath.ToSlash(p)
+	name := strings.TrimSuffix(path.Base(p), path.Ext(p))
+	return strings.Contains(p, "/src/test/") ||
+		strings.HasSuffix(name, "Test") || strings.HasSuffix(name, "Tests") || strings.HasSuffix(name, "IT")
+}
+
+// TestFilePath puts the test class in the source's package under src/test
+// (src/main/java/com/acme/Cart.java gives src/test/java/com/acme/<name>.java),
+// or next to the source outside the standard layout. The file is named after
+// the class, which must be test_name.
+func (j *JVM) TestFilePath(sourceFile string, testName string) string {
+	dir := filepath.ToSlash(filepath.Dir(sourceFile))
+	if i := strings.LastIndex("/"+dir+"/", "/src/main/"); i >= 0 {
+		dir = dir[:i] + "src/test/" + dir[i+len("src/main/"):]
+	}
+	return filepath.Join(filepath.FromSlash(dir), jvmClassName(testName)+filepath.Ext(sourceFile))
+}
+
+// classNameChars matches runs of characters not allowed in class names.
+var classNameChars = regexp.MustCompile(`[^A-Za-z0-9_$]+`)
+
+// jvmClassName turns a test name into a valid class name.
+func jvmClassName(name string) string {
+	name = classNameChars.ReplaceAllString(name, "_")
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "T" + name
+	}
+	return name
+}
+
+// jvmBuildFiles mark Maven and Gradle projects and modules.
+var jvmBuildFiles = []string{"pom.xml", "build.gradle.kts", "build.gradle", "settings.gradle.kts", "settings.gradle"}
+
+func (j *JVM) RootMarkers() []string {
+	return jvmBuildFiles
+}
+
+func (j *JVM) Prompt() PromptStyle {
+	if j.kotlin {
+		return PromptStyle{
+			CodeFence:       "kotlin",
+			PackageLabel:    "Package",
+			ShowSignature:   true,
+			TestFramework:   "JUnit 5",
+			TestFileNote:    " declaring one test class",
+			TestNameExample: "FuncNameRiskDescriptionTest",
+			TestCodeExample: `"package com.example\n\nimport org.junit.jupiter.api.Assertions.assertEquals\nimport org.junit.jupiter.api.Test\n\nclass FuncNameRiskDescriptionTest {\n    @Test\n    fun riskDescription() {\n        assertEquals(2, Calc().add(1, 1))\n    }\n}"`,
+			Rules: []string{
+				"Declare the test in package {package}, so internal members are accessible",
+				"The test class must be named exactly as test_name",
+				"Use JUnit 5 (org.junit.jupiter.api.Test and org.junit.jupiter.api.Assertions) and only libraries the project already depends on",
+				"Top-level functions (qualified FileKt#name) are called directly by name",
+			},
+			Comments: analysis.CommentSyntax{Line: "//", BlockStart: "/*", BlockEnd: "*/", Attributes: []string{"@"}},
+		}
+	}
+	return PromptStyle{
+		CodeFence:       "java",
+		PackageLabel:    "Package",
+		ShowSignature:   true,
+		TestFramework:   "JUnit 5",
+		TestFileNote:    " declaring one test class",
+		TestNameExample: "FuncNameRiskDescriptionTest",
+		TestCodeExample: `"package com.example;\n\nimport static org.junit.jupiter.api.Assertions.assertEquals;\n\nimport org.junit.jupiter.api.Test;\n\nclass FuncNameRiskDescriptionTest {\n    @Test\n    void riskDescription() {\n        assertEquals(2, new Calc().add(1, 1));\n    }\n}"`,
+		Rules: []string{
+			"Declare the test in package {package}, so package-private members are accessible",
+			"The test class must be named exactly as test_name",
+			"Use JUnit 5 (org.junit.jupiter.api.Test and org.junit.jupiter.api.Assertions) and only libraries the project already depends on",
+		},
+		Comments: analysis.CommentSyntax{Line: "//", BlockStart: "/*", BlockEnd: "*/", Attributes: []string{"@"}},
+	}
+}
+
+// parse parses a Java or Kotlin source file.
+func (j *JVM) parse(filePath string, source []byte) (*jvmFile, error) {
+	if j.kotlin {
+		return parseKotlin(string(source), kotlinFileClass(filePath))
+	}
+	return parseJava(string(source))
+}
+
+// kotlinFileClass returns the class holding a Kotlin file's top-level
+// functions: cart.kt gives CartKt.
+func kotlinFileClass(filePath string) string {
+	name := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	if name == "" {
+		return "Kt"
+	}
+	return strings.ToUpper(name[:1]) + name[1:] + "Kt"
+}
+
+func (j *JVM) IdentifyChangedFuncs(filePath string, source []byte, hunks []model.Hunk) ([]model.ChangedFunc, error) {
+	file, err := j.parse(filePath, source)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", j.Name(), err)
+	}
+
+	var result []model.ChangedFunc
+	for _, fn := range file.Funcs {
+		var diffParts []string
+		for _, h := range hunks {
+			hunkEnd := h.NewStartLine + h.NewLineCount - 1
+			if hunkEnd >= fn.StartLine && h.NewStartLine <= fn.EndLine {
+				diffParts = append(diffParts, h.Content)
+			}
+		}
+		if len(diffParts) == 0 {
+			continue
+		}
+
+		result = append(result, model.ChangedFunc{
+			FilePath:      filePath,
+			Package:       file.Package,
+			Name:          fn.Name,
+			QualifiedName: fn.QualName,
+			Signature:     fn.Signature,
+			Body:          fn.Body,
+			StartLine:     fn.StartLine,
+			EndLine:       fn.EndLine,
+			Imports:       file.Imports,
+			DiffContext:   strings.Join(diffParts, "\n"),
+		})
+	}
+	return result, nil
+}
+
+// ApplyMutant replaces the original snippet and checks that the result still
+// parses. The check covers brackets, strings and comments only,
+// as there is no compiler to ask without building the project.
+func (j *JVM) ApplyMutant(filePath string, source []byte, mutant model.Mutant) ([]byte, Site, error) {
+	result, site, err := replaceInFunc(j, filePath, source, mutant)
+	if err != nil {
+		return nil, site, err
+	}
+	if _, err := j.parse(filePath, result); err != nil {
+		if _, origErr := j.parse(filePath, source); origErr == nil {
+			return nil, site, fmt.Errorf("mutated code is not valid %s: %w", j.Name(), err)
+		}
+	}
+	return result, site, nil
+}
+
+// RunTest runs the test class in testFile, which is named after it, in the
+// Maven or Gradle module containing the file.
+func (j *JVM) RunTest(dir string, testFile string, testFunc string, timeout time.Duration) (passed bool, output string, err error) {
+	class := strings.TrimSuffix(filepath.Base(testFile), filepath.Ext(testFile))
+	if src, err := os.ReadFile(filepath.Join(dir, testFile)); err == nil {
+		if file, err := j.parse(testFile, src); err == nil && file.Package != "" {
+			class = file.Package + "." + class
+		}
+	}
+	passed, output, err = jvmTest(dir, filepath.Dir(testFile), class, timeout)
+	if err == nil && passed && jvmBuildTool(dir) == "maven" && !mavenTestsRan.MatchString(output) {
+		// Surefire passes when the filter matches nothing; treat it as a
+		// failure the repair step can explain
+		return false, output + fmt.Sprintf("\nsnare: no tests ran in %s; the class must be named %s and contain @Test methods\n", class, jvmClassName(testFunc)), nil
+	}
+	return passed, output, err
+}
+
+// mavenTestsRan matches Surefire's summary when at least one test ran.
+var mavenTestsRan = regexp.MustCompile(`Tests run: [1-9]`)
+
+// RunSuite runs the module's tests in the package of sourceFile.
+func (j *JVM) RunSuite(dir string, sourceFile string, timeout time.Duration) (passed bool, output string, err error) {
+	filter := ""
+	if src, err := os.ReadFile(filepath.Join(dir, sourceFile)); err == nil {
+		if file, err := j.parse(sourceFile, src); err == nil && file.Package != "" {
+			filter = file.Package + ".*"
+		}
+	}
+	return jvmTest(dir, filepath.Dir(sourceFile), filter, timeout)
+}
+
+// ValidateTestSyntax checks that the test code is balanced and declares a class.
+func (j *JVM) ValidateTestSyntax(testCode []byte) error {
+	p, err := newJVMParser(string(testCode), j.kotlin)
+	if err != nil {
+		return fmt.Errorf("invalid %s syntax: %w", j.Name(), err)
+	}
+	for _, t := range p.tokens {
+		if t.text == "class" {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid %s syntax: no test class declared", j.Name())
+}
+
+// jvmBuildTool returns "maven" or "gradle" for the project in dir.
+func jvmBuildTool(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, "pom.xml")); err == nil {
+		return "maven"
+	}
+	return "gradle"
+}
+
+// jvmModule returns the directory (relative to dir) of the nearest Maven or
+// Gradle module at or above fileDir, or "." for the root project.
+func jvmModule(dir, fileDir string) string {
+	for d := fileDir; d != "." && d != "/" && d != ""; d = filepath.Dir(d) {
+		for _, name := range []string{"pom.xml", "build.gradle.kts", "build.gradle"} {
+			if _, err := os.Stat(filepath.Join(dir, d, name)); err == nil {
+				return d
+			}
+		}
+	}
+	return "."
+}
+
+// jvmBuildTimeout bounds a whole test build on top of the test timeout, so
+// a stuck build can't hang the run. The test timeout itself applies to each
+// test method, through JUnit.
+const jvmBuildTimeout = 10 * time.Minute
+
+// mavenSkips turns off plugins that slow down a test build without
+// affecting its result.
+var mavenSkips = []string{
+	"-Djacoco.skip=true", "-Dcheckstyle.skip=true", "-Denforcer.skip=true", "-Dspotbugs.skip=true",
+	"-Dpmd.skip=true", "-Dmaven.javadoc.skip=true", "-Dspotless.check.skip=true",
+}
+
+// IncrementalBuild marks Maven and Gradle builds as incremental, so test
+// runs reuse project mirrors and their outputs (see jvmTest).
+func (j *JVM) IncrementalBuild() {}
+
+// jvmTest runs the tests matching filter (a class name or pattern; "" for
+// all) in the module containing fileDir. A multi-module Maven build compiles
+// only the module and what it depends on, and since the executor reuses its
+// project mirrors, target/ and build/ outputs from earlier runs are reused
+// too: a run recompiles only the swapped source and test. mvnd's or Gradle's
+// daemon keeps the JVM warm.
+func jvmTest(dir, fileDir, filter string, timeout time.Duration) (passed bool, output string, err error) {
+	module := jvmModule(dir, fileDir)
+
+	var name string
+	var args []string
+	if jvmBuildTool(dir) == "maven" {
+		name = "mvn"
+		if _, err := exec.LookPath("mvnd"); err == nil {
+			name = "mvnd"
+		} else if _, err := os.Stat(filepath.Join(dir, "mvnw")); err == nil {
+			name = "./mvnw"
+		}
+		args = append([]string{"-B", "-ntp", "test", "-Dsurefire.failIfNoSpecifiedTests=false", "-DfailIfNoTests=false"}, mavenSkips...)
+		if filter != "" {
+			args = append(args, "-Dtest="+filter)
+		}
+		if module != "." {
+			args = append(args, "-pl", filepath.ToSlash(module), "-am")
+		}
+	} else {
+		name = "gradle"
+		if _, err := os.Stat(filepath.Join(dir, "gradlew")); err == nil {
+			name = "./gradlew"
+		}
+		task := "test"
+		if module != "." {
+			task = ":" + strings.ReplaceAll(filepath.ToSlash(module), "/", ":") + ":test"
+		}
+		args = []string{task, "--build-cache", "--console=plain"}
+		if filter != "" {
+			args = append(args, "--tests", filter)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+jvmBuildTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	junitTimeout := fmt.Sprintf("-Djunit.jupiter.execution.timeout.default=%dms", max(timeout.Milliseconds(), 1))
+	cmd.Env = append(os.Environ(), "JAVA_TOOL_OPTIONS="+strings.TrimSpace(os.Getenv("JAVA_TOOL_OPTIONS")+" "+junitTimeout))
+
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+
+	err = cmd.Run()
+	output = buf.String()
+	if ctx.Err() != nil {
+		return false, output + fmt.Sprintf("\nsnare: build timed out after %s\n", timeout+jvmBuildTimeout), nil
+	}
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			// Test failed (non-zero exit) — expected for catching tests
+			return false, output, nil
+		}
+		return false, output, fmt.Errorf("running %s: %w", name, err)
+	}
+	return true, output, nil
+}
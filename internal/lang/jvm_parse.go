@@ -0,0 +1,717 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A lightweight Java and Kotlin declaration parser, in the spirit of the Rust
+// one: a lexer that understands comments, strings (including text blocks and
+// Kotlin string templates) and char literals, and a parser that finds types
+// and their methods by brace structure without understanding expressions.
+
+type jvmToken struct {
+	kind       rustTokenKind // reuses the Rust token kinds
+	text       string
+	start, end int
+	line       int
+	newline    bool // first token on its line
+}
+
+// scanJVM splits src into tokens, dropping whitespace and comments. Kotlin
+// block comments nest and its strings may contain ${...} templates.
+func scanJVM(src string, kotlin bool) ([]jvmToken, error) {
+	var tokens []jvmToken
+	line := 1
+	newline := true
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		start, startLine := i, line
+		switch {
+		case c == '\n':
+			line++
+			newline = true
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			end, err := blockCommentEnd(src, i, kotlin)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", startLine, err)
+			}
+			line += strings.Count(src[i:end], "\n")
+			i = end
+			continue
+		}
+
+		kind := rustPunct
+		switch {
+		case c == '"':
+			end, err := jvmStringEnd(src, i, kotlin)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", startLine, err)
+			}
+			kind = rustLiteral
+			i = end
+		case c == '\'':
+			end := quotedEnd(src, i, '\'')
+			if end < 0 || strings.Contains(src[i:end], "\n") {
+				return nil, fmt.Errorf("line %d: unterminated char literal", startLine)
+			}
+			kind = rustLiteral
+			i = end
+		case c == '`' && kotlin:
+			// Backquoted identifier: fun `adds two numbers`()
+			end := strings.IndexByte(src[i+1:], '`')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated backquoted name", startLine)
+			}
+			kind = rustIdent
+			i += end + 2
+		case c == '_' || c == '$' || unicode.IsLetter(decodeRune(src, i)):
+			kind = rustIdent
+			for i < len(src) && (src[i] == '_' || src[i] == '$' || isRustIdentChar(src, i)) {
+				i += runeLen(src, i)
+			}
+		case c >= '0' && c <= '9':
+			kind = rustLiteral
+			for i < len(src) && (isRustIdentChar(src, i) || (src[i] == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9')) {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "->") || strings.HasPrefix(src[i:], "::"):
+			i += 2
+		default:
+			i += runeLen(src, i)
+		}
+		line += strings.Count(src[start:i], "\n")
+		tokens = append(tokens, jvmToken{kind: kind, text: src[start:i], start: start, end: i, line: startLine, newline: newline})
+		newline = false
+	}
+	return tokens, nil
+}
+
+func decodeRune(src string, i int) rune {
+	r, _ := utf8.DecodeRuneInString(src[i:])
+	return r
+}
+
+// blockCommentEnd returns the offset just past the block comment at src[i].
+func blockCommentEnd(src string, i int, nested bool) (int, error) {
+	depth := 0
+	for j := i; j < len(src); {
+		switch {
+		case strings.HasPrefix(src[j:], "/*") && (nested || depth == 0):
+			depth++
+			j += 2
+		case strings.HasPrefix(src[j:], "*/"):
+			depth--
+			j += 2
+			if depth == 0 {
+				return j, nil
+			}
+		default:
+			j++
+		}
+	}
+	return 0, fmt.Errorf("unterminated block comment")
+}
+
+// jvmStringEnd returns the offset just past the string literal at src[i]:
+// "..." or a """ text block (Java) or raw string (Kotlin). Kotlin ${...}
+// templates are skipped as code, so strings inside them don't end the literal.
+func jvmStringEnd(src string, i int, kotlin bool) (int, error) {
+	triple := strings.HasPrefix(src[i:], `"""`)
+	j := i + 1
+	if triple {
+		j = i + 3
+	}
+	for j < len(src) {
+		c := src[j]
+		switch {
+		case c == '\\' && !(triple && kotlin):
+			j += 2
+			continue
+		case c == '\n' && !triple:
+			return 0, fmt.Errorf("unterminated string")
+		case kotlin && strings.HasPrefix(src[j:], "${"):
+			end, err := templateEnd(src, j+2)
+			if err != nil {
+				return 0, err
+			}
+			j = end
+			continue
+		case triple && strings.HasPrefix(src[j:], `"""`):
+			// A raw string may end with extra quotes: """a""""
+			j += 3
+			for j < len(src) && src[j] == '"' {
+				j++
+			}
+			return j, nil
+		case !triple && c == '"':
+			return j + 1, nil
+		}
+		j++
+	}
+	return 0, fmt.Errorf("unterminated string")
+}
+
+// templateEnd returns the offset just past the "}" closing a Kotlin string
+// template whose expression starts at src[i].
+func templateEnd(src string, i int) (int, error) {
+	depth := 1
+	for j := i; j < len(src); {
+		switch src[j] {
+		case '"':
+			end, err := jvmStringEnd(src, j, true)
+			if err != nil {
+				return 0, err
+			}
+			j = end
+			continue
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return j + 1, nil
+			}
+		}
+		j++
+	}
+	return 0, fmt.Errorf("unterminated string template")
+}
+
+// jvmMatch maps every opening (, [ and { token to its closing token.
+func jvmMatch(tokens []jvmToken) (map[int]int, error) {
+	rt := make([]rustToken, len(tokens))
+	for i, t := range tokens {
+		rt[i] = rustToken{kind: t.kind, text: t.text, line: t.line}
+	}
+	return matchBrackets(rt)
+}
+
+// jvmFunc is a method found by parseJava or parseKotlin.
+type jvmFunc struct {
+	Name      string
+	QualName  string // "Cart#add", "Cart.Item#price", "Cart#add(Item, int)"
+	Signature string
+	Body      string
+	StartLine int
+	EndLine   int
+}
+
+// jvmFile is what parseJava and parseKotlin extract from a source file.
+type jvmFile struct {
+	Package string
+	Imports []string
+	Funcs   []jvmFunc
+}
+
+type jvmParser struct {
+	src    string
+	tokens []jvmToken
+	match  map[int]int
+	file   *jvmFile
+	params map[int]string // method index in file.Funcs -> parameter types
+}
+
+func newJVMParser(src string, kotlin bool) (*jvmParser, error) {
+	tokens, err := scanJVM(src, kotlin)
+	if err != nil {
+		return nil, err
+	}
+	match, err := jvmMatch(tokens)
+	if err != nil {
+		return nil, err
+	}
+	return &jvmParser{src: src, tokens: tokens, match: match, file: &jvmFile{}, params: make(map[int]string)}, nil
+}
+
+func (p *jvmParser) text(i, j int) string {
+	return p.src[p.tokens[i].start:p.tokens[j].end]
+}
+
+// skipAnnotation returns the last token of the annotation at tokens[i]
+// ("@Name", "@a.b.Name" or either with arguments).
+func (p *jvmParser) skipAnnotation(i, hi int) int {
+	j := i + 1
+	if j < hi && p.tokens[j].text == "interface" {
+		return i // @interface declaration, not an annotation
+	}
+	// Kotlin use-site targets: @field:Json, @get:JvmName("x")
+	for j+1 < hi && (p.tokens[j+1].text == "." || p.tokens[j+1].text == ":") && p.tokens[j].kind == rustIdent {
+		j += 2
+	}
+	if j+1 < hi && p.tokens[j+1].text == "(" {
+		return p.match[j+1]
+	}
+	return j
+}
+
+// addFunc records a method whose signature runs from tokens[start] to the
+// body starting at tokens[open] and ending at tokens[end].
+func (p *jvmParser) addFunc(class, name string, start, open, end, paramsOpen int) {
+	p.params[len(p.file.Funcs)] = p.paramTypes(paramsOpen)
+	p.file.Funcs = append(p.file.Funcs, jvmFunc{
+		Name:      name,
+		QualName:  class + "#" + name,
+		Signature: strings.TrimSpace(p.src[p.tokens[start].start:p.tokens[open].start]),
+		Body:      p.text(open, end),
+		StartLine: p.tokens[start].line,
+		EndLine:   p.tokens[end].line,
+	})
+}
+
+// paramTypes returns a method's parameter types, e.g. "Item, int", from its
+// parameter list at tokens[open]: each parameter's text minus its name, for
+// Java, or its type after ":", for Kotlin.
+func (p *jvmParser) paramTypes(open int) string {
+	if open < 0 {
+		return ""
+	}
+	closeIdx := p.match[open]
+	var types []string
+	from := open + 1
+	for j := open + 1; j <= closeIdx; j++ {
+		t := p.tokens[j].text
+		if t == "(" || t == "[" || t == "{" {
+			j = p.match[j]
+			continue
+		}
+		if t == "<" {
+			depth := 0
+			for ; j < closeIdx; j++ {
+				if p.tokens[j].text == "<" {
+					depth++
+				} else if p.tokens[j].text == ">" {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			continue
+		}
+		if (t == "," || j == closeIdx) && j > from {
+			types = append(types, p.paramType(from, j))
+			from = j + 1
+		}
+	}
+	return strings.Join(types, ", ")
+}
+
+// paramType returns the type of the parameter in tokens[lo:hi].
+func (p *jvmParser) paramType(lo, hi int) string {
+	// Kotlin: name: Type = default
+	for j := lo; j < hi; j++ {
+		if p.tokens[j].text == ":" {
+			end := hi
+			for k := j + 1; k < hi; k++ {
+				if p.tokens[k].text == "=" {
+					end = k
+					break
+				}
+			}
+			return p.compact(j+1, end)
+		}
+	}
+	// Java: [final] [@Ann] Type name
+	for lo < hi && (p.tokens[lo].text == "final" || p.tokens[lo].text == "@") {
+		if p.tokens[lo].text == "@" {
+			lo = p.skipAnnotation(lo, hi)
+		}
+		lo++
+	}
+	return p.compact(lo, hi-1)
+}
+
+// compact joins tokens[lo:hi] with spaces only between words.
+func (p *jvmParser) compact(lo, hi int) string {
+	var sb strings.Builder
+	for j := lo; j < hi; j++ {
+		t := p.tokens[j]
+		if j > lo && t.kind == rustIdent && p.tokens[j-1].kind == rustIdent {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(t.text)
+	}
+	return sb.String()
+}
+
+// qualifyOverloads appends parameter types to the names of overloaded
+// methods, so every method has a distinct identity.
+func (p *jvmParser) qualifyOverloads() {
+	count := make(map[string]int)
+	for _, fn := range p.file.Funcs {
+		count[fn.QualName]++
+	}
+	for i, fn := range p.file.Funcs {
+		if count[fn.QualName] > 1 {
+			p.file.Funcs[i].QualName = fn.QualName + "(" + p.params[i] + ")"
+		}
+	}
+}
+
+// javaTypeKeywords start a type declaration.
+var javaTypeKeywords = map[string]bool{"class": true, "interface": true, "enum": true, "record": true}
+
+// parseJava finds the methods and constructors of the classes, interfaces,
+// enums and records in src, including nested types. Method bodies (and so
+// local and anonymous classes) are not searched.
+func parseJava(src string) (*jvmFile, error) {
+	p, err := newJVMParser(src, false)
+	if err != nil {
+		return nil, err
+	}
+	p.javaBody(0, len(p.tokens), "", false)
+	p.qualifyOverloads()
+	return p.file, nil
+}
+
+// javaBody parses the declarations in tokens[lo:hi]: the top level of the
+// file (class == "") or the body of a type.
+func (p *jvmParser) javaBody(lo, hi int, class string, isEnum bool) {
+	if isEnum {
+		// Skip the enum constants, which end at the first top-level ";"
+		lo = p.skipTo(lo, hi, ";") + 1
+	}
+	start := -1
+	for i := lo; i < hi; i++ {
+		t := p.tokens[i]
+		if t.text == "@" {
+			if end := p.skipAnnotation(i, hi); end != i {
+				i = end
+				continue
+			}
+		}
+		if start < 0 {
+			if t.text == ";" {
+				continue
+			}
+			start = i
+		}
+
+		switch {
+		case class == "" && (t.text == "package" || t.text == "import"):
+			end := p.skipTo(i, hi, ";")
+			if t.text == "package" {
+				p.file.Package = strings.TrimSuffix(p.compact(i+1, end), ";")
+			} else {
+				p.file.Imports = append(p.file.Imports, p.text(i, end))
+			}
+			i = end
+		case p.javaTypeDecl(i, hi):
+			if t.text == "@" {
+				i++
+			}
+			open := p.skipTo(i, hi, "{")
+			if open >= hi || i+1 >= hi {
+				i = hi
+				break
+			}
+			name := p.tokens[i+1].text
+			if class != "" {
+				name = class + "." + name
+			}
+			p.javaBody(open+1, p.match[open], name, t.text == "enum")
+			i = p.match[open]
+		case class != "" && t.text == "{":
+			// An initializer block, or a record's compact constructor
+			if simple := class[strings.LastIndex(class, ".")+1:]; i > start && p.tokens[i-1].text == simple {
+				p.addFunc(class, simple, start, i, p.match[i], -1)
+			}
+			i = p.match[i]
+		case class != "" && t.text == "(":
+			i = p.javaMember(start, i, hi, class)
+		case t.text == "=" || t.text == ";":
+			// A field
+			i = p.skipTo(i, hi, ";")
+		default:
+			continue
+		}
+		start = -1
+	}
+}
+
+// javaTypeDecl reports whether tokens[i] starts a type declaration's name:
+// class, interface, enum, @interface, or record (also a valid identifier,
+// so only when a name and a header follow).
+func (p *jvmParser) javaTypeDecl(i, hi int) bool {
+	t := p.tokens[i]
+	switch {
+	case t.text == "@":
+		return i+1 < hi && p.tokens[i+1].text == "interface"
+	case t.text == "record":
+		return i+2 < hi && p.tokens[i+1].kind == rustIdent && (p.tokens[i+2].text == "(" || p.tokens[i+2].text == "<")
+	}
+	return javaTypeKeywords[t.text]
+}
+
+// javaMember handles a member whose first "(" is tokens[paren]: a method or
+// constructor if a name precedes it and a body follows. It returns the
+// member's last token.
+func (p *jvmParser) javaMember(start, paren, hi int, class string) int {
+	closeIdx := p.match[paren]
+	if paren == start || p.tokens[paren-1].kind != rustIdent {
+		return p.skipTo(closeIdx, hi, ";")
+	}
+	name := p.tokens[paren-1].text
+	for j := closeIdx + 1; j < hi; j++ {
+		switch p.tokens[j].text {
+		case ";":
+			return j // abstract or interface method
+		case "{":
+			end := p.match[j]
+			p.addFunc(class, name, start, j, end, paren)
+			return end
+		case "(":
+			j = p.match[j]
+		}
+	}
+	return hi - 1
+}
+
+// skipTo returns the index of the first text token at bracket depth 0 in
+// tokens[i:hi], skipping over (), [] and {} groups unless text is "{", or hi.
+func (p *jvmParser) skipTo(i, hi int, text string) int {
+	for j := i; j < hi; j++ {
+		t := p.tokens[j].text
+		if t == text {
+			return j
+		}
+		if t == "(" || t == "[" || t == "{" {
+			j = p.match[j]
+		}
+	}
+	return hi
+}
+
+// kotlinModifiers may precede a Kotlin declaration.
+var kotlinModifiers = map[string]bool{
+	"public": true, "private": true, "protected": true, "internal": true,
+	"open": true, "override": true, "abstract": true, "final": true, "sealed": true,
+	"suspend": true, "inline": true, "noinline": true, "crossinline": true, "operator": true,
+	"infix": true, "tailrec": true, "external": true, "data": true, "enum": true,
+	"annotation": true, "inner": true, "value": true, "const": true, "lateinit": true,
+	"expect": true, "actual": true, "companion": true,
+}
+
+// kotlinDeclStart reports whether t can begin a Kotlin declaration.
+func kotlinDeclStart(t jvmToken) bool {
+	switch t.text {
+	case "fun", "val", "var", "class", "interface", "object", "init", "constructor", "typealias", "@", "}":
+		return true
+	}
+	return kotlinModifiers[t.text]
+}
+
+// parseKotlin finds the functions of src: methods of classes, objects and
+// interfaces (including nested ones and companion objects), and top-level
+// functions, which belong to fileClass (e.g. "CartKt", as the JVM sees them).
+func parseKotlin(src string, fileClass string) (*jvmFile, error) {
+	p, err := newJVMParser(src, true)
+	if err != nil {
+		return nil, err
+	}
+	p.kotlinBody(0, len(p.tokens), "", fileClass, false)
+	p.qualifyOverloads()
+	return p.file, nil
+}
+
+// kotlinBody parses the declarations in tokens[lo:hi]: the top level of the
+// file (class == "") or the body of a class or object. Functions at the top
+// level belong to fileClass.
+func (p *jvmParser) kotlinBody(lo, hi int, class, fileClass string, isEnum bool) {
+	if isEnum {
+		if semi := p.skipTo(lo, hi, ";"); semi < hi {
+			lo = semi + 1
+		} else {
+			return
+		}
+	}
+	owner := class
+	if owner == "" {
+		owner = fileClass
+	}
+	start := -1
+	for i := lo; i < hi; i++ {
+		t := p.tokens[i]
+		if t.text == "@" {
+			i = p.skipAnnotation(i, hi)
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		if kotlinModifiers[t.text] {
+			continue
+		}
+
+		switch t.text {
+		case "package", "import":
+			end := p.lineEnd(i, hi)
+			if class == "" {
+				if t.text == "package" {
+					p.file.Package = p.compact(i+1, end+1)
+				} else {
+					p.file.Imports = append(p.file.Imports, p.text(i, end))
+				}
+			}
+			i = end
+		case "class", "interface", "object":
+			i = p.kotlinType(i, hi, start, class, fileClass)
+		case "fun", "constructor":
+			name := "" // from the declaration
+			if t.text == "constructor" {
+				name = class[strings.LastIndex(class, ".")+1:]
+			}
+			i = p.kotlinFun(i, hi, start, owner, name)
+		case "init":
+			if i+1 < hi && p.tokens[i+1].text == "{" {
+				i = p.match[i+1]
+			}
+		default:
+			// Properties and anything else: skip to the next declaration
+			i = p.nextDecl(i, hi) - 1
+		}
+		start = -1
+	}
+}
+
+// kotlinType handles class, interface or object at tokens[i] and returns
+// the last token of its declaration.
+func (p *jvmParser) kotlinType(i, hi, start int, class, fileClass string) int {
+	isEnum := false
+	for j := start; j < i; j++ {
+		if p.tokens[j].text == "enum" {
+			isEnum = true
+		}
+	}
+	name := "Companion"
+	if i+1 < hi && p.tokens[i+1].kind == rustIdent {
+		name = p.tokens[i+1].text
+	}
+	if class != "" {
+		name = class + "." + name
+	}
+	// The body is the first "{" before the next declaration on a new line
+	for j := i + 1; j < hi; j++ {
+		t := p.tokens[j]
+		switch {
+		case t.text == "(" || t.text == "[":
+			j = p.match[j]
+		case t.text == "{":
+			p.kotlinBody(j+1, p.match[j], name, fileClass, isEnum)
+			return p.match[j]
+		case t.newline && kotlinDeclStart(t) && t.text != "@":
+			return j - 1
+		}
+	}
+	return hi - 1
+}
+
+// kotlinFun handles "fun" or "constructor" at tokens[i]: a block body or an
+// "= expression" body. name is "" to take it from the declaration.
+func (p *jvmParser) kotlinFun(i, hi, start int, owner, name string) int {
+	paren := -1
+	for j := i + 1; j < hi; j++ {
+		t := p.tokens[j]
+		if t.text == "(" {
+			paren = j
+			if name == "" && j > i+1 {
+				name = strings.Trim(p.tokens[j-1].text, "`")
+			}
+			break
+		}
+		if t.text == "<" {
+			// Type parameters: fun <T> name(...)
+			for depth := 0; j < hi; j++ {
+				if p.tokens[j].text == "<" {
+					depth++
+				} else if p.tokens[j].text == ">" {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+		}
+		if t.newline && kotlinDeclStart(t) {
+			return j - 1
+		}
+	}
+	if paren < 0 {
+		return hi - 1
+	}
+	for j := p.match[paren] + 1; j < hi; j++ {
+		t := p.tokens[j]
+		switch {
+		case t.text == "(" || t.text == "[":
+			j = p.match[j]
+		case t.text == "{":
+			end := p.match[j]
+			p.addFunc(owner, name, start, j, end, paren)
+			return end
+		case t.text == "=":
+			if j+1 >= hi {
+				return j
+			}
+			end := p.expressionEnd(j+1, hi)
+			p.addFunc(owner, name, start, j+1, end, paren)
+			p.file.Funcs[len(p.file.Funcs)-1].Signature = strings.TrimSpace(p.src[p.tokens[start].start:p.tokens[j].end])
+			return end
+		case t.newline && kotlinDeclStart(t):
+			return j - 1 // abstract or interface function
+		}
+	}
+	return hi - 1
+}
+
+// expressionEnd returns the last token of the expression starting at
+// tokens[i]: it ends before the next declaration on a new line.
+func (p *jvmParser) expressionEnd(i, hi int) int {
+	j := i
+	for ; j < hi; j++ {
+		t := p.tokens[j]
+		if j > i && t.newline && kotlinDeclStart(t) {
+			return j - 1
+		}
+		if t.text == "(" || t.text == "[" || t.text == "{" {
+			j = p.match[j]
+		}
+	}
+	return hi - 1
+}
+
+// lineEnd returns the last token on the line of tokens[i].
+func (p *jvmParser) lineEnd(i, hi int) int {
+	for j := i + 1; j < hi; j++ {
+		if p.tokens[j].newline || p.tokens[j].text == ";" {
+			return j - 1
+		}
+	}
+	return hi - 1
+}
+
+// nextDecl returns the index of the next token after tokens[i] that starts
+// a declaration on a new line, skipping bracketed groups, or hi.
+func (p *jvmParser) nextDecl(i, hi int) int {
+	for j := i; j < hi; j++ {
+		t := p.tokens[j]
+		if j > i && t.newline && kotlinDeclStart(t) && t.text != "}" {
+			return j
+		}
+		if t.text == "(" || t.text == "[" || t.text == "{" {
+			j = p.match[j]
+		}
+	}
+	return hi
+}
@@ -0,0 +1,211 @@
+package lang
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+const javaCartSource = `package com.acme.shop;
+
+import java.util.ArrayList;
+import java.util.List;
+
+/** A shopping cart. */
+public class Cart {
+    private final List<Item> items = new ArrayList<>();
+    private final Runnable onChange = () -> { System.out.println("}"); };
+
+    public Cart() {
+        this(List.of());
+    }
+
+    @SuppressWarnings("unchecked")
+    public <T extends Item> int add(T item) {
+        items.add(item);
+        return items.size();
+    }
+
+    public int add(Item item, int count) {
+        String note = """
+            added { items
+            """;
+        return items.size() + count;
+    }
+
+    abstract static class Discount {
+        abstract int percent();
+
+        int apply(int price) {
+            return price - price * percent() / 100;
+        }
+    }
+
+    enum Size {
+        SMALL { int factor() { return 1; } }, LARGE;
+
+        int factor() { return 2; }
+    }
+}
+`
+
+func TestParseJava(t *testing.T) {
+	file, err := parseJava(javaCartSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Package != "com.acme.shop" || len(file.Imports) != 2 || file.Imports[0] != "import java.util.ArrayList;" {
+		t.Errorf("package %q, imports %q", file.Package, file.Imports)
+	}
+
+	want := []struct {
+		qual       string
+		start, end int
+	}{
+		{"Cart#Cart", 11, 13},
+		{"Cart#add(T)", 16, 19},
+		{"Cart#add(Item, int)", 21, 26},
+		{"Cart.Discount#apply", 31, 33},
+		{"Cart.Size#factor", 39, 39},
+	}
+	if len(file.Funcs) != len(want) {
+		t.Fatalf("got %d funcs, want %d: %+v", len(file.Funcs), len(want), file.Funcs)
+	}
+	for i, w := range want {
+		fn := file.Funcs[i]
+		if fn.QualName != w.qual || fn.StartLine != w.start || fn.EndLine != w.end {
+			t.Errorf("func %d = %s lines %d-%d, want %s lines %d-%d", i, fn.QualName, fn.StartLine, fn.EndLine, w.qual, w.start, w.end)
+		}
+	}
+	if sig := file.Funcs[1].Signature; sig != "public <T extends Item> int add(T item)" {
+		t.Errorf("signature = %q", sig)
+	}
+}
+
+const kotlinCartSource = `package com.acme.shop
+
+import java.math.BigDecimal
+
+fun total(items: List<Item>): BigDecimal =
+    items.fold(BigDecimal.ZERO) { sum, i -> sum + i.price }
+
+val label = "cart: ${listOf("}").size}"
+
+data class Item(val price: BigDecimal)
+
+class Cart(private val items: MutableList<Item> = mutableListOf()) {
+    val size: Int
+        get() = items.size
+
+    fun add(item: Item): Int {
+        items.add(item)
+        return items.size
+    }
+
+    override fun toString() = "Cart($size)"
+
+    companion object {
+        fun empty(): Cart = Cart()
+    }
+}
+`
+
+func TestParseKotlin(t *testing.T) {
+	file, err := parseKotlin(kotlinCartSource, "CartKt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Package != "com.acme.shop" || len(file.Imports) != 1 {
+		t.Errorf("package %q, imports %q", file.Package, file.Imports)
+	}
+
+	want := []struct {
+		qual       string
+		start, end int
+	}{
+		{"CartKt#total", 5, 6},
+		{"Cart#add", 16, 19},
+		{"Cart#toString", 21, 21},
+		{"Cart.Companion#empty", 24, 24},
+	}
+	if len(file.Funcs) != len(want) {
+		t.Fatalf("got %d funcs, want %d: %+v", len(file.Funcs), len(want), file.Funcs)
+	}
+	for i, w := range want {
+		fn := file.Funcs[i]
+		if fn.QualName != w.qual || fn.StartLine != w.start || fn.EndLine != w.end {
+			t.Errorf("func %d = %s lines %d-%d, want %s lines %d-%d", i, fn.QualName, fn.StartLine, fn.EndLine, w.qual, w.start, w.end)
+		}
+	}
+	if fn := file.Funcs[0]; fn.Signature != "fun total(items: List<Item>): BigDecimal =" || fn.Body != "items.fold(BigDecimal.ZERO) { sum, i -> sum + i.price }" {
+		t.Errorf("total = %+v", fn)
+	}
+}
+
+func TestJVM_IdentifyChangedFuncs(t *testing.T) {
+	// Line 23 changed: only the second add overload
+	hunks := []model.Hunk{{NewStartLine: 23, NewLineCount: 1}}
+	funcs, err := NewJava().IdentifyChangedFuncs("src/main/java/com/acme/shop/Cart.java", []byte(javaCartSource), hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(funcs) != 1 {
+		t.Fatalf("got %d funcs, want 1: %+v", len(funcs), funcs)
+	}
+	if fn := funcs[0]; fn.ID() != "Cart#add(Item, int)" || fn.Name != "add" || fn.Package != "com.acme.shop" {
+		t.Errorf("func = %+v", fn)
+	}
+}
+
+func TestJVM_ApplyMutant(t *testing.T) {
+	src := []byte("class Calc {\n    int add(int a, int b) {\n        return a + b;\n    }\n}\n")
+	java := NewJava()
+
+	result, _, err := java.ApplyMutant("Calc.java", src, model.Mutant{FuncName: "Calc#add", Original: "a + b", Mutated: "a - b"})
+	if err != nil {
+		t.Fatalf("ApplyMutant: %v", err)
+	}
+	if string(result) != "class Calc {\n    int add(int a, int b) {\n        return a - b;\n    }\n}\n" {
+		t.Errorf("result = %q", result)
+	}
+
+	if _, _, err := java.ApplyMutant("Calc.java", src, model.Mutant{FuncName: "Calc#add", Original: "a + b;", Mutated: "(a + b;"}); err == nil {
+		t.Error("expected an error for a mutant that doesn't parse")
+	}
+}
+
+func TestJVM_ValidateTestSyntax(t *testing.T) {
+	kotlin := NewKotlin()
+	if err := kotlin.ValidateTestSyntax([]byte("class AddTest {\n    @Test\n    fun adds() = assertEquals(2, add(1, 1))\n}\n")); err != nil {
+		t.Errorf("valid test rejected: %v", err)
+	}
+	if err := kotlin.ValidateTestSyntax([]byte("@Test\nfun adds() = assertEquals(2, add(1, 1))\n")); err == nil {
+		t.Error("expected an error for a test without a class")
+	}
+	if err := kotlin.ValidateTestSyntax([]byte("class AddTest {\n")); err == nil {
+		t.Error("expected an error for an unclosed class")
+	}
+}
+
+func TestJVM_IsTestFile(t *testing.T) {
+	tests := map[string]bool{
+		"src/main/java/com/acme/Cart.java":     false,
+		"src/test/java/com/acme/Checkout.java": true,
+		"app/src/main/kotlin/CartTest.kt":      true,
+		"src/main/java/com/acme/CartIT.java":   true,
+		"src/main/java/com/acme/Contest.java":  false,
+	}
+	java := NewJava()
+	for path, want := range tests {
+		if got := java.IsTestFile(path); got != want {
+			t.Errorf("IsTestFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestKotlinFileClass(t *testing.T) {
+	if got := kotlinFileClass(filepath.Join("src", "main", "kotlin", "cart.kt")); got != "CartKt" {
+		t.Errorf("kotlinFileClass = %q, want CartKt", got)
+	}
+}
@@ -3,16 +3,113 @@ package lang
 import (
 	"time"
 
+	"github.com/yiyuanh/snare/internal/analysis"
 	"github.com/yiyuanh/snare/pkg/model"
 )
 
 // Language defines the interface for language-specific operations.
-// This provides an extensibility seam for supporting languages beyond Go.
+// This provides an extensibility seam for supporting languages beyond Go:
+// a new language implements Language in one file and registers itself (see
+// Register), and diff filtering, project root detection, prompts and test
+// execution all pick it up from the registry.
 type Language interface {
 	Name() string
 	FileExtensions() []string
+	// IsTestFile reports whether a source file (by path) is a test file,
+	// which snare neither analyzes nor mutates.
+	IsTestFile(path string) bool
+	// TestFilePath returns where a generated test for sourceFile goes, both
+	// relative to the project root.
+	TestFilePath(sourceFile string, testName string) string
+	// RootMarkers are files whose presence marks a project root (e.g. go.mod).
+	RootMarkers() []string
+	// Prompt returns the language-specific wording of generation prompts.
+	Prompt() PromptStyle
 	IdentifyChangedFuncs(filePath string, source []byte, hunks []model.Hunk) ([]model.ChangedFunc, error)
-	ApplyMutant(originalSource []byte, original string, mutated string) ([]byte, error)
+	// ApplyMutant replaces mutant.Original with mutant.Mutated within the
+	// function mutant.FuncName of source (filePath is relative to the project
+	// root), checks that the result still parses, and reports where the
+	// mutant was applied.
+	ApplyMutant(filePath string, source []byte, mutant model.Mutant) ([]byte, Site, error)
 	RunTest(dir string, testFile string, testFunc string, timeout time.Duration) (passed bool, output string, err error)
+	// RunSuite runs the project's own tests covering sourceFile (relative to dir).
+	RunSuite(dir string, sourceFile string, timeout time.Duration) (passed bool, output string, err error)
 	ValidateTestSyntax(testCode []byte) error
 }
+
+// ProjectAware is implemented by languages whose tooling depends on the
+// project, such as which test runner it uses. The pipeline replaces the
+// registered language with the result of ForProject before using it.
+type ProjectAware interface {
+	ForProject(root string, opts Options) Language
+}
+
+// InlineTests is implemented by languages whose tests live in the file they
+// test, like Rust's #[cfg(test)] modules. TestFilePath then returns the
+// source file, and the executor runs a test by writing EmbedTest's result
+// there instead of writing a separate test file.
+type InlineTests interface {
+	EmbedTest(source []byte, testCode []byte) []byte
+}
+
+// IncrementalBuild is implemented by languages whose builds keep their
+// outputs in the project and redo only what changed, like Maven's target/ and
+// Gradle's build/. The executor then reuses mirrors of the project across
+// their test runs, undoing each run's changes, instead of starting every run
+// from a fresh mirror and a cold build. Languages without it get fresh
+// mirrors: a reused one could serve stale outputs, such as Python bytecode
+// that only records its source's mtime in whole seconds.
+type IncrementalBuild interface {
+	IncrementalBuild()
+}
+
+// OverlayRunner is implemented by languages whose toolchain can build with
+// files replaced or added without touching the project, like `go test
+// -overlay`. The executor then runs tests in the project itself, sharing its
+// build cache, instead of in a mirrored temp dir.
+type OverlayRunner interface {
+	// RunTestOverlay is RunTest in root with overlay's files (paths relative
+	// to root) in place of what is on disk.
+	RunTestOverlay(root string, overlay map[string][]byte, testFile, testFunc string, timeout time.Duration) (passed bool, output string, err error)
+	// RunSuiteOverlay is RunSuite in root with overlay's files in place.
+	RunSuiteOverlay(root string, overlay map[string][]byte, sourceFile string, timeout time.Duration) (passed bool, output string, err error)
+}
+
+// TypeChecker is implemented by languages that can type-check a package
+// without building and running its tests. Mutants and generated tests are
+// checked before they run, so compile errors go back to the model instead of
+// surfacing as failed test runs.
+type TypeChecker interface {
+	// TypeCheck type-checks the package of sourceFile with the file's content
+	// replaced by source and, if testFile is not "", the test file added with
+	// testCode (paths relative to root). It returns the compile errors, or an
+	// error if the package could not be loaded at all.
+	TypeCheck(root, sourceFile string, source []byte, testFile string, testCode []byte) ([]string, error)
+}
+
+// Options are user settings for language tooling.
+type Options struct {
+	JSRunner string // jest, vitest, node, or "auto" to detect from package.json
+}
+
+// PromptStyle holds the language-specific fragments of the generation and
+// repair prompts.
+type PromptStyle struct {
+	CodeFence       string // markdown code fence language, e.g. "go"
+	PackageLabel    string // what a package is called: "Package", "Module"
+	ImportPrefix    string // written before each import path ("import " for Go)
+	ShowSignature   bool   // print Signature before Body (false when Body includes it)
+	TestFramework   string // e.g. "Go test", "pytest"
+	TestFileNote    string // appended to "Is a complete, self-contained test file"
+	TestNameExample string
+	TestCodeExample string // an example test file as a JSON string literal
+
+	// Rules are extra "IMPORTANT" bullets for tests; "{package}" is replaced
+	// with the package or module of the function under test.
+	Rules []string
+
+	// Comments is how the language writes comments, where snare:ignore
+	// directives are looked for. It isn't part of the prompt, so it is left
+	// out of generation cache keys.
+	Comments analysis.CommentSyntax `json:"-"`
+}
@@ -0,0 +1,169 @@
+package lang
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+// How ApplyMutant found a mutant's original snippet, from strictest to loosest.
+const (
+	MatchExact      = "exact"
+	MatchWhitespace = "whitespace-insensitive" // whitespace runs may differ, e.g. re-indented
+	MatchToken      = "token"                  // same tokens, any spacing: a<b for a < b
+)
+
+// Site is where a mutant was applied.
+type Site struct {
+	Func  string // the function the replacement was scoped to, or "" for the whole file
+	Line  int    // 1-based line where the replaced text starts
+	Match string // MatchExact, MatchWhitespace or MatchToken
+}
+
+func (s Site) String() string {
+	str := fmt.Sprintf("line %d", s.Line)
+	if s.Func != "" {
+		str += " in " + s.Func
+	}
+	if s.Match != MatchExact {
+		str += fmt.Sprintf(" (%s match)", s.Match)
+	}
+	return str
+}
+
+// replaceInFunc replaces the first match of mutant.Original with
+// mutant.Mutated within the function mutant.FuncName of source, found with
+// l's own parser. The whole file is searched only if the function can't be
+// found. Matching is exact if possible, then whitespace-insensitive, then
+// token by token, since snippets are often copied from a reformatted body.
+func replaceInFunc(l Language, filePath string, source []byte, mutant model.Mutant) ([]byte, Site, error) {
+	lo, hi := 0, len(source)
+	site := Site{}
+	if fn, ok := findFunc(l, filePath, source, mutant.FuncName); ok {
+		lo, hi = lineRange(source, fn.StartLine, fn.EndLine)
+		site.Func = fn.ID()
+	}
+
+	start, end, match := findSnippet(string(source[lo:hi]), mutant.Original)
+	if start < 0 {
+		if site.Func != "" {
+			return nil, site, fmt.Errorf("original snippet not found in %s", site.Func)
+		}
+		return nil, site, fmt.Errorf("original snippet not found in source")
+	}
+	start, end = lo+start, lo+end
+	site.Line = bytes.Count(source[:start], []byte("\n")) + 1
+	site.Match = match
+
+	result := make([]byte, 0, len(source)-(end-start)+len(mutant.Mutated))
+	result = append(result, source[:start]...)
+	result = append(result, mutant.Mutated...)
+	result = append(result, source[end:]...)
+	return result, site, nil
+}
+
+// findFunc finds the function with the given identity (ChangedFunc.ID) in
+// source.
+func findFunc(l Language, filePath string, source []byte, id string) (model.ChangedFunc, bool) {
+	if id == "" {
+		return model.ChangedFunc{}, false
+	}
+	all := []model.Hunk{{NewStartLine: 1, NewLineCount: bytes.Count(source, []byte("\n")) + 1}}
+	funcs, err := l.IdentifyChangedFuncs(filePath, source, all)
+	if err != nil {
+		return model.ChangedFunc{}, false
+	}
+	for _, fn := range funcs {
+		if fn.ID() == id {
+			return fn, true
+		}
+	}
+	return model.ChangedFunc{}, false
+}
+
+// lineRange returns the byte offsets of lines first through last (1-based,
+// inclusive) of source.
+func lineRange(source []byte, first, last int) (lo, hi int) {
+	line := 1
+	lo, hi = -1, len(source)
+	for i, c := range source {
+		if line == first && lo < 0 {
+			lo = i
+		}
+		if c == '\n' {
+			if line == last {
+				return max(lo, 0), i + 1
+			}
+			line++
+		}
+	}
+	return max(lo, 0), hi
+}
+
+// findSnippet returns the byte range of the first match of snippet in s and
+// how it matched, or -1 if there is none. A snippet starting or ending with a
+// word never matches part of a longer word: "s := xs[i]" doesn't match
+// "ys := xs[i]".
+func findSnippet(s, snippet string) (start, end int, match string) {
+	tokens := snippetTokens.FindAllString(snippet, -1)
+	if len(tokens) == 0 {
+		return -1, -1, ""
+	}
+	fields := strings.Fields(snippet)
+	for i, f := range fields {
+		fields[i] = regexp.QuoteMeta(f)
+	}
+	patterns := []struct{ core, match string }{
+		{regexp.QuoteMeta(snippet), MatchExact},
+		{strings.Join(fields, `\s+`), MatchWhitespace},
+		{tokenPattern(tokens), MatchToken},
+	}
+	for _, p := range patterns {
+		re := regexp.MustCompile(bounded(p.core, tokens))
+		if loc := re.FindStringSubmatchIndex(s); loc != nil {
+			return loc[2], loc[3], p.match
+		}
+	}
+	return -1, -1, ""
+}
+
+// snippetTokens splits code into words (identifiers, keywords, numbers) and
+// single punctuation characters.
+var snippetTokens = regexp.MustCompile(`[\p{L}\p{N}_$]+|\S`)
+
+// wordToken matches a word token.
+var wordToken = regexp.MustCompile(`^[\p{L}\p{N}_$]`)
+
+// tokenPattern returns a regexp matching tokens with any spacing between
+// them, except that adjacent words still need space between them.
+func tokenPattern(tokens []string) string {
+	var sb strings.Builder
+	for i, t := range tokens {
+		if i > 0 {
+			if wordToken.MatchString(tokens[i-1]) && wordToken.MatchString(t) {
+				sb.WriteString(`\s+`)
+			} else {
+				sb.WriteString(`\s*`)
+			}
+		}
+		sb.WriteString(regexp.QuoteMeta(t))
+	}
+	return sb.String()
+}
+
+// bounded wraps the pattern core for a snippet of tokens in group 1, and
+// keeps a word at either end from continuing into a longer one.
+func bounded(core string, tokens []string) string {
+	const notWord = `[^\p{L}\p{N}_$]`
+	pattern := "(" + core + ")"
+	if wordToken.MatchString(tokens[0]) {
+		pattern = "(?:^|" + notWord + ")" + pattern
+	}
+	if wordToken.MatchString(tokens[len(tokens)-1]) {
+		pattern += "(?:$|" + notWord + ")"
+	}
+	return pattern
+}
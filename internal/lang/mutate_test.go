@@ -0,0 +1,47 @@
+package lang
+
+import "testing"
+
+func TestFindSnippet(t *testing.T) {
+	const src = "\tif err != nil {\n\t\treturn nil,\n\t\t\terr\n\t}\n\tx := a<b\n\tys := xs[i]\n"
+	tests := []struct {
+		snippet string
+		want    string
+		match   string
+	}{
+		{"return nil,", "return nil,", MatchExact},
+		{"return nil, err", "return nil,\n\t\t\terr", MatchWhitespace},
+		{"x := a < b", "x := a<b", MatchToken},
+		{"s := xs[i]", "", ""}, // must not match inside ys
+		{"  ", "", ""},
+	}
+	for _, tc := range tests {
+		start, end, match := findSnippet(src, tc.snippet)
+		got := ""
+		if start >= 0 {
+			got = src[start:end]
+		}
+		if got != tc.want || match != tc.match {
+			t.Errorf("findSnippet(%q) = %q (%s), want %q (%s)", tc.snippet, got, match, tc.want, tc.match)
+		}
+	}
+}
+
+func TestLineRange(t *testing.T) {
+	src := []byte("a\nbb\nccc\n")
+	if lo, hi := lineRange(src, 2, 3); string(src[lo:hi]) != "bb\nccc\n" {
+		t.Errorf("lineRange(2, 3) = %q", src[lo:hi])
+	}
+	if lo, hi := lineRange(src, 3, 9); string(src[lo:hi]) != "ccc\n" {
+		t.Errorf("lineRange(3, 9) = %q", src[lo:hi])
+	}
+}
+
+func TestSiteString(t *testing.T) {
+	if got := (Site{Func: "Cart.Add", Line: 42, Match: MatchExact}).String(); got != "line 42 in Cart.Add" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (Site{Line: 7, Match: MatchToken}).String(); got != "line 7 (token match)" {
+		t.Errorf("String() = %q", got)
+	}
+}
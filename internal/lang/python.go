@@ -2,6 +2,7 @@ package lang
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
 	"encoding/json"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/yiyuanh/snare/internal/analysis"
 	"github.com/yiyuanh/snare/pkg/model"
 )
 
@@ -24,6 +26,10 @@ func NewPython() *Python {
 	return &Python{}
 }
 
+func init() {
+	Register(NewPython())
+}
+
 func (p *Python) Name() string {
 	return "python"
 }
@@ -32,9 +38,42 @@ func (p *Python) FileExtensions() []string {
 	return []string{".py"}
 }
 
+func (p *Python) IsTestFile(path string) bool {
+	base := filepath.Base(path)
+	return strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py")
+}
+
+// TestFilePath puts the test next to the source file as test_snare_<name>.py.
+func (p *Python) TestFilePath(sourceFile string, testName string) string {
+	// LLM-generated Python test names already have test_ prefix; strip it to avoid double prefix
+	name := strings.TrimPrefix(strings.ToLower(testName), "test_")
+	return filepath.Join(filepath.Dir(sourceFile), fmt.Sprintf("test_snare_%s.py", name))
+}
+
+func (p *Python) RootMarkers() []string {
+	return []string{"setup.py", "pyproject.toml", "requirements.txt"}
+}
+
+func (p *Python) Prompt() PromptStyle {
+	return PromptStyle{
+		CodeFence:       "python",
+		PackageLabel:    "Module",
+		TestFramework:   "pytest",
+		TestNameExample: "test_func_name_risk_description",
+		TestCodeExample: `"import pytest\nfrom module import function\n\ndef test_func_name_risk_description():\n    # test body\n    assert result == expected"`,
+		Rules: []string{
+			"Each test must be a complete, self-contained Python test file",
+			`Import the function under test from the module "{package}"`,
+			"Use pytest assertions (assert statements), not unittest",
+		},
+		Comments: analysis.CommentSyntax{Line: "#", Attributes: []string{"@"}},
+	}
+}
+
 // pythonFuncInfo represents the JSON output from the Python helper script.
 type pythonFuncInfo struct {
 	Name      string   `json:"name"`
+	QualName  string   `json:"qualname"`
 	Signature string   `json:"signature"`
 	Body      string   `json:"body"`
 	StartLine int      `json:"start_line"`
@@ -91,15 +130,16 @@ func (p *Python) IdentifyChangedFuncs(filePath string, source []byte, hunks []mo
 		}
 
 		result = append(result, model.ChangedFunc{
-			FilePath:    filePath,
-			Package:     module,
-			Name:        fn.Name,
-			Signature:   fn.Signature,
-			Body:        fn.Body,
-			StartLine:   fn.StartLine,
-			EndLine:     fn.EndLine,
-			Imports:     fn.Imports,
-			DiffContext: strings.Join(diffParts, "\n"),
+			FilePath:      filePath,
+			Package:       module,
+			Name:          fn.Name,
+			QualifiedName: fn.QualName,
+			Signature:     fn.Signature,
+			Body:          fn.Body,
+			StartLine:     fn.StartLine,
+			EndLine:       fn.EndLine,
+			Imports:       fn.Imports,
+			DiffContext:   strings.Join(diffParts, "\n"),
 		})
 	}
 	return result, nil
@@ -115,34 +155,52 @@ func overlapsHunksPython(fn pythonFuncInfo, hunks []model.Hunk) bool {
 	return false
 }
 
-func (p *Python) ApplyMutant(originalSource []byte, original string, mutated string) ([]byte, error) {
-	result := strings.Replace(string(originalSource), original, mutated, 1)
-	if result == string(originalSource) {
-		return nil, fmt.Errorf("original snippet not found in source")
+func (p *Python) ApplyMutant(filePath string, source []byte, mutant model.Mutant) ([]byte, Site, error) {
+	result, site, err := replaceInFunc(p, filePath, source, mutant)
+	if err != nil {
+		return nil, site, err
 	}
 	// Validate that the mutated source is still valid Python
 	cmd := exec.Command("python3", "-c", fmt.Sprintf("import ast; ast.parse(%q)", result))
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("mutated code is not valid Python: %s", stderr.String())
+		return nil, site, fmt.Errorf("mutated code is not valid Python: %s", stderr.String())
 	}
-	return []byte(result), nil
+	return result, site, nil
 }
 
 func (p *Python) RunTest(dir string, testFile string, testFunc string, timeout time.Duration) (passed bool, output string, err error) {
+	return pytest(dir, timeout, "-xvs", fmt.Sprintf("%s::%s", testFile, testFunc))
+}
+
+// RunSuite runs the project's pytest suite from the project root. Python tests
+// usually live apart from the code they cover, so the whole suite is run, and
+// the timeout bounds all of it rather than each test.
+func (p *Python) RunSuite(dir string, sourceFile string, timeout time.Duration) (passed bool, output string, err error) {
+	return pytest(dir, timeout, "-x", "-q")
+}
+
+// pytest runs pytest in dir with the given arguments and a per-test timeout.
+func pytest(dir string, timeout time.Duration, extraArgs ...string) (passed bool, output string, err error) {
 	timeoutSec := int(timeout.Seconds())
 	if timeoutSec < 1 {
 		timeoutSec = 1
 	}
 
-	args := []string{"-m", "pytest", "-xvs", fmt.Sprintf("%s::%s", testFile, testFunc), fmt.Sprintf("--timeout=%d", timeoutSec)}
-	cmd := exec.Command("python3", args...)
+	args := append([]string{"-m", "pytest"}, extraArgs...)
+	args = append(args, fmt.Sprintf("--timeout=%d", timeoutSec))
+
+	// --timeout limits each test; the context limits the run as a whole
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "python3", args...)
 	cmd.Dir = dir
 
-	// Set PYTHONPATH to the temp dir root so imports work
+	// Set PYTHONPATH to the temp dir root so imports work. Bytecode isn't
+	// written: it would only be valid for one version of the source under test
 	env := os.Environ()
-	env = append(env, fmt.Sprintf("PYTHONPATH=%s", dir))
+	env = append(env, fmt.Sprintf("PYTHONPATH=%s", dir), "PYTHONDONTWRITEBYTECODE=1")
 	cmd.Env = env
 
 	var buf bytes.Buffer
@@ -151,7 +209,9 @@ func (p *Python) RunTest(dir string, testFile string, testFunc string, timeout t
 
 	err = cmd.Run()
 	output = buf.String()
-
+	if ctx.Err() != nil {
+		return false, output + fmt.Sprintf("\nsnare: test timed out after %s\n", timeout), nil
+	}
 	if err != nil {
 		if _, ok := err.(*exec.ExitError); ok {
 			// Test failed (non-zero exit) — expected for catching tests
@@ -11,6 +11,7 @@ Output: JSON array of function objects:
 [
   {
     "name": "function_name",
+    "qualname": "ClassName.function_name",
     "signature": "def function_name(arg1, arg2):",
     "body": "full function source including def line",
     "start_line": 10,
@@ -66,27 +67,25 @@ def extract_functions(file_path):
     module = parts[-1].replace(".py", "") if parts else module
 
     functions = []
+    _collect_funcs(tree, lines, imports, module, [], functions)
+    return functions
+
 
-    for node in ast.iter_child_nodes(tree):
+def _collect_funcs(parent, lines, imports, module, class_path, functions):
+    """Collect functions and methods, recursing into (nested) classes."""
+    for node in ast.iter_child_nodes(parent):
         if isinstance(node, (ast.FunctionDef, ast.AsyncFunctionDef)):
-            func_info = _extract_func(node, lines, imports, module)
+            func_info = _extract_func(node, lines, imports, module, class_path)
             if func_info:
                 functions.append(func_info)
         elif isinstance(node, ast.ClassDef):
-            # Extract methods from classes
-            for item in ast.iter_child_nodes(node):
-                if isinstance(item, (ast.FunctionDef, ast.AsyncFunctionDef)):
-                    func_info = _extract_func(
-                        item, lines, imports, module,
-                        class_name=node.name
-                    )
-                    if func_info:
-                        functions.append(func_info)
-
-    return functions
+            _collect_funcs(
+                node, lines, imports, module,
+                class_path + [node.name], functions
+            )
 
 
-def _extract_func(node, lines, imports, module, class_name=None):
+def _extract_func(node, lines, imports, module, class_path):
     """Extract metadata for a single function/method node."""
     start_line = node.lineno
     end_line = node.end_lineno or node.lineno
@@ -108,13 +107,12 @@ def _extract_func(node, lines, imports, module, class_name=None):
 
     signature = "".join(sig_lines).rstrip()
 
-    # Build qualified name
-    name = node.name
-    if class_name:
-        name = f"{class_name}.{node.name}"
+    # Build qualified name from the enclosing class path
+    qualname = ".".join(class_path + [node.name])
 
     return {
-        "name": name,
+        "name": node.name,
+        "qualname": qualname,
         "signature": signature,
         "body": body,
         "start_line": start_line,
@@ -0,0 +1,35 @@
+package lang
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPython_RunSuiteTimeout(t *testing.T) {
+	// A python3 whose suite never finishes: pytest's --timeout only limits
+	// each test, so the run as a whole must be cut off
+	bin := t.TempDir()
+	script := "#!/bin/sh\nexec sleep 30\n"
+	if err := os.WriteFile(filepath.Join(bin, "python3"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	start := time.Now()
+	passed, output, err := NewPython().RunSuite(t.TempDir(), "app.py", 200*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passed {
+		t.Error("a suite that timed out must not pass")
+	}
+	if !strings.Contains(output, "timed out after 200ms") {
+		t.Errorf("output does not report the timeout:\n%s", output)
+	}
+	if d := time.Since(start); d > 10*time.Second {
+		t.Errorf("RunSuite took %s", d)
+	}
+}
@@ -0,0 +1,56 @@
+package lang
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// languages holds every supported language, in registration order.
+var languages []Language
+
+// byExtension maps a file extension (e.g. ".go") to its language.
+var byExtension = make(map[string]Language)
+
+// Register adds a language, claiming its FileExtensions. Each language
+// registers itself from an init function in its own file. A later
+// registration of the same extension replaces the earlier one.
+func Register(l Language) {
+	languages = append(languages, l)
+	for _, ext := range l.FileExtensions() {
+		byExtension[ext] = l
+	}
+}
+
+// Languages returns the registered languages.
+func Languages() []Language {
+	return languages
+}
+
+// ForFile returns the language for a file name, or nil if no registered
+// language handles its extension.
+func ForFile(name string) Language {
+	return byExtension[strings.ToLower(filepath.Ext(name))]
+}
+
+// IsSourceFile reports whether a registered language handles the file and it
+// is not one of that language's test files.
+func IsSourceFile(name string) bool {
+	l := ForFile(name)
+	return l != nil && !l.IsTestFile(name)
+}
+
+// RootMarkers returns the project root markers of every registered language,
+// without duplicates (Java and Kotlin share theirs).
+func RootMarkers() []string {
+	var markers []string
+	seen := make(map[string]bool)
+	for _, l := range languages {
+		for _, m := range l.RootMarkers() {
+			if !seen[m] {
+				seen[m] = true
+				markers = append(markers, m)
+			}
+		}
+	}
+	return markers
+}
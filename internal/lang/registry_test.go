@@ -0,0 +1,79 @@
+package lang
+
+import "testing"
+
+func TestForFile(t *testing.T) {
+	tests := map[string]string{
+		"svc/handler.go":    "go",
+		"api/user.pb.go":    "go",
+		"scripts/report.py": "python",
+		"Main.PY":           "python",
+		"web/src/cart.ts":   "typescript",
+		"web/index.mjs":     "typescript",
+		"cli/src/main.rs":   "rust",
+		"src/Cart.java":     "java",
+		"src/cart.kt":       "kotlin",
+		"README.md":         "",
+		"Makefile":          "",
+	}
+	for name, want := range tests {
+		got := ""
+		if l := ForFile(name); l != nil {
+			got = l.Name()
+		}
+		if got != want {
+			t.Errorf("ForFile(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestIsSourceFile(t *testing.T) {
+	tests := map[string]bool{
+		"pkg/server.go":       true,
+		"pkg/server_test.go":  false,
+		"app/views.py":        true,
+		"app/test_views.py":   false,
+		"app/views_test.py":   false,
+		"docs/guide.md":       false,
+		"app/testing_util.py": true,
+		"web/cart.ts":         true,
+		"web/cart.test.ts":    false,
+		"cli/src/args.rs":     true,
+		"cli/tests/smoke.rs":  false,
+		"src/main/Cart.java":  true,
+		"src/test/Cart.java":  false,
+	}
+	for name, want := range tests {
+		if got := IsSourceFile(name); got != want {
+			t.Errorf("IsSourceFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestTestFilePath(t *testing.T) {
+	if got := NewGo().TestFilePath("pkg/server.go", "TestStart_Nil"); got != "pkg/snare_teststart_nil_test.go" {
+		t.Errorf("Go: %s", got)
+	}
+	if got := NewPython().TestFilePath("app/views.py", "test_index_empty"); got != "app/test_snare_index_empty.py" {
+		t.Errorf("Python: %s", got)
+	}
+	if got := NewTypeScript().TestFilePath("web/cart.ts", "Cart.add keeps order"); got != "web/cart_cart_add_keeps_order.snare.test.ts" {
+		t.Errorf("TypeScript: %s", got)
+	}
+	if got := NewRust().TestFilePath("src/args.rs", "parses_flags"); got != "src/args.rs" {
+		t.Errorf("Rust: %s", got)
+	}
+	if got := NewJava().TestFilePath("core/src/main/java/com/acme/Cart.java", "CartAddOverflowTest"); got != "core/src/test/java/com/acme/CartAddOverflowTest.java" {
+		t.Errorf("Java: %s", got)
+	}
+	if got := NewKotlin().TestFilePath("Cart.kt", "cart add-overflow"); got != "cart_add_overflow.kt" {
+		t.Errorf("Kotlin: %s", got)
+	}
+}
+
+func TestRootMarkers(t *testing.T) {
+	markers := RootMarkers()
+	if len(markers) < 2 || markers[0] != "go.mod" {
+		t.Errorf("RootMarkers() = %v, want go.mod first", markers)
+	}
+}
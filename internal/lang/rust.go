@@ -0,0 +1,305 @@
+package lang
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yiyuanh/snare/internal/analysis"
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+// Rust implements the Language interface for Rust crates. Generated tests are
+// not separate files: each is appended to the file under test as a
+// #[cfg(test)] mod snare_tests module (see EmbedTest), so they can reach
+// private items, and run with cargo test.
+type Rust struct{}
+
+func NewRust() *Rust {
+	return &Rust{}
+}
+
+func init() {
+	Register(NewRust())
+}
+
+func (r *Rust) Name() string {
+	return "rust"
+}
+
+func (r *Rust) FileExtensions() []string {
+	return []string{".rs"}
+}
+
+// IsTestFile reports integration tests (tests/), benchmarks (benches/) and
+// tests.rs modules. Unit tests inside source files are skipped by the parser.
+func (r *Rust) IsTestFile(p string) bool {
+	p = "/" + filepath.ToSlash(p)
+	return strings.Contains(p, "/tests/") || strings.Contains(p, "/benches/") || path.Base(p) == "tests.rs"
+}
+
+// TestFilePath returns the source file itself: the test is embedded in it.
+func (r *Rust) TestFilePath(sourceFile string, testName string) string {
+	return sourceFile
+}
+
+func (r *Rust) RootMarkers() []string {
+	return []string{"Cargo.toml"}
+}
+
+func (r *Rust) Prompt() PromptStyle {
+	return PromptStyle{
+		CodeFence:       "rust",
+		PackageLabel:    "Module",
+		ShowSignature:   true,
+		TestFramework:   "Rust #[test]",
+		TestFileNote:    " module body: use declarations and #[test] functions",
+		TestNameExample: "func_name_risk_description",
+		TestCodeExample: `"use super::*;\n\n#[test]\nfn func_name_risk_description() {\n    assert_eq!(func_name(1), 2);\n}"`,
+		Rules: []string{
+			"The test code is placed in a #[cfg(test)] mod snare_tests module appended to the file of {package}; write only the module's contents, not the mod snare_tests wrapper",
+			"Start with use super::*; private functions and types of the file are then in scope",
+			"The #[test] function must be named exactly as test_name",
+			"Use only the standard library and the crate's existing dependencies",
+		},
+		Comments: analysis.CommentSyntax{Line: "//", BlockStart: "/*", BlockEnd: "*/", Attributes: []string{"#["}},
+	}
+}
+
+func (r *Rust) IdentifyChangedFuncs(filePath string, source []byte, hunks []model.Hunk) ([]model.ChangedFunc, error) {
+	file, err := parseRust(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("parsing Rust: %w", err)
+	}
+	module := rustModulePath(filePath)
+
+	var result []model.ChangedFunc
+	for _, fn := range file.Funcs {
+		if !overlapsHunksRust(fn, hunks) {
+			continue
+		}
+
+		var diffParts []string
+		for _, h := range hunks {
+			hunkEnd := h.NewStartLine + h.NewLineCount - 1
+			if hunkEnd >= fn.StartLine && h.NewStartLine <= fn.EndLine {
+				diffParts = append(diffParts, h.Content)
+			}
+		}
+
+		result = append(result, model.ChangedFunc{
+			FilePath:      filePath,
+			Package:       module,
+			Name:          fn.Name,
+			QualifiedName: fn.QualName,
+			Signature:     fn.Signature,
+			Body:          fn.Body,
+			StartLine:     fn.StartLine,
+			EndLine:       fn.EndLine,
+			Imports:       file.Uses,
+			TypeDefs:      file.TypeDefs,
+			DiffContext:   strings.Join(diffParts, "\n"),
+		})
+	}
+	return result, nil
+}
+
+func overlapsHunksRust(fn rustFunc, hunks []model.Hunk) bool {
+	for _, h := range hunks {
+		hunkEnd := h.NewStartLine + h.NewLineCount - 1
+		if hunkEnd >= fn.StartLine && h.NewStartLine <= fn.EndLine {
+			return true
+		}
+	}
+	return false
+}
+
+// rustModulePath returns the module path of a source file within its crate,
+// following Cargo's layout: "crate" for src/lib.rs, src/main.rs and
+// src/bin/*.rs, "crate::net::conn" for src/net/conn.rs or src/net/conn/mod.rs.
+func rustModulePath(file string) string {
+	parts := strings.Split(filepath.ToSlash(file), "/")
+	src := -1
+	for i, p := range parts[:len(parts)-1] {
+		if p == "src" {
+			src = i
+		}
+	}
+	if src < 0 {
+		return "crate" // tests/, examples/ or a single-file crate root
+	}
+	rest := parts[src+1:]
+	if rest[0] == "bin" && len(rest) >= 2 {
+		// src/bin/tool.rs or src/bin/tool/main.rs is its own crate root
+		if len(rest) == 2 {
+			return "crate"
+		}
+		rest = rest[2:]
+	}
+	last := strings.TrimSuffix(rest[len(rest)-1], ".rs")
+	rest[len(rest)-1] = last
+	if len(rest) == 1 && (last == "lib" || last == "main") {
+		return "crate"
+	}
+	if last == "mod" {
+		rest = rest[:len(rest)-1]
+	}
+	return "crate::" + strings.Join(rest, "::")
+}
+
+// crateDir returns the directory (relative, as file is) of the nearest
+// Cargo.toml above file in dir, or "." if there is none below dir.
+func crateDir(dir, file string) string {
+	for d := filepath.Dir(file); d != "." && d != "/" && d != ""; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(dir, d, "Cargo.toml")); err == nil {
+			return d
+		}
+	}
+	return "."
+}
+
+// EmbedTest appends testCode to source as a #[cfg(test)] mod snare_tests
+// module, adding the wrapper and use super::* unless the code has them.
+func (r *Rust) EmbedTest(source []byte, testCode []byte) []byte {
+	code := strings.TrimSpace(string(testCode))
+	var buf bytes.Buffer
+	buf.Write(source)
+	if len(source) > 0 && !bytes.HasSuffix(source, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	if strings.Contains(code, "mod snare_tests") {
+		buf.WriteString("\n" + code + "\n")
+		return buf.Bytes()
+	}
+	buf.WriteString("\n#[cfg(test)]\nmod snare_tests {\n")
+	if !strings.Contains(code, "use super::*") {
+		buf.WriteString("use super::*;\n\n")
+	}
+	buf.WriteString(code + "\n}\n")
+	return buf.Bytes()
+}
+
+// ApplyMutant replaces the original snippet and checks that the result still
+// parses. A mutant is rejected only if the original passes the
+// check the mutated source fails.
+func (r *Rust) ApplyMutant(filePath string, source []byte, mutant model.Mutant) ([]byte, Site, error) {
+	result, site, err := replaceInFunc(r, filePath, source, mutant)
+	if err != nil {
+		return nil, site, err
+	}
+	if err := checkRustSyntax(result); err != nil {
+		if checkRustSyntax(source) == nil {
+			return nil, site, fmt.Errorf("mutated code is not valid Rust: %w", err)
+		}
+	}
+	return result, site, nil
+}
+
+// checkRustSyntax parses source with rustfmt, which reports syntax errors
+// without resolving names or types. Without rustfmt it only checks that
+// brackets, strings and comments are balanced.
+func checkRustSyntax(source []byte) error {
+	if _, err := exec.LookPath("rustfmt"); err != nil {
+		_, err := parseRust(string(source))
+		return err
+	}
+	cmd := exec.Command("rustfmt", "--edition", "2021", "--emit", "stdout")
+	cmd.Stdin = bytes.NewReader(source)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return errors.New(strings.TrimSpace(stderr.String()))
+		}
+		return fmt.Errorf("running rustfmt: %w", err)
+	}
+	return nil
+}
+
+// RunTest runs the embedded test by its full path, e.g.
+// net::conn::snare_tests::reconnects, from the crate containing testFile.
+func (r *Rust) RunTest(dir string, testFile string, testFunc string, timeout time.Duration) (passed bool, output string, err error) {
+	name := strings.TrimPrefix(rustModulePath(testFile)+"::", "crate::") + "snare_tests::" + testFunc
+	passed, output, err = cargoTest(filepath.Join(dir, crateDir(dir, testFile)), timeout, name, "--", "--exact")
+	if err == nil && passed && !strings.Contains(output, "test "+name+" ... ok") {
+		// An exact filter that matches nothing passes; treat it as a failure
+		// the repair step can explain
+		return false, output + fmt.Sprintf("\nsnare: no test named %s ran; the #[test] function must be named %s\n", name, testFunc), nil
+	}
+	return passed, output, err
+}
+
+// RunSuite runs the tests of the crate containing sourceFile.
+func (r *Rust) RunSuite(dir string, sourceFile string, timeout time.Duration) (passed bool, output string, err error) {
+	return cargoTest(filepath.Join(dir, crateDir(dir, sourceFile)), timeout)
+}
+
+// cargoTarget is the CARGO_TARGET_DIR for test builds. Temp copies must not
+// build into the project's own target/ (runner.TempDir leaves it out), and a
+// directory shared across runs keeps dependencies compiled.
+func cargoTarget() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "snare", "cargo-target")
+}
+
+// cargoTest builds the crate's tests in crate, then runs cargo test with args
+// under the timeout. Build failures count as failed tests.
+func cargoTest(crate string, timeout time.Duration, args ...string) (passed bool, output string, err error) {
+	env := append(os.Environ(), "CARGO_TARGET_DIR="+cargoTarget(), "CARGO_TERM_COLOR=never")
+
+	// Build first so compilation doesn't count against the test timeout
+	var buf bytes.Buffer
+	build := exec.Command("cargo", "test", "--no-run")
+	build.Dir = crate
+	build.Env = env
+	build.Stdout = &buf
+	build.Stderr = &buf
+	if err := build.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return false, buf.String(), nil
+		}
+		return false, buf.String(), fmt.Errorf("running cargo: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "cargo", append([]string{"test"}, args...)...)
+	cmd.Dir = crate
+	cmd.Env = env
+	buf.Reset()
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+
+	err = cmd.Run()
+	output = buf.String()
+	if ctx.Err() != nil {
+		return false, output + fmt.Sprintf("\nsnare: test timed out after %s\n", timeout), nil
+	}
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			// Test failed (non-zero exit) — expected for catching tests
+			return false, output, nil
+		}
+		return false, output, fmt.Errorf("running test: %w", err)
+	}
+	return true, output, nil
+}
+
+// ValidateTestSyntax checks the test code as it will be embedded.
+func (r *Rust) ValidateTestSyntax(testCode []byte) error {
+	if err := checkRustSyntax(r.EmbedTest(nil, testCode)); err != nil {
+		return fmt.Errorf("invalid Rust syntax: %w", err)
+	}
+	return nil
+}
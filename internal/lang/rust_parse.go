@@ -0,0 +1,537 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A lightweight Rust item parser: enough of a lexer to skip comments, strings
+// and char literals, and enough of a parser to find functions, impl methods,
+// use declarations and type definitions by brace structure. It does not
+// understand expressions; rustfmt does the real syntax checking.
+
+type rustTokenKind int
+
+const (
+	rustIdent rustTokenKind = iota
+	rustPunct
+	rustLiteral
+	rustLifetime
+)
+
+type rustToken struct {
+	kind       rustTokenKind
+	text       string
+	start, end int // byte offsets into the source
+	line       int // 1-based line of start
+}
+
+// scanRust splits src into tokens, dropping whitespace and comments.
+func scanRust(src string) ([]rustToken, error) {
+	var tokens []rustToken
+	line := 1
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		start, startLine := i, line
+		switch {
+		case c == '\n':
+			line++
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			// Block comments nest
+			depth := 0
+			for i < len(src) {
+				if strings.HasPrefix(src[i:], "/*") {
+					depth++
+					i += 2
+				} else if strings.HasPrefix(src[i:], "*/") {
+					depth--
+					i += 2
+					if depth == 0 {
+						break
+					}
+				} else {
+					if src[i] == '\n' {
+						line++
+					}
+					i++
+				}
+			}
+			if depth != 0 {
+				return nil, fmt.Errorf("line %d: unterminated block comment", startLine)
+			}
+			continue
+		}
+
+		kind := rustPunct
+		if n, ok := rawStringLen(src[i:]); ok {
+			if n < 0 {
+				return nil, fmt.Errorf("line %d: unterminated raw string", startLine)
+			}
+			kind = rustLiteral
+			i += n
+		} else if c == '"' || ((c == 'b' || c == 'c') && i+1 < len(src) && src[i+1] == '"') {
+			if c != '"' {
+				i++
+			}
+			end := quotedEnd(src, i, '"')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", startLine)
+			}
+			kind = rustLiteral
+			i = end
+		} else if c == 'b' && i+1 < len(src) && src[i+1] == '\'' {
+			end := quotedEnd(src, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated byte literal", startLine)
+			}
+			kind = rustLiteral
+			i = end
+		} else if c == '\'' {
+			// A char literal ('a', '\n', '\u{1F600}') or a lifetime or label ('a)
+			r, size := utf8.DecodeRuneInString(src[i+1:])
+			if r == '\\' || (i+1+size < len(src) && src[i+1+size] == '\'') {
+				end := quotedEnd(src, i, '\'')
+				if end < 0 {
+					return nil, fmt.Errorf("line %d: unterminated char literal", startLine)
+				}
+				kind = rustLiteral
+				i = end
+			} else {
+				kind = rustLifetime
+				i++
+				for i < len(src) && isRustIdentChar(src, i) {
+					i += runeLen(src, i)
+				}
+			}
+		} else if isRustIdentStart(src, i) {
+			kind = rustIdent
+			if strings.HasPrefix(src[i:], "r#") {
+				i += 2
+			}
+			for i < len(src) && isRustIdentChar(src, i) {
+				i += runeLen(src, i)
+			}
+		} else if c >= '0' && c <= '9' {
+			kind = rustLiteral
+			for i < len(src) && (isRustIdentChar(src, i) || (src[i] == '.' && !strings.HasPrefix(src[i:], "..") && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9')) {
+				i++
+			}
+		} else if strings.HasPrefix(src[i:], "->") || strings.HasPrefix(src[i:], "=>") || strings.HasPrefix(src[i:], "::") {
+			i += 2
+		} else {
+			i += runeLen(src, i)
+		}
+		line += strings.Count(src[start:i], "\n")
+		tokens = append(tokens, rustToken{kind: kind, text: src[start:i], start: start, end: i, line: startLine})
+	}
+	return tokens, nil
+}
+
+// rawStringLen reports whether s starts with a raw string (r"..", r#".."#,
+// br"..", cr".."), and if so its length, or -1 if it is unterminated.
+func rawStringLen(s string) (int, bool) {
+	i := 0
+	if strings.HasPrefix(s, "br") || strings.HasPrefix(s, "cr") {
+		i = 2
+	} else if strings.HasPrefix(s, "r") {
+		i = 1
+	} else {
+		return 0, false
+	}
+	hashes := 0
+	for i < len(s) && s[i] == '#' {
+		hashes++
+		i++
+	}
+	if i >= len(s) || s[i] != '"' {
+		return 0, false
+	}
+	closing := "\"" + strings.Repeat("#", hashes)
+	end := strings.Index(s[i+1:], closing)
+	if end < 0 {
+		return -1, true
+	}
+	return i + 1 + end + len(closing), true
+}
+
+// quotedEnd returns the offset just past the quote closing the literal that
+// opens at src[i], honoring backslash escapes, or -1.
+func quotedEnd(src string, i int, quote byte) int {
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		}
+	}
+	return -1
+}
+
+func isRustIdentStart(src string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(src[i:])
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isRustIdentChar(src string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(src[i:])
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func runeLen(src string, i int) int {
+	_, size := utf8.DecodeRuneInString(src[i:])
+	return size
+}
+
+// matchBrackets maps the index of every opening (, [ and { token to the index
+// of its closing token, and reports the first unbalanced bracket.
+func matchBrackets(tokens []rustToken) (map[int]int, error) {
+	pairs := map[string]string{")": "(", "]": "[", "}": "{"}
+	match := make(map[int]int)
+	var stack []int
+	for i, t := range tokens {
+		if t.kind != rustPunct {
+			continue
+		}
+		switch t.text {
+		case "(", "[", "{":
+			stack = append(stack, i)
+		case ")", "]", "}":
+			if len(stack) == 0 || tokens[stack[len(stack)-1]].text != pairs[t.text] {
+				return nil, fmt.Errorf("line %d: unexpected %q", t.line, t.text)
+			}
+			match[stack[len(stack)-1]] = i
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if len(stack) > 0 {
+		t := tokens[stack[len(stack)-1]]
+		return nil, fmt.Errorf("line %d: unclosed %q", t.line, t.text)
+	}
+	return match, nil
+}
+
+// rustFunc is a function or method found by parseRust.
+type rustFunc struct {
+	Name      string
+	QualName  string // "parse", "Parser::next" or "<Token as Display>::fmt"
+	Signature string
+	Body      string
+	StartLine int
+	EndLine   int
+}
+
+// rustFile is what parseRust extracts from a source file.
+type rustFile struct {
+	Funcs    []rustFunc
+	Uses     []string // top-level use declarations
+	TypeDefs []string // top-level struct, enum and union definitions
+}
+
+// rustQualifiers may precede "fn" (or struct, enum, ...) in an item.
+var rustQualifiers = map[string]bool{
+	"pub": true, "async": true, "const": true, "unsafe": true, "extern": true, "default": true,
+}
+
+// parseRust finds the functions of src outside test code: free functions,
+// functions in inline modules (qualified by module path) and impl methods.
+// Function bodies are not searched for nested functions.
+func parseRust(src string) (*rustFile, error) {
+	tokens, err := scanRust(src)
+	if err != nil {
+		return nil, err
+	}
+	match, err := matchBrackets(tokens)
+	if err != nil {
+		return nil, err
+	}
+	p := &rustParser{src: src, tokens: tokens, match: match, file: &rustFile{}}
+	p.items(0, len(tokens), "", true)
+	return p.file, nil
+}
+
+type rustParser struct {
+	src    string
+	tokens []rustToken
+	match  map[int]int
+	file   *rustFile
+}
+
+// items parses the items in tokens[lo:hi], the top level of the file or the
+// block of an inline module or impl. prefix qualifies the names of functions
+// found there: "" at the top level, "net::" in mod net, "Conn::" in impl Conn.
+func (p *rustParser) items(lo, hi int, prefix string, topLevel bool) {
+	var attrs []string
+	itemStart := -1 // first token of the current item, including qualifiers
+	for i := lo; i < hi; i++ {
+		t := p.tokens[i]
+		if itemStart < 0 {
+			itemStart = i
+		}
+
+		// Attributes: #[...] or #![...]
+		if t.text == "#" {
+			j := i + 1
+			if j < hi && p.tokens[j].text == "!" {
+				j++
+			}
+			if j < hi && p.tokens[j].text == "[" {
+				end := p.match[j]
+				attrs = append(attrs, p.text(j, end))
+				i = end
+				itemStart = -1
+				continue
+			}
+		}
+
+		// Qualifiers: pub, pub(crate), const fn, unsafe, extern "C", ...
+		if t.kind == rustIdent && rustQualifiers[t.text] && i+1 < hi {
+			next := p.tokens[i+1]
+			switch {
+			case t.text == "pub" && next.text == "(":
+				i = p.match[i+1]
+				continue
+			case t.text == "extern" && next.kind == rustLiteral:
+				i++
+				continue
+			case t.text != "const" || next.text == "fn" || rustQualifiers[next.text]:
+				continue
+			}
+			// "const X: T = ...;" is an item, skipped below
+		}
+
+		isTest := hasRustAttr(attrs, "test") || hasRustAttr(attrs, "cfg(test)")
+		switch t.text {
+		case "fn":
+			i = p.function(i, hi, itemStart, prefix, isTest)
+		case "mod":
+			if i+2 < hi && p.tokens[i+2].text == "{" {
+				name := p.tokens[i+1].text
+				end := p.match[i+2]
+				if !isTest && name != "tests" {
+					p.items(i+3, end, prefix+name+"::", false)
+				}
+				i = end
+			} else {
+				i = p.skipItem(i, hi)
+			}
+		case "impl":
+			open := p.findOpen(i, hi)
+			if open < 0 {
+				i = p.skipItem(i, hi)
+				break
+			}
+			if !isTest {
+				p.items(open+1, p.match[open], p.implPrefix(prefix, i+1, open), false)
+			}
+			i = p.match[open]
+		case "use":
+			end := p.skipItem(i, hi)
+			if topLevel {
+				p.file.Uses = append(p.file.Uses, p.text(itemStart, end))
+			}
+			i = end
+		case "struct", "enum", "union":
+			end := p.skipItem(i, hi)
+			if !isTest {
+				p.file.TypeDefs = append(p.file.TypeDefs, p.text(itemStart, end))
+			}
+			i = end
+		case ";", "}":
+		default:
+			// An item we don't track: const, static, trait, type, macro...
+			i = p.skipItem(i, hi)
+		}
+		attrs = nil
+		itemStart = -1
+	}
+}
+
+// function records the fn item at tokens[i] and returns its last token.
+func (p *rustParser) function(i, hi, itemStart int, prefix string, isTest bool) int {
+	open := -1
+	for j := i + 1; j < hi && open < 0; j++ {
+		switch p.tokens[j].text {
+		case "(", "[":
+			j = p.match[j]
+		case ";":
+			return j // declaration without a body (trait or extern)
+		case "{":
+			open = j
+		}
+	}
+	if open < 0 || i+1 >= hi {
+		return hi - 1
+	}
+	closeIdx := p.match[open]
+	if isTest {
+		return closeIdx
+	}
+	name := p.tokens[i+1].text
+	p.file.Funcs = append(p.file.Funcs, rustFunc{
+		Name:      name,
+		QualName:  prefix + name,
+		Signature: strings.TrimSpace(p.src[p.tokens[itemStart].start:p.tokens[open].start]),
+		Body:      p.text(open, closeIdx),
+		StartLine: p.tokens[itemStart].line,
+		EndLine:   p.tokens[closeIdx].line,
+	})
+	return closeIdx
+}
+
+// implPrefix returns the method prefix for an impl header in tokens[lo:hi]:
+// "Type::" for an inherent impl, "<Type as Trait>::" for a trait impl, each
+// after the enclosing module prefix.
+func (p *rustParser) implPrefix(prefix string, lo, hi int) string {
+	// Skip impl generics
+	if lo < hi && p.tokens[lo].text == "<" {
+		lo = p.angleEnd(lo, hi) + 1
+	}
+	// Split at a top-level "for" and stop at "where"
+	forIdx, end := -1, hi
+	depth := 0
+	for j := lo; j < hi; j++ {
+		switch p.tokens[j].text {
+		case "<":
+			depth++
+		case ">":
+			depth--
+		case "(", "[":
+			j = p.match[j]
+		case "for":
+			if depth == 0 && forIdx < 0 {
+				forIdx = j
+			}
+		case "where":
+			if depth == 0 {
+				end = j
+				j = hi
+			}
+		}
+	}
+	if forIdx < 0 {
+		return prefix + p.typeName(lo, end) + "::"
+	}
+	return "<" + prefix + p.typeName(forIdx+1, end) + " as " + p.typeName(lo, forIdx) + ">::"
+}
+
+// typeName returns the last path segment of the type in tokens[lo:hi],
+// without generics, references or lifetimes: "Vec" for "&'a mut std::vec::Vec<T>".
+func (p *rustParser) typeName(lo, hi int) string {
+	name := ""
+	for j := lo; j < hi; j++ {
+		t := p.tokens[j]
+		if t.text == "<" {
+			break
+		}
+		if t.kind == rustIdent && t.text != "mut" && t.text != "dyn" && t.text != "const" {
+			name = t.text
+		} else if t.text == "(" || t.text == "[" {
+			// Tuples, arrays and slices: keep them whole
+			return p.compactText(j, p.match[j])
+		}
+	}
+	return name
+}
+
+// angleEnd returns the index of the ">" closing the "<" at tokens[i].
+func (p *rustParser) angleEnd(i, hi int) int {
+	depth := 0
+	for j := i; j < hi; j++ {
+		switch p.tokens[j].text {
+		case "<":
+			depth++
+		case ">":
+			depth--
+			if depth == 0 {
+				return j
+			}
+		case "(", "[", "{":
+			j = p.match[j]
+		}
+	}
+	return hi - 1
+}
+
+// findOpen returns the index of the "{" opening the block of the item at
+// tokens[i], or -1 if the item ends with ";" first.
+func (p *rustParser) findOpen(i, hi int) int {
+	for j := i + 1; j < hi; j++ {
+		switch p.tokens[j].text {
+		case "(", "[":
+			j = p.match[j]
+		case ";":
+			return -1
+		case "{":
+			return j
+		}
+	}
+	return -1
+}
+
+// skipItem returns the index of the last token of the item starting at
+// tokens[i]: its ";" or the "}" closing its block.
+func (p *rustParser) skipItem(i, hi int) int {
+	for j := i; j < hi; j++ {
+		switch p.tokens[j].text {
+		case "(", "[":
+			j = p.match[j]
+		case ";":
+			return j
+		case "{":
+			end := p.match[j]
+			if end+1 >= hi {
+				return end
+			}
+			// The block may be part of an expression: const X: S = S { .. }.f();
+			switch next := p.tokens[end+1]; {
+			case next.text == ";":
+				return end + 1
+			case next.kind == rustPunct && next.text != "#" && next.text != "}":
+				j = end
+			default:
+				return end
+			}
+		}
+	}
+	return hi - 1
+}
+
+// text returns the source between tokens[i] and tokens[j], inclusive.
+func (p *rustParser) text(i, j int) string {
+	return p.src[p.tokens[i].start:p.tokens[j].end]
+}
+
+// compactText is text with whitespace between tokens removed.
+func (p *rustParser) compactText(i, j int) string {
+	var sb strings.Builder
+	for k := i; k <= j; k++ {
+		sb.WriteString(p.tokens[k].text)
+	}
+	return sb.String()
+}
+
+// hasRustAttr reports whether attrs contains #[name] or #[cfg(name)]-style
+// attributes exactly matching name once spaces are removed.
+func hasRustAttr(attrs []string, name string) bool {
+	for _, a := range attrs {
+		inner := strings.TrimSuffix(strings.TrimPrefix(a, "["), "]")
+		if strings.ReplaceAll(inner, " ", "") == name {
+			return true
+		}
+	}
+	return false
+}
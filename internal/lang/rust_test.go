@@ -0,0 +1,172 @@
+package lang
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yiyuanh/snare/internal/analysis"
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+const tokenSource = `use std::fmt;
+
+pub struct Token<'a> {
+    text: &'a str,
+}
+
+pub fn split(s: &str) -> Vec<&str> {
+    let braces = "}{";
+    let brace = '}';
+    s.split(' ').collect()
+}
+
+impl<'a> Token<'a> {
+    pub fn new(text: &'a str) -> Self {
+        Token { text }
+    }
+}
+
+impl<'a> fmt::Display for Token<'a> {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "{}", self.text)
+    }
+}
+
+mod inner {
+    pub(crate) fn helper<T: Into<u8>>(x: T) -> u8 { x.into() }
+}
+
+#[cfg(test)]
+mod tests {
+    #[test]
+    fn splits() {}
+}
+`
+
+func TestRust_IdentifyChangedFuncs(t *testing.T) {
+	hunks := []model.Hunk{{NewStartLine: 1, NewLineCount: 45}}
+	funcs, err := NewRust().IdentifyChangedFuncs("src/lexer/token.rs", []byte(tokenSource), hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, fn := range funcs {
+		names = append(names, fn.QualifiedName)
+	}
+	want := "split Token::new <Token as Display>::fmt inner::helper"
+	if got := strings.Join(names, " "); got != want {
+		t.Fatalf("functions = %q, want %q", got, want)
+	}
+
+	split := funcs[0]
+	if split.Signature != "pub fn split(s: &str) -> Vec<&str>" || split.StartLine != 7 || split.EndLine != 11 {
+		t.Errorf("split = %+v", split)
+	}
+	if split.Package != "crate::lexer::token" || len(split.Imports) != 1 || len(split.TypeDefs) != 1 {
+		t.Errorf("package %q, imports %q, types %q", split.Package, split.Imports, split.TypeDefs)
+	}
+	if helper := funcs[3]; helper.Signature != "pub(crate) fn helper<T: Into<u8>>(x: T) -> u8" {
+		t.Errorf("helper signature = %q", helper.Signature)
+	}
+}
+
+func TestRust_IdentifyChangedFuncs_Unbalanced(t *testing.T) {
+	if _, err := NewRust().IdentifyChangedFuncs("src/lib.rs", []byte("fn f() {\n"), nil); err == nil {
+		t.Error("expected an error for an unclosed brace")
+	}
+}
+
+func TestRustModulePath(t *testing.T) {
+	tests := map[string]string{
+		"src/lib.rs":                 "crate",
+		"src/main.rs":                "crate",
+		"src/bin/tool.rs":            "crate",
+		"src/net.rs":                 "crate::net",
+		"src/net/mod.rs":             "crate::net",
+		"crates/core/src/net/tcp.rs": "crate::net::tcp",
+		"src/bin/tool/args.rs":       "crate::args",
+	}
+	for file, want := range tests {
+		if got := rustModulePath(file); got != want {
+			t.Errorf("rustModulePath(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestRust_EmbedTest(t *testing.T) {
+	got := string(NewRust().EmbedTest([]byte("fn f() {}"), []byte("#[test]\nfn t() {}\n")))
+	want := "fn f() {}\n\n#[cfg(test)]\nmod snare_tests {\nuse super::*;\n\n#[test]\nfn t() {}\n}\n"
+	if got != want {
+		t.Errorf("EmbedTest = %q, want %q", got, want)
+	}
+}
+
+func TestRust_ApplyMutant(t *testing.T) {
+	src := []byte("pub fn add(a: i32, b: i32) -> i32 {\n    a + b\n}\n")
+	r := NewRust()
+	if _, _, err := r.ApplyMutant("src/lib.rs", src, model.Mutant{FuncName: "add", Original: "a + b", Mutated: "a - b"}); err != nil {
+		t.Errorf("ApplyMutant: %v", err)
+	}
+	if _, _, err := r.ApplyMutant("src/lib.rs", src, model.Mutant{FuncName: "add", Original: "a + b", Mutated: "(a + b"}); err == nil {
+		t.Error("expected an error for a mutant that doesn't parse")
+	}
+}
+
+func TestRust_RunTest(t *testing.T) {
+	if _, err := exec.LookPath("cargo"); err != nil || testing.Short() {
+		t.Skip("needs cargo")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"Cargo.toml": "[package]\nname = \"calc\"\nversion = \"0.1.0\"\nedition = \"2021\"\n",
+		"src/lib.rs": "pub mod ops;\n",
+		"src/ops.rs": "fn add(a: i32, b: i32) -> i32 {\n    a + b\n}\n",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewRust()
+	src := []byte(files["src/ops.rs"])
+	test := []byte("#[test]\nfn adds() {\n    assert_eq!(add(2, 2), 4);\n}\n")
+	if err := os.WriteFile(filepath.Join(dir, "src/ops.rs"), r.EmbedTest(src, test), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	passed, output, err := r.RunTest(dir, "src/ops.rs", "adds", time.Minute)
+	if err != nil || !passed {
+		t.Fatalf("passed=%v err=%v\n%s", passed, err, output)
+	}
+
+	// A name that matches no test must not count as a pass
+	passed, _, err = r.RunTest(dir, "src/ops.rs", "missing", time.Minute)
+	if err != nil || passed {
+		t.Errorf("missing test: passed=%v err=%v", passed, err)
+	}
+}
+
+func TestRust_IgnoreDirective(t *testing.T) {
+	src := []byte("use std::fmt;\n\n// snare:ignore\n#[inline]\nfn f() -> i32 {\n    1\n}\n\n#[inline]\nfn g() -> i32 {\n    2\n}\n")
+	fd := model.FileDiff{
+		NewName:   "src/lib.rs",
+		NewSource: src,
+		Hunks:     []model.Hunk{{NewStartLine: 1, NewLineCount: 12}},
+	}
+	r := NewRust()
+	funcs, _, err := analysis.MapChangedFuncsWithLang([]model.FileDiff{fd}, r, r.Prompt().Comments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(funcs) != 1 || funcs[0].Name != "g" {
+		t.Errorf("funcs = %v, want only g", funcs)
+	}
+}
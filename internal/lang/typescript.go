@@ -0,0 +1,373 @@
+package lang
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yiyuanh/snare/internal/analysis"
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+//go:embed typescript_helper.js
+var typescriptHelperScript string
+
+// JavaScript test runners. TypeScript uses RunnerNode unless configured for a
+// project (see ForProject).
+const (
+	RunnerJest   = "jest"
+	RunnerVitest = "vitest"
+	RunnerNode   = "node" // node:test, built into Node
+)
+
+// TypeScript implements the Language interface for TypeScript and JavaScript
+// codebases. Functions are extracted by an embedded Node helper, so Node must
+// be installed; tests run with jest, vitest or node:test.
+type TypeScript struct {
+	runner string
+}
+
+func NewTypeScript() *TypeScript {
+	return &TypeScript{runner: RunnerNode}
+}
+
+func init() {
+	Register(NewTypeScript())
+}
+
+// ForProject returns a TypeScript that runs tests with opts.JSRunner, or with
+// the runner the project's package.json depends on when that is "" or "auto".
+func (ts *TypeScript) ForProject(root string, opts Options) Language {
+	runner := opts.JSRunner
+	if runner == "" || runner == "auto" {
+		runner = detectJSRunner(root)
+	}
+	return &TypeScript{runner: runner}
+}
+
+// detectJSRunner picks vitest or jest if package.json in root depends on
+// them, preferring vitest, and node:test otherwise.
+func detectJSRunner(root string) string {
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return RunnerNode
+	}
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return RunnerNode
+	}
+	has := func(name string) bool {
+		_, dep := pkg.Dependencies[name]
+		_, dev := pkg.DevDependencies[name]
+		return dep || dev
+	}
+	switch {
+	case has("vitest"):
+		return RunnerVitest
+	case has("jest"), has("ts-jest"):
+		return RunnerJest
+	}
+	return RunnerNode
+}
+
+// Runner returns the test runner: RunnerJest, RunnerVitest or RunnerNode.
+func (ts *TypeScript) Runner() string {
+	return ts.runner
+}
+
+func (ts *TypeScript) Name() string {
+	return "typescript"
+}
+
+func (ts *TypeScript) FileExtensions() []string {
+	return []string{".ts", ".tsx", ".mts", ".cts", ".js", ".jsx", ".mjs", ".cjs"}
+}
+
+func (ts *TypeScript) IsTestFile(path string) bool {
+	base := filepath.Base(path)
+	if strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") {
+		return true
+	}
+	return strings.Contains("/"+filepath.ToSlash(path), "/__tests__/")
+}
+
+// testNameChars matches runs of characters not allowed in test file names.
+var testNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// TestFilePath puts the test next to the source file as
+// <name>_<test name>.snare.test<ext>, e.g. cart_adds_item.snare.test.ts, which
+// jest's and vitest's default patterns pick up.
+func (ts *TypeScript) TestFilePath(sourceFile string, testName string) string {
+	ext := filepath.Ext(sourceFile)
+	base := strings.TrimSuffix(filepath.Base(sourceFile), ext)
+	name := strings.Trim(testNameChars.ReplaceAllString(strings.ToLower(testName), "_"), "_")
+	return filepath.Join(filepath.Dir(sourceFile), fmt.Sprintf("%s_%s.snare.test%s", base, name, ext))
+}
+
+func (ts *TypeScript) RootMarkers() []string {
+	return []string{"package.json"}
+}
+
+func (ts *TypeScript) Prompt() PromptStyle {
+	style := PromptStyle{
+		CodeFence:       "typescript",
+		PackageLabel:    "Module",
+		ShowSignature:   true,
+		TestFileNote:    " with its imports",
+		TestNameExample: "funcName_riskDescription",
+		Rules: []string{
+			"Each test must be a complete, self-contained test file",
+			`Import the function under test from the module "./{package}"; the test file is written next to it`,
+			"Only exported functions can be imported; test an unexported function through the exported code that calls it",
+		},
+		Comments: analysis.CommentSyntax{Line: "//", BlockStart: "/*", BlockEnd: "*/", Attributes: []string{"@"}},
+	}
+	switch ts.runner {
+	case RunnerJest:
+		style.TestFramework = "Jest"
+		style.TestCodeExample = `"import { funcName } from \"./module\";\n\ntest(\"funcName riskDescription\", () => {\n  expect(funcName(1)).toBe(2);\n});"`
+		style.Rules = append(style.Rules, "Use Jest's global test and expect; do not import another test framework")
+	case RunnerVitest:
+		style.TestFramework = "Vitest"
+		style.TestCodeExample = `"import { expect, test } from \"vitest\";\nimport { funcName } from \"./module\";\n\ntest(\"funcName riskDescription\", () => {\n  expect(funcName(1)).toBe(2);\n});"`
+		style.Rules = append(style.Rules, `Import test and expect from "vitest"`)
+	default:
+		style.TestFramework = "node:test"
+		style.TestCodeExample = `"import { test } from \"node:test\";\nimport assert from \"node:assert/strict\";\nimport { funcName } from \"./module.ts\";\n\ntest(\"funcName riskDescription\", () => {\n  assert.equal(funcName(1), 2);\n});"`
+		style.Rules[1] = `Import the function under test from "./{package}" with the source file's extension (e.g. "./{package}.ts"); the test file is written next to it`
+		style.Rules = append(style.Rules, `Use only "node:test" and "node:assert/strict"; no other test framework is installed`)
+	}
+	return style
+}
+
+// tsFuncInfo represents the JSON output from the Node helper script.
+type tsFuncInfo struct {
+	Name      string   `json:"name"`
+	QualName  string   `json:"qualname"`
+	Signature string   `json:"signature"`
+	Body      string   `json:"body"`
+	StartLine int      `json:"start_line"`
+	EndLine   int      `json:"end_line"`
+	Imports   []string `json:"imports"`
+}
+
+func (ts *TypeScript) IdentifyChangedFuncs(filePath string, source []byte, hunks []model.Hunk) ([]model.ChangedFunc, error) {
+	var stdout bytes.Buffer
+	if err := runTSHelper("extract", filePath, source, &stdout); err != nil {
+		return nil, err
+	}
+
+	var funcs []tsFuncInfo
+	if err := json.Unmarshal(stdout.Bytes(), &funcs); err != nil {
+		return nil, fmt.Errorf("parsing Node helper output: %w\noutput: %s", err, stdout.String())
+	}
+
+	// The module is what a test next to the file imports: its name without extension
+	module := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+
+	var result []model.ChangedFunc
+	for _, fn := range funcs {
+		if !overlapsHunksTS(fn, hunks) {
+			continue
+		}
+
+		var diffParts []string
+		for _, h := range hunks {
+			hunkEnd := h.NewStartLine + h.NewLineCount - 1
+			if hunkEnd >= fn.StartLine && h.NewStartLine <= fn.EndLine {
+				diffParts = append(diffParts, h.Content)
+			}
+		}
+
+		result = append(result, model.ChangedFunc{
+			FilePath:      filePath,
+			Package:       module,
+			Name:          fn.Name,
+			QualifiedName: fn.QualName,
+			Signature:     fn.Signature,
+			Body:          fn.Body,
+			StartLine:     fn.StartLine,
+			EndLine:       fn.EndLine,
+			Imports:       fn.Imports,
+			DiffContext:   strings.Join(diffParts, "\n"),
+		})
+	}
+	return result, nil
+}
+
+func overlapsHunksTS(fn tsFuncInfo, hunks []model.Hunk) bool {
+	for _, h := range hunks {
+		hunkEnd := h.NewStartLine + h.NewLineCount - 1
+		if hunkEnd >= fn.StartLine && h.NewStartLine <= fn.EndLine {
+			return true
+		}
+	}
+	return false
+}
+
+// runTSHelper writes source to a temp file with filePath's extension and runs
+// the Node helper on it in the given mode ("extract" or "check"), writing its
+// output to stdout (which may be nil).
+func runTSHelper(mode, filePath string, source []byte, stdout *bytes.Buffer) error {
+	tmpFile, err := os.CreateTemp("", "snare-ts-*"+filepath.Ext(filePath))
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(source); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	tmpFile.Close()
+
+	// The helper's main block reads the mode and file from argv
+	cmd := exec.Command("node", "-e", typescriptHelperScript, mode, tmpFile.Name())
+	var stderr bytes.Buffer
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if mode == "check" && errors.As(err, &exitErr) {
+			return errors.New(strings.TrimSpace(stderr.String()))
+		}
+		return fmt.Errorf("running Node helper: %w\nstderr: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// ApplyMutant replaces the original snippet and checks that the result still
+// parses, first as JavaScript, which V8 checks exactly, then as
+// TypeScript. A mutant is rejected only if the original passes the check the
+// mutated source fails; if neither passes (e.g. JSX), the mutant is applied
+// and left for the test run to reject.
+func (ts *TypeScript) ApplyMutant(filePath string, source []byte, mutant model.Mutant) ([]byte, Site, error) {
+	result, site, err := replaceInFunc(ts, filePath, source, mutant)
+	if err != nil {
+		return nil, site, err
+	}
+	for _, ext := range []string{".js", ".ts"} {
+		err := runTSHelper("check", "mutant"+ext, result, nil)
+		if err == nil {
+			break
+		}
+		if runTSHelper("check", "original"+ext, source, nil) == nil {
+			return nil, site, fmt.Errorf("mutated code is not valid TypeScript: %w", err)
+		}
+	}
+	return result, site, nil
+}
+
+func (ts *TypeScript) RunTest(dir string, testFile string, testFunc string, timeout time.Duration) (passed bool, output string, err error) {
+	ms := fmt.Sprint(timeout.Milliseconds())
+	switch ts.runner {
+	case RunnerJest:
+		return jsTest(dir, "npx", "--no-install", "jest", "--ci", "--forceExit", "--testTimeout="+ms, "--runTestsByPath", testFile)
+	case RunnerVitest:
+		return jsTest(dir, "npx", "--no-install", "vitest", "run", "--testTimeout="+ms, testFile)
+	}
+	args := []string{"--test", "--test-timeout=" + ms}
+	if isTSFile(testFile) {
+		// Without type stripping every test would fail on parent, which
+		// looks like a broken test rather than a broken setup
+		if err := nodeStripsTypes(); err != nil {
+			return false, "", err
+		}
+		args = append(args, "--experimental-strip-types")
+	}
+	return jsTest(dir, "node", append(args, testFile)...)
+}
+
+var (
+	stripTypesOnce sync.Once
+	stripTypesErr  error
+)
+
+// nodeStripsTypes returns an error unless the installed Node can run
+// TypeScript with --experimental-strip-types (Node 22.6 and later). Node is
+// asked once per run.
+func nodeStripsTypes() error {
+	stripTypesOnce.Do(func() {
+		out, err := exec.Command("node", "--experimental-strip-types", "--eval", "").CombinedOutput()
+		if err != nil {
+			stripTypesErr = fmt.Errorf("node:test can't run TypeScript tests: Node 22.6 or later is needed for --experimental-strip-types, or use --js-runner jest or vitest (%v: %s)", err, strings.TrimSpace(string(out)))
+		}
+	})
+	return stripTypesErr
+}
+
+// RunSuite runs the project's tests related to sourceFile: the ones jest or
+// vitest find through imports, or for node:test the whole suite, with type
+// stripping when sourceFile is TypeScript.
+func (ts *TypeScript) RunSuite(dir string, sourceFile string, timeout time.Duration) (passed bool, output string, err error) {
+	ms := fmt.Sprint(timeout.Milliseconds())
+	switch ts.runner {
+	case RunnerJest:
+		return jsTest(dir, "npx", "--no-install", "jest", "--ci", "--forceExit", "--testTimeout="+ms, "--passWithNoTests", "--findRelatedTests", sourceFile)
+	case RunnerVitest:
+		return jsTest(dir, "npx", "--no-install", "vitest", "related", "--run", "--testTimeout="+ms, "--passWithNoTests", sourceFile)
+	}
+	args := []string{"--test", "--test-timeout=" + ms}
+	if isTSFile(sourceFile) {
+		if err := nodeStripsTypes(); err != nil {
+			return false, "", err
+		}
+		args = append(args, "--experimental-strip-types")
+	}
+	return jsTest(dir, "node", args...)
+}
+
+// jsTest runs a test command in dir; a non-zero exit means tests failed.
+func jsTest(dir string, name string, args ...string) (passed bool, output string, err error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "CI=true", "NO_COLOR=1")
+
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+
+	err = cmd.Run()
+	output = buf.String()
+
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			// Test failed (non-zero exit) — expected for catching tests
+			return false, output, nil
+		}
+		return false, output, fmt.Errorf("running test: %w", err)
+	}
+	return true, output, nil
+}
+
+func isTSFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".ts", ".tsx", ".mts", ".cts":
+		return true
+	}
+	return false
+}
+
+// ValidateTestSyntax checks test code as TypeScript, which also accepts
+// plain JavaScript tests.
+func (ts *TypeScript) ValidateTestSyntax(testCode []byte) error {
+	if err := runTSHelper("check", "test.ts", testCode, nil); err != nil {
+		return fmt.Errorf("invalid TypeScript syntax: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,585 @@
+/*
+ * TypeScript/JavaScript helper for snare.
+ *
+ * Extracts function metadata from TypeScript and JavaScript sources as JSON,
+ * and checks that a source file still parses. Invoked by snare's Go code via
+ * `node -e` with this script embedded, so it has no npm dependencies.
+ *
+ * Usage:
+ *   node typescript_helper.js extract <file>
+ *   node typescript_helper.js check <file>
+ *
+ * extract prints a JSON array of function objects:
+ * [
+ *   {
+ *     "name": "add",
+ *     "qualname": "Cart.add",
+ *     "signature": "add(item: Item): void",
+ *     "body": "{ ... }",
+ *     "start_line": 10,
+ *     "end_line": 25,
+ *     "imports": ["import { Item } from './item';"]
+ *   }
+ * ]
+ *
+ * check exits non-zero with the syntax error on stderr. JavaScript is checked
+ * by V8 (node --check); TypeScript with Node's built-in type stripping when
+ * available, otherwise with the project's typescript package, otherwise only
+ * for balanced brackets and terminated strings and comments.
+ */
+"use strict";
+
+const fs = require("fs");
+const path = require("path");
+const childProcess = require("child_process");
+const os = require("os");
+
+// Keywords after which a "/" starts a regular expression, not a division.
+const REGEX_AFTER = new Set([
+  "return", "typeof", "instanceof", "in", "of", "new", "delete", "void",
+  "throw", "case", "do", "else", "yield", "await",
+]);
+
+const MODIFIERS = new Set([
+  "public", "private", "protected", "static", "async", "readonly",
+  "abstract", "override", "declare", "get", "set", "accessor",
+]);
+
+/**
+ * scan splits source into identifier, number, string, regex and punctuation
+ * tokens, skipping comments. Problems (unterminated strings, comments or
+ * templates, unbalanced brackets) are collected in errors; scanning carries on
+ * so extraction still works on code the scanner only partly understands.
+ */
+function scan(src) {
+  const tokens = [];
+  const errors = [];
+  const brackets = []; // open brackets; "${" marks a template substitution
+  let i = 0;
+  let line = 1;
+
+  const push = (type, start, startLine) => {
+    tokens.push({ type, value: src.slice(start, i), start, end: i, line: startLine });
+  };
+  const regexAllowed = () => {
+    const prev = tokens[tokens.length - 1];
+    if (!prev) return true;
+    if (prev.type === "id") return REGEX_AFTER.has(prev.value);
+    if (prev.type === "punct") return !/^[)\]}]$/.test(prev.value);
+    return false;
+  };
+
+  // readTemplate scans template text from i (just after "`" or a closing
+  // "}" of a substitution) up to the closing "`" or the next "${".
+  const readTemplate = (start, startLine) => {
+    while (i < src.length) {
+      const c = src[i];
+      if (c === "\\") {
+        i += 2;
+        continue;
+      }
+      if (c === "\n") line++;
+      if (c === "`") {
+        i++;
+        push("string", start, startLine);
+        return;
+      }
+      if (c === "$" && src[i + 1] === "{") {
+        i += 2;
+        push("string", start, startLine);
+        brackets.push("${");
+        return;
+      }
+      i++;
+    }
+    errors.push(`line ${startLine}: unterminated template literal`);
+    push("string", start, startLine);
+  };
+
+  while (i < src.length) {
+    const c = src[i];
+    const start = i;
+    const startLine = line;
+
+    if (c === "\n") {
+      line++;
+      i++;
+    } else if (/\s/.test(c)) {
+      i++;
+    } else if (c === "/" && src[i + 1] === "/") {
+      while (i < src.length && src[i] !== "\n") i++;
+    } else if (c === "/" && src[i + 1] === "*") {
+      const end = src.indexOf("*/", i + 2);
+      const stop = end < 0 ? src.length : end + 2;
+      for (; i < stop; i++) if (src[i] === "\n") line++;
+      if (end < 0) errors.push(`line ${startLine}: unterminated comment`);
+    } else if (c === '"' || c === "'") {
+      i++;
+      while (i < src.length && src[i] !== c && src[i] !== "\n") {
+        i += src[i] === "\\" ? 2 : 1;
+      }
+      if (src[i] !== c) errors.push(`line ${startLine}: unterminated string`);
+      else i++;
+      push("string", start, startLine);
+    } else if (c === "`") {
+      i++;
+      readTemplate(start, startLine);
+    } else if (c === "/" && regexAllowed()) {
+      i++;
+      let inClass = false;
+      while (i < src.length && src[i] !== "\n") {
+        if (src[i] === "\\") i++;
+        else if (src[i] === "[") inClass = true;
+        else if (src[i] === "]") inClass = false;
+        else if (src[i] === "/" && !inClass) break;
+        i++;
+      }
+      if (src[i] !== "/") errors.push(`line ${startLine}: unterminated regular expression`);
+      else i++;
+      while (i < src.length && /[a-z]/i.test(src[i])) i++;
+      push("regex", start, startLine);
+    } else if (/[A-Za-z_$#@]/.test(c) || c.charCodeAt(0) > 127) {
+      i++;
+      while (i < src.length && (/[\w$]/.test(src[i]) || src.charCodeAt(i) > 127)) i++;
+      push("id", start, startLine);
+    } else if (/[0-9]/.test(c) || (c === "." && /[0-9]/.test(src[i + 1] || ""))) {
+      i++;
+      while (i < src.length && /[\w.]/.test(src[i])) i++;
+      push("number", start, startLine);
+    } else if (c === "=" && src[i + 1] === ">") {
+      i += 2;
+      push("punct", start, startLine);
+    } else if (c === "." && src[i + 1] === "." && src[i + 2] === ".") {
+      i += 3;
+      push("punct", start, startLine);
+    } else {
+      i++;
+      if ("([{".includes(c)) {
+        brackets.push(c);
+      } else if (")]}".includes(c)) {
+        const open = brackets.pop();
+        if (c === "}" && open === "${") {
+          readTemplate(start, startLine);
+          continue;
+        }
+        if (open !== { ")": "(", "]": "[", "}": "{" }[c]) {
+          errors.push(`line ${startLine}: unexpected "${c}"`);
+        }
+      }
+      push("punct", start, startLine);
+    }
+  }
+  if (brackets.length > 0) {
+    errors.push(`unclosed "${brackets[brackets.length - 1]}" at end of file`);
+  }
+  return { tokens, errors };
+}
+
+/**
+ * matching returns the index of the token closing the bracket at tokens[i],
+ * or -1. For "<" a ";" or an unbalanced closing bracket means it was a
+ * comparison rather than a type parameter list.
+ */
+function matching(tokens, i) {
+  const pairs = { "(": ")", "[": "]", "{": "}", "<": ">" };
+  const stack = [];
+  for (let j = i; j < tokens.length; j++) {
+    const t = tokens[j];
+    if (t.type !== "punct") continue;
+    const top = stack[stack.length - 1];
+    if (t.value in pairs && (t.value !== "<" || top === "<" || j === i)) {
+      stack.push(t.value);
+    } else if (t.value === pairs[top]) {
+      stack.pop();
+      if (stack.length === 0) return j;
+    } else if (top === "<" && (t.value === ";" || ")]}".includes(t.value))) {
+      return -1;
+    }
+  }
+  return -1;
+}
+
+/**
+ * findBody returns the index of the "{" opening a function body, starting at
+ * the token after the parameter list: it skips a return type annotation, in
+ * which "{" starts an object type rather than the body.
+ */
+function findBody(tokens, i) {
+  let prev = null;
+  for (let j = i; j < tokens.length; j++) {
+    const t = tokens[j];
+    if (t.type === "punct") {
+      if (t.value === "{") {
+        const inType = prev && prev.type === "punct" && /^(:|\||&|<|,|\(|=>)$/.test(prev.value);
+        if (!inType) return j;
+        j = matching(tokens, j);
+        if (j < 0) return -1;
+      } else if (t.value === "(" || t.value === "[" || t.value === "<") {
+        const end = matching(tokens, j);
+        if (end < 0) return -1;
+        j = end;
+      } else if (t.value === ";" || t.value === "}" || t.value === "=") {
+        return -1; // overload or abstract declaration without a body
+      } else if (t.value === "=>") {
+        return -2; // caller handles arrow bodies
+      }
+    }
+    prev = tokens[j];
+  }
+  return -1;
+}
+
+/**
+ * arrowBodyEnd returns the index of the last token of an expression-bodied
+ * arrow function starting at tokens[i]: up to a ";" or "," at depth 0, a
+ * closing bracket of the enclosing scope, or a line break at depth 0 that is
+ * not a continuation.
+ */
+function arrowBodyEnd(tokens, i) {
+  let depth = 0;
+  for (let j = i; j < tokens.length; j++) {
+    const t = tokens[j];
+    if (t.type === "punct") {
+      if ("([{".includes(t.value)) depth++;
+      else if (")]}".includes(t.value)) {
+        if (depth === 0) return j - 1;
+        depth--;
+      } else if (depth === 0 && (t.value === ";" || t.value === ",")) {
+        return j - 1;
+      }
+    }
+    const next = tokens[j + 1];
+    if (depth === 0 && next && next.line > t.line) {
+      const continues = (t.type === "punct" && !/^[)\]}]$/.test(t.value)) ||
+        (next.type === "punct" && /^[.?:+\-*/%&|=<>]/.test(next.value));
+      if (!continues) return j;
+    }
+  }
+  return tokens.length - 1;
+}
+
+/**
+ * extract returns the top-level functions, class methods and functions
+ * assigned to top-level variables or class fields.
+ */
+function extract(src) {
+  const { tokens } = scan(src);
+  const funcs = [];
+  const imports = [];
+
+  const lineAt = (offset) => src.slice(0, offset).split("\n").length;
+  const add = (name, qualname, declStart, bodyStart, bodyEnd) => {
+    const body = src.slice(tokens[bodyStart].start, tokens[bodyEnd].end);
+    const signature = src.slice(declStart, tokens[bodyStart].start).trim().replace(/\s*=>$/, " =>");
+    funcs.push({
+      name,
+      qualname,
+      signature,
+      body,
+      start_line: lineAt(declStart),
+      end_line: lineAt(tokens[bodyEnd].end - 1),
+      imports,
+    });
+  };
+
+  // statementStart walks back over export/default/async/declare modifiers.
+  const statementStart = (i) => {
+    let s = i;
+    while (s > 0 && tokens[s - 1].type === "id" && /^(export|default|async|declare)$/.test(tokens[s - 1].value)) s--;
+    while (s > 0 && tokens[s - 1].type === "id" && tokens[s - 1].value.startsWith("@")) s--; // decorators without arguments
+    return s;
+  };
+
+  // functionAt handles "function name(...) {" at tokens[i].
+  const functionAt = (i, className) => {
+    let j = i + 1;
+    if (tokens[j] && tokens[j].value === "*") j++;
+    const nameTok = tokens[j];
+    let name = "default";
+    if (nameTok && nameTok.type === "id") {
+      name = nameTok.value;
+      j++;
+    }
+    if (tokens[j] && tokens[j].value === "<") j = matching(tokens, j) + 1;
+    if (!tokens[j] || tokens[j].value !== "(") return i;
+    const paramsEnd = matching(tokens, j);
+    if (paramsEnd < 0) return i;
+    const body = findBody(tokens, paramsEnd + 1);
+    if (body < 0) return paramsEnd;
+    const end = matching(tokens, body);
+    if (end < 0) return paramsEnd;
+    const qual = className ? `${className}.${name}` : name;
+    add(name, qual, tokens[statementStart(i)].start, body, end);
+    return end;
+  };
+
+  // assignedFunctionAt handles "name = <function or arrow>" where tokens[i]
+  // is the name; returns the last token consumed, or i if it isn't one.
+  const assignedFunctionAt = (i, declStart, className) => {
+    let j = i + 1;
+    if (tokens[j] && (tokens[j].value === "!" || tokens[j].value === "?")) j++;
+    if (tokens[j] && tokens[j].value === ":") {
+      // Skip the type annotation up to "="
+      for (j++; j < tokens.length && tokens[j].value !== "="; j++) {
+        if ("([{<".includes(tokens[j].value)) {
+          const end = matching(tokens, j);
+          if (end < 0) return i;
+          j = end;
+        } else if (tokens[j].value === ";") {
+          return i;
+        }
+      }
+    }
+    if (!tokens[j] || tokens[j].value !== "=") return i;
+    j++;
+    if (tokens[j] && tokens[j].value === "async") j++;
+    const name = tokens[i].value;
+    const qual = className ? `${className}.${name}` : name;
+
+    if (tokens[j] && tokens[j].value === "function") {
+      let k = j + 1;
+      if (tokens[k] && tokens[k].value === "*") k++;
+      if (tokens[k] && tokens[k].type === "id") k++;
+      if (!tokens[k] || tokens[k].value !== "(") return i;
+      const paramsEnd = matching(tokens, k);
+      const body = paramsEnd < 0 ? -1 : findBody(tokens, paramsEnd + 1);
+      const end = body < 0 ? -1 : matching(tokens, body);
+      if (end < 0) return i;
+      add(name, qual, declStart, body, end);
+      return end;
+    }
+
+    // Arrow function: [<T>](params) [: type] => body, or ident => body
+    let k = j;
+    if (tokens[k] && tokens[k].value === "<") {
+      k = matching(tokens, k) + 1;
+      if (k <= 0) return i;
+    }
+    if (tokens[k] && tokens[k].value === "(") {
+      k = matching(tokens, k);
+      if (k < 0) return i;
+    } else if (!tokens[k] || tokens[k].type !== "id") {
+      return i;
+    }
+    // Find "=>" after the parameters (and any return type)
+    let arrow = -1;
+    for (let m = k + 1; m < tokens.length; m++) {
+      const t = tokens[m];
+      if (t.value === "=>") {
+        arrow = m;
+        break;
+      }
+      if (t.value === ":" || t.type === "id" || t.value === "." || t.value === "|" || t.value === "&") continue;
+      if ("([{<".includes(t.value)) {
+        const end = matching(tokens, m);
+        if (end < 0) return i;
+        m = end;
+        continue;
+      }
+      return i;
+    }
+    if (arrow < 0 || !tokens[arrow + 1]) return i;
+    const bodyStart = arrow + 1;
+    const end = tokens[bodyStart].value === "{" ? matching(tokens, bodyStart) : arrowBodyEnd(tokens, bodyStart);
+    if (end < 0) return i;
+    add(name, qual, declStart, bodyStart, end);
+    return end;
+  };
+
+  // classAt handles "class Name ... {" at tokens[i].
+  const classAt = (i) => {
+    const nameTok = tokens[i + 1];
+    const className = nameTok && nameTok.type === "id" && nameTok.value !== "extends" && nameTok.value !== "implements" ? nameTok.value : "default";
+    let open = i + 1;
+    while (open < tokens.length && tokens[open].value !== "{") {
+      if (tokens[open].value === "<" || tokens[open].value === "(") {
+        const end = matching(tokens, open);
+        if (end < 0) return i;
+        open = end;
+      }
+      open++;
+    }
+    const close = open < tokens.length ? matching(tokens, open) : -1;
+    if (close < 0) return i;
+
+    for (let j = open + 1; j < close; j++) {
+      const memberStart = j;
+      // Skip decorators: @name or @name(...)
+      while (tokens[j].value.startsWith("@")) {
+        j++;
+        while (tokens[j].value === ".") j += 2;
+        if (tokens[j].value === "(") j = matching(tokens, j) + 1;
+      }
+      const declStart = tokens[j].start;
+      while (j < close && tokens[j].type === "id" && MODIFIERS.has(tokens[j].value) &&
+             tokens[j + 1] && (tokens[j + 1].type === "id" || tokens[j + 1].value === "*" || tokens[j + 1].value === "[")) {
+        j++;
+      }
+      if (tokens[j].value === "*") j++;
+      const nameTok = tokens[j];
+      if (nameTok.type === "id" || nameTok.type === "string") {
+        const name = nameTok.type === "string" ? nameTok.value.slice(1, -1) : nameTok.value;
+        let k = j + 1;
+        if (tokens[k].value === "?" || tokens[k].value === "!") k++;
+        if (tokens[k].value === "<") k = matching(tokens, k) + 1;
+        if (k > 0 && tokens[k].value === "(") {
+          const paramsEnd = matching(tokens, k);
+          const body = paramsEnd < 0 ? -1 : findBody(tokens, paramsEnd + 1);
+          if (body >= 0) {
+            const end = matching(tokens, body);
+            if (end > 0) {
+              add(name, `${className}.${name}`, declStart, body, end);
+              j = end;
+              continue;
+            }
+          }
+        } else if (nameTok.type === "id") {
+          const end = assignedFunctionAt(j, declStart, className);
+          if (end !== j) {
+            j = end;
+            continue;
+          }
+        }
+      }
+      // Not a method: skip to the end of the member
+      for (; j < close; j++) {
+        const t = tokens[j];
+        if ("([{".includes(t.value)) j = matching(tokens, j);
+        else if (t.value === ";") break;
+        const next = tokens[j + 1];
+        if (next && next.line > t.line && next.type === "id" && !"([{".includes(t.value)) break;
+      }
+      if (j === memberStart - 1) j = memberStart;
+    }
+    return close;
+  };
+
+  let depth = 0;
+  for (let i = 0; i < tokens.length; i++) {
+    const t = tokens[i];
+    if (t.type === "punct") {
+      if ("([{".includes(t.value)) depth++;
+      else if (")]}".includes(t.value)) depth--;
+      continue;
+    }
+    if (depth !== 0 || t.type !== "id") continue;
+
+    switch (t.value) {
+      case "import": {
+        // import ... from "x"; import "x"; not import(...) or import.meta
+        const next = tokens[i + 1];
+        if (!next || next.value === "(" || next.value === ".") break;
+        let j = i + 1;
+        while (j < tokens.length && tokens[j].type !== "string") {
+          if (tokens[j].value === "{") j = matching(tokens, j);
+          j++;
+        }
+        if (j >= tokens.length) break;
+        if (tokens[j + 1] && tokens[j + 1].value === ";") j++;
+        imports.push(src.slice(t.start, tokens[j].end));
+        i = j;
+        break;
+      }
+      case "function":
+        i = functionAt(i, null);
+        break;
+      case "class":
+        i = classAt(i);
+        break;
+      case "const":
+      case "let":
+      case "var": {
+        const nameTok = tokens[i + 1];
+        if (nameTok && nameTok.type === "id") {
+          const end = assignedFunctionAt(i + 1, tokens[statementStart(i)].start, null);
+          if (end !== i + 1) i = end;
+        }
+        break;
+      }
+    }
+  }
+  return funcs;
+}
+
+/** check throws an Error describing the first syntax problem in file. */
+function check(file, src) {
+  const ext = path.extname(file).toLowerCase();
+  const isTS = [".ts", ".mts", ".cts", ".tsx"].includes(ext);
+  const isJSX = ext === ".tsx" || ext === ".jsx";
+
+  if (isTS && !isJSX) {
+    const mod = require("module");
+    if (typeof mod.stripTypeScriptTypes === "function") {
+      process.removeAllListeners("warning"); // stripTypeScriptTypes is experimental
+      try {
+        src = mod.stripTypeScriptTypes(src);
+      } catch (e) {
+        throw new Error(e.message);
+      }
+      return nodeCheck(src);
+    }
+    const ts = loadTypeScript(file);
+    if (ts) {
+      const sf = ts.createSourceFile(file, src, ts.ScriptTarget.Latest, false);
+      const diags = sf.parseDiagnostics || [];
+      if (diags.length > 0) {
+        const d = diags[0];
+        const { line } = sf.getLineAndCharacterOfPosition(d.start || 0);
+        throw new Error(`line ${line + 1}: ${ts.flattenDiagnosticMessageText(d.messageText, "\n")}`);
+      }
+      return;
+    }
+  } else if (!isTS && !isJSX) {
+    return nodeCheck(src);
+  }
+
+  const { errors } = scan(src);
+  if (errors.length > 0) throw new Error(errors[0]);
+}
+
+/** nodeCheck has V8 parse JavaScript without running it. */
+function nodeCheck(src) {
+  const isModule = /^\s*(import|export)\b/m.test(src);
+  const tmp = path.join(fs.mkdtempSync(path.join(os.tmpdir(), "snare-js-")), isModule ? "check.mjs" : "check.cjs");
+  try {
+    fs.writeFileSync(tmp, src);
+    const res = childProcess.spawnSync(process.execPath, ["--check", tmp], { encoding: "utf8" });
+    if (res.status !== 0) {
+      // Keep the "file:line" header and the error message, drop the stack
+      const lines = (res.stderr || "").split("\n").filter((l) => l && !/^\s+at /.test(l) && !/^Node\.js v/.test(l));
+      throw new Error(lines.join("\n").split(tmp).join("<source>") || "syntax error");
+    }
+  } finally {
+    fs.rmSync(path.dirname(tmp), { recursive: true, force: true });
+  }
+}
+
+/** loadTypeScript returns the typescript package nearest to file, if any. */
+function loadTypeScript(file) {
+  try {
+    return require(require.resolve("typescript", { paths: [path.dirname(path.resolve(file)), process.cwd()] }));
+  } catch (e) {
+    return null;
+  }
+}
+
+if (require.main === module || process.argv[1] === "extract" || process.argv[1] === "check") {
+  // With `node -e`, argv is [node, mode, file]; as a script, [node, script, mode, file]
+  const args = require.main === module ? process.argv.slice(2) : process.argv.slice(1);
+  const [mode, file] = args;
+  const src = fs.readFileSync(file, "utf8");
+  try {
+    if (mode === "extract") {
+      process.stdout.write(JSON.stringify(extract(src)));
+    } else if (mode === "check") {
+      check(file, src);
+    } else {
+      throw new Error(`unknown mode ${mode}`);
+    }
+  } catch (e) {
+    process.stderr.write(e.message + "\n");
+    process.exit(1);
+  }
+}
+
+module.exports = { scan, extract, check };
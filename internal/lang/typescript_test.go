@@ -0,0 +1,197 @@
+package lang
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+func requireNode(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node not installed")
+	}
+}
+
+const cartSource = `import { Item } from "./item";
+
+export function total(items: Item[]): number {
+  return items.reduce((sum, i) => sum + i.price, 0);
+}
+
+export const isEmpty = (items: Item[]): boolean => items.length === 0;
+
+export class Cart {
+  private items: Item[] = [];
+
+  add(item: Item): { size: number } {
+    this.items.push(item);
+    return { size: this.items.length };
+  }
+}
+`
+
+func TestTypeScript_IdentifyChangedFuncs(t *testing.T) {
+	requireNode(t)
+
+	// Lines 7 and 12-15 changed: isEmpty and Cart.add, not total
+	hunks := []model.Hunk{
+		{NewStartLine: 7, NewLineCount: 1},
+		{NewStartLine: 12, NewLineCount: 4},
+	}
+	funcs, err := NewTypeScript().IdentifyChangedFuncs("src/cart.ts", []byte(cartSource), hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(funcs) != 2 {
+		t.Fatalf("got %d funcs, want 2: %+v", len(funcs), funcs)
+	}
+
+	if fn := funcs[0]; fn.QualifiedName != "isEmpty" || fn.Body != "items.length === 0" || fn.StartLine != 7 {
+		t.Errorf("isEmpty = %+v", fn)
+	}
+	add := funcs[1]
+	if add.QualifiedName != "Cart.add" || add.Signature != "add(item: Item): { size: number }" || add.StartLine != 12 || add.EndLine != 15 {
+		t.Errorf("Cart.add = %+v", add)
+	}
+	if add.Package != "cart" || len(add.Imports) != 1 || add.Imports[0] != `import { Item } from "./item";` {
+		t.Errorf("package %q, imports %q", add.Package, add.Imports)
+	}
+}
+
+func TestTypeScript_ApplyMutant(t *testing.T) {
+	requireNode(t)
+	src := []byte("export function add(a, b) {\n  return a + b;\n}\n")
+	ts := NewTypeScript()
+
+	result, _, err := ts.ApplyMutant("math.js", src, model.Mutant{FuncName: "add", Original: "a + b", Mutated: "a - b"})
+	if err != nil {
+		t.Fatalf("ApplyMutant: %v", err)
+	}
+	if string(result) != "export function add(a, b) {\n  return a - b;\n}\n" {
+		t.Errorf("result = %q", result)
+	}
+
+	if _, _, err := ts.ApplyMutant("math.js", src, model.Mutant{FuncName: "add", Original: "a + b", Mutated: "a +"}); err == nil {
+		t.Error("expected an error for a mutant that doesn't parse")
+	}
+}
+
+func TestTypeScript_ValidateTestSyntax(t *testing.T) {
+	requireNode(t)
+	ts := NewTypeScript()
+	if err := ts.ValidateTestSyntax([]byte("import { test } from \"node:test\";\ntest(\"x\", () => {});\n")); err != nil {
+		t.Errorf("valid test rejected: %v", err)
+	}
+	if err := ts.ValidateTestSyntax([]byte("test(\"x\", () => {\n")); err == nil {
+		t.Error("expected an error for an unclosed test")
+	}
+}
+
+func TestTypeScript_IsTestFile(t *testing.T) {
+	tests := map[string]bool{
+		"src/cart.ts":                false,
+		"src/cart.test.ts":           true,
+		"src/cart.spec.js":           true,
+		"src/__tests__/cart.ts":      true,
+		"src/cart_add.snare.test.ts": true,
+		"src/testing/fixtures.ts":    false,
+	}
+	ts := NewTypeScript()
+	for path, want := range tests {
+		if got := ts.IsTestFile(path); got != want {
+			t.Errorf("IsTestFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestTypeScript_ForProject(t *testing.T) {
+	for name, tc := range map[string]struct {
+		packageJSON string
+		runner      string
+		want        string
+	}{
+		"vitest":     {`{"devDependencies": {"vitest": "^2.0.0", "jest": "^29"}}`, "auto", RunnerVitest},
+		"jest":       {`{"devDependencies": {"ts-jest": "^29"}}`, "", RunnerJest},
+		"none":       {`{"dependencies": {"express": "^4"}}`, "auto", RunnerNode},
+		"no package": {"", "auto", RunnerNode},
+		"flag wins":  {`{"devDependencies": {"vitest": "^2.0.0"}}`, RunnerJest, RunnerJest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.packageJSON != "" {
+				if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(tc.packageJSON), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			l := NewTypeScript().ForProject(dir, Options{JSRunner: tc.runner})
+			if got := l.(*TypeScript).Runner(); got != tc.want {
+				t.Errorf("runner = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTypeScript_RunTestNode(t *testing.T) {
+	requireNode(t)
+	dir := t.TempDir()
+	files := map[string]string{
+		"add.mjs":           "export function add(a, b) { return a + b; }\n",
+		"add.ts":            "export function add(a: number, b: number): number { return a + b; }\n",
+		"add_adds.test.mjs": "import test from \"node:test\";\nimport assert from \"node:assert\";\nimport { add } from \"./add.mjs\";\n\ntest(\"adds\", () => assert.strictEqual(add(1, 2), 3));\n",
+		"add_adds.test.ts":  "import test from \"node:test\";\nimport assert from \"node:assert\";\nimport { add } from \"./add.ts\";\n\ntest(\"adds\", () => assert.strictEqual(add(1, 2), 3));\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := NewTypeScript()
+
+	if passed, output, err := ts.RunTest(dir, "add_adds.test.mjs", "adds", time.Minute); err != nil || !passed {
+		t.Errorf("JavaScript test: passed=%v err=%v\n%s", passed, err, output)
+	}
+
+	passed, output, err := ts.RunTest(dir, "add_adds.test.ts", "adds", time.Minute)
+	if nodeStripsTypes() != nil {
+		// An old Node is a setup error, not a failing test
+		if err == nil || !strings.Contains(err.Error(), "Node 22.6") {
+			t.Errorf("TypeScript test on an old Node: passed=%v err=%v, want an error", passed, err)
+		}
+		return
+	}
+	if err != nil || !passed {
+		t.Errorf("TypeScript test: passed=%v err=%v\n%s", passed, err, output)
+	}
+}
+
+func TestTypeScript_RunSuiteNode(t *testing.T) {
+	requireNode(t)
+	dir := t.TempDir()
+	files := map[string]string{
+		"add.ts":      "export function add(a: number, b: number): number { return a + b; }\n",
+		"add.test.ts": "import test from \"node:test\";\nimport assert from \"node:assert\";\nimport { add } from \"./add.ts\";\n\ntest(\"adds\", () => assert.strictEqual(add(1, 2), 3));\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	passed, output, err := NewTypeScript().RunSuite(dir, "add.ts", time.Minute)
+	if nodeStripsTypes() != nil {
+		// Without type stripping every suite run would fail, mutant or not
+		if err == nil || !strings.Contains(err.Error(), "Node 22.6") {
+			t.Errorf("TypeScript suite on an old Node: passed=%v err=%v, want an error", passed, err)
+		}
+		return
+	}
+	if err != nil || !passed {
+		t.Errorf("TypeScript suite: passed=%v err=%v\n%s", passed, err, output)
+	}
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	bedrockpkg "github.com/anthropics/anthropic-sdk-go/bedrock"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// Anthropic talks to Claude through the Anthropic API or Amazon Bedrock.
+type Anthropic struct {
+	client anthropic.Client
+	name   string
+}
+
+// NewAnthropic creates a provider for the Anthropic API, authenticated with
+// ANTHROPIC_API_KEY.
+func NewAnthropic(throttle *Throttle) *Anthropic {
+	return &Anthropic{client: anthropic.NewClient(throttleOptions(throttle)...), name: ProviderAnthropic}
+}
+
+// NewBedrock creates a provider for Claude on Amazon Bedrock, authenticated
+// with AWS credentials from the default config chain.
+func NewBedrock(ctx context.Context, throttle *Throttle) *Anthropic {
+	// The throttle goes before the Bedrock middleware so each retry is re-signed
+	opts := append(throttleOptions(throttle), bedrockpkg.WithLoadDefaultConfig(ctx))
+	return &Anthropic{client: anthropic.NewClient(opts...), name: ProviderBedrock}
+}
+
+// throttleOptions installs the throttle in place of the SDK's own retries.
+func throttleOptions(throttle *Throttle) []option.RequestOption {
+	if throttle == nil {
+		return nil
+	}
+	return []option.RequestOption{option.WithMiddleware(throttle.Middleware), option.WithMaxRetries(0)}
+}
+
+// userBlocks renders the prompt as content blocks. A prefix gets its own
+// block with a cache breakpoint, so the tool definition and prefix are cached
+// and reused by later calls that share them (Anthropic API and Bedrock alike).
+func userBlocks(req Request) []anthropic.ContentBlockParamUnion {
+	if req.Prefix == "" {
+		return []anthropic.ContentBlockParamUnion{anthropic.NewTextBlock(req.Prompt)}
+	}
+	prefix := anthropic.TextBlockParam{
+		Text:         req.Prefix,
+		CacheControl: anthropic.NewCacheControlEphemeralParam(),
+	}
+	return []anthropic.ContentBlockParamUnion{
+		{OfText: &prefix},
+		anthropic.NewTextBlock(req.Prompt),
+	}
+}
+
+func (a *Anthropic) Name() string { return a.name }
+
+func (a *Anthropic) Complete(ctx context.Context, req Request) (*Response, error) {
+	params := anthropic.MessageNewParams{
+		Model:     anthropic.Model(req.Model),
+		MaxTokens: int64(req.MaxTokens),
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(userBlocks(req)...),
+		},
+	}
+	if req.Tool != nil {
+		tool := anthropic.ToolUnionParamOfTool(anthropic.ToolInputSchemaParam{
+			Properties: req.Tool.Schema.Properties,
+			Required:   req.Tool.Schema.Required,
+		}, req.Tool.Name)
+		tool.OfTool.Description = anthropic.String(req.Tool.Description)
+		params.Tools = []anthropic.ToolUnionParam{tool}
+		params.ToolChoice = anthropic.ToolChoiceParamOfTool(req.Tool.Name)
+	}
+
+	resp, err := a.client.Messages.New(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("Claude API call: %w", err)
+	}
+
+	// Use the first text block and the forced tool call
+	out := Response{Usage: Usage{
+		InputTokens:      resp.Usage.InputTokens,
+		OutputTokens:     resp.Usage.OutputTokens,
+		CacheReadTokens:  resp.Usage.CacheReadInputTokens,
+		CacheWriteTokens: resp.Usage.CacheCreationInputTokens,
+	}}
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			if out.Text == "" {
+				out.Text = block.Text
+			}
+		case "tool_use":
+			if req.Tool != nil && block.Name == req.Tool.Name && out.ToolInput == nil {
+				out.ToolInput = block.Input
+			}
+		}
+	}
+	return &out, nil
+}
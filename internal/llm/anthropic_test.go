@@ -0,0 +1,28 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestUserBlocks_CacheBreakpointOnPrefix(t *testing.T) {
+	blocks := userBlocks(Request{Prefix: "shared file context", Prompt: "this function"})
+	if len(blocks) != 2 {
+		t.Fatalf("blocks = %d, want 2", len(blocks))
+	}
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	got := string(data)
+	want := `[{"text":"shared file context","cache_control":{"type":"ephemeral"},"type":"text"},{"text":"this function","type":"text"}]`
+	if got != want {
+		t.Errorf("blocks =\n%s\nwant\n%s", got, want)
+	}
+
+	single, _ := json.Marshal(userBlocks(Request{Prompt: "whole prompt"}))
+	if strings.Contains(string(single), "cache_control") {
+		t.Error("a prompt without a prefix should not set a cache breakpoint")
+	}
+}
@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// cassetteEntry is one recorded call, stored as <dir>/<key>.json.
+type cassetteEntry struct {
+	Model     string          `json:"model"`
+	Prefix    string          `json:"prefix,omitempty"`
+	Prompt    string          `json:"prompt"`
+	Tool      string          `json:"tool,omitempty"`
+	Response  string          `json:"response"`
+	ToolInput json.RawMessage `json:"tool_input,omitempty"`
+	Usage     Usage           `json:"usage"`
+}
+
+// Recorder passes calls through to another provider and stores each prompt
+// and raw response in a cassette directory for later replay.
+type Recorder struct {
+	inner Provider
+	dir   string
+	root  string // project root, masked in keys (see CassetteKey)
+}
+
+// NewRecorder creates a recording wrapper around inner, writing to dir, for
+// the project at root.
+func NewRecorder(inner Provider, dir, root string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cassette directory: %w", err)
+	}
+	return &Recorder{inner: inner, dir: dir, root: root}, nil
+}
+
+func (r *Recorder) Name() string { return r.inner.Name() + " (recording)" }
+
+func (r *Recorder) Complete(ctx context.Context, req Request) (*Response, error) {
+	resp, err := r.inner.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := cassetteEntry{Model: req.Model, Prefix: req.Prefix, Prompt: req.Prompt, Response: resp.Text, ToolInput: resp.ToolInput, Usage: resp.Usage}
+	if req.Tool != nil {
+		entry.Tool = req.Tool.Name
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding cassette entry: %w", err)
+	}
+	// Write-then-rename so concurrent workers never expose a partial file
+	path := filepath.Join(r.dir, CassetteKey(req, r.root)+".json")
+	tmp, err := os.CreateTemp(r.dir, ".entry-*")
+	if err != nil {
+		return nil, fmt.Errorf("writing cassette entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("writing cassette entry: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("writing cassette entry: %w", err)
+	}
+	return resp, nil
+}
+
+// Replayer answers calls from a cassette directory without any network access.
+type Replayer struct {
+	dir  string
+	root string // project root, masked in keys (see CassetteKey)
+}
+
+// NewReplayer creates a provider that serves responses recorded in dir to
+// the project at root, wherever it was checked out when they were recorded.
+func NewReplayer(dir, root string) (*Replayer, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("opening cassette: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("opening cassette: %s is not a directory", dir)
+	}
+	return &Replayer{dir: dir, root: root}, nil
+}
+
+func (r *Replayer) Name() string { return "replay" }
+
+func (r *Replayer) Complete(ctx context.Context, req Request) (*Response, error) {
+	key := CassetteKey(req, r.root)
+	data, err := os.ReadFile(filepath.Join(r.dir, key+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no recorded response for prompt %s in %s", key[:12], r.dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette entry: %w", err)
+	}
+
+	var entry cassetteEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("parsing cassette entry %s: %w", key[:12], err)
+	}
+	// Recorded usage is replayed so cost reports match the original run
+	return &Response{Text: entry.Response, ToolInput: entry.ToolInput, Usage: entry.Usage}, nil
+}
+
+var (
+	// Test output embedded in judge prompts varies between otherwise identical
+	// runs: temp directory names and timings are masked before hashing
+	tempDirPattern  = regexp.MustCompile(`snare-([a-z]+-)?\d+`)
+	durationPattern = regexp.MustCompile(`\b\d+(\.\d+)?(ns|µs|us|ms|s)\b`)
+)
+
+// CassetteKey returns the cassette key for a request: a SHA-256 hash of the
+// model and prompt (and tool, if any) with run-specific noise masked. Paths
+// under root, the project root, are hashed relative to it, so a cassette can
+// be replayed from another checkout.
+func CassetteKey(req Request, root string) string {
+	prompt := req.Prefix + req.Prompt
+	if root = filepath.Clean(root); root != "." && root != string(filepath.Separator) {
+		prompt = strings.ReplaceAll(prompt, root, "<root>")
+	}
+	prompt = tempDirPattern.ReplaceAllString(prompt, "snare-*")
+	prompt = durationPattern.ReplaceAllString(prompt, "<duration>")
+	prompt = req.Model + "\x00" + prompt
+	if req.Tool != nil {
+		schema, _ := json.Marshal(req.Tool.Schema)
+		prompt += "\x00" + req.Tool.Name + "\x00" + string(schema)
+	}
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+type echoProvider struct{ calls int }
+
+func (e *echoProvider) Name() string { return "echo" }
+
+func (e *echoProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	e.calls++
+	return &Response{Text: "reply to " + req.Prompt}, nil
+}
+
+func TestCassette_RecordThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	inner := &echoProvider{}
+	rec, err := NewRecorder(inner, dir, "/home/a/proj")
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	if _, err := rec.Complete(context.Background(), Request{Model: "m", Prompt: "hello"}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	rep, err := NewReplayer(dir, "/home/a/proj")
+	if err != nil {
+		t.Fatalf("NewReplayer: %v", err)
+	}
+	resp, err := rep.Complete(context.Background(), Request{Model: "m", Prompt: "hello"})
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if resp.Text != "reply to hello" {
+		t.Errorf("Text = %q, want %q", resp.Text, "reply to hello")
+	}
+	if inner.calls != 1 {
+		t.Errorf("inner calls = %d, want 1", inner.calls)
+	}
+
+	if _, err := rep.Complete(context.Background(), Request{Model: "m", Prompt: "unseen"}); err == nil {
+		t.Error("expected error for a prompt that was not recorded")
+	}
+}
+
+func TestCassetteKey_MasksRunNoise(t *testing.T) {
+	a := CassetteKey(Request{Prompt: "--- FAIL: TestX (0.01s)\n/tmp/snare-123/x_test.go:4\nok  \tpkg\t0.004s"}, "")
+	b := CassetteKey(Request{Prompt: "--- FAIL: TestX (0.32s)\n/tmp/snare-overlay-987/x_test.go:4\nok  \tpkg\t1.250s"}, "")
+	if a != b {
+		t.Error("keys should ignore timings and temp directory names")
+	}
+	if a == CassetteKey(Request{Prompt: "--- FAIL: TestY (0.01s)"}, "") {
+		t.Error("different prompts should have different keys")
+	}
+}
+
+func TestCassetteKey_ProjectRootAndModel(t *testing.T) {
+	req := Request{Model: "m", Prompt: "File: /home/a/proj/calc/add.go"}
+	moved := Request{Model: "m", Prompt: "File: /ci/work/proj/calc/add.go"}
+	if CassetteKey(req, "/home/a/proj") != CassetteKey(moved, "/ci/work/proj/") {
+		t.Error("keys should not depend on where the project is checked out")
+	}
+	other := req
+	other.Model = "other"
+	if CassetteKey(req, "/home/a/proj") == CassetteKey(other, "/home/a/proj") {
+		t.Error("different models should have different keys")
+	}
+}
@@ -0,0 +1,263 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+// Stages label LLM calls for cost accounting.
+const (
+	StageGenerate = "generate"
+	StageRepair   = "repair"
+	StageJudge    = "judge"
+)
+
+// ErrBudgetExceeded is returned for calls made after the run's budget is spent.
+var ErrBudgetExceeded = errors.New("LLM budget exceeded")
+
+type labelKey struct{}
+
+type label struct {
+	stage    string
+	funcName string
+}
+
+// WithLabel attributes LLM calls made with ctx to a stage and function.
+func WithLabel(ctx context.Context, stage, funcName string) context.Context {
+	return context.WithValue(ctx, labelKey{}, label{stage: stage, funcName: funcName})
+}
+
+func labelFrom(ctx context.Context) label {
+	l, _ := ctx.Value(labelKey{}).(label)
+	if l.stage == "" {
+		l.stage = "other"
+	}
+	return l
+}
+
+// Budget caps a run's LLM spend in tokens or dollars (zero = unlimited).
+type Budget struct {
+	Tokens  int64
+	Dollars float64
+}
+
+// ParseBudget parses a budget flag: a token count ("500000", "500k", "2M")
+// or a dollar amount ("$5", "5usd"). The empty string means no budget.
+func ParseBudget(s string) (Budget, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return Budget{}, nil
+	}
+	if strings.HasPrefix(s, "$") || strings.HasSuffix(s, "usd") {
+		v := strings.TrimSuffix(strings.TrimPrefix(s, "$"), "usd")
+		d, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || d <= 0 {
+			return Budget{}, fmt.Errorf("invalid dollar budget %q", s)
+		}
+		return Budget{Dollars: d}, nil
+	}
+
+	mult := 1.0
+	switch {
+	case strings.HasSuffix(s, "k"):
+		mult, s = 1e3, strings.TrimSuffix(s, "k")
+	case strings.HasSuffix(s, "m"):
+		mult, s = 1e6, strings.TrimSuffix(s, "m")
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return Budget{}, fmt.Errorf("invalid token budget %q (want e.g. 500000, 500k, 2M or $5)", s)
+	}
+	return Budget{Tokens: int64(n * mult)}, nil
+}
+
+func (b Budget) String() string {
+	switch {
+	case b.Dollars > 0:
+		return fmt.Sprintf("$%.2f", b.Dollars)
+	case b.Tokens > 0:
+		return fmt.Sprintf("%d tokens", b.Tokens)
+	}
+	return ""
+}
+
+// Pricing is a model's price in USD per million tokens. Cache reads cost 10%
+// and cache writes 125% of the input price.
+type Pricing struct {
+	Input  float64
+	Output float64
+}
+
+// pricing lists known models by ID substring; more specific entries first.
+var pricing = []struct {
+	match string
+	Pricing
+}{
+	{"opus-4-6", Pricing{5, 25}},
+	{"opus-4-5", Pricing{5, 25}},
+	{"opus-4", Pricing{15, 75}},
+	{"sonnet-4", Pricing{3, 15}},
+	{"sonnet-3", Pricing{3, 15}},
+	{"3-7-sonnet", Pricing{3, 15}},
+	{"3-5-sonnet", Pricing{3, 15}},
+	{"haiku-4-5", Pricing{1, 5}},
+	{"3-5-haiku", Pricing{0.8, 4}},
+	{"3-haiku", Pricing{0.25, 1.25}},
+}
+
+// PricingFor returns the price of a model, if known.
+func PricingFor(modelID string) (Pricing, bool) {
+	for _, p := range pricing {
+		if strings.Contains(modelID, p.match) {
+			return p.Pricing, true
+		}
+	}
+	return Pricing{}, false
+}
+
+// Cost returns the dollar cost of a call's usage.
+func (p Pricing) Cost(u Usage) float64 {
+	return (float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheReadTokens)*p.Input*0.1 +
+		float64(u.CacheWriteTokens)*p.Input*1.25) / 1e6
+}
+
+// Meter wraps a provider to account for every call's tokens, cost and
+// latency, and refuses new calls once the budget is spent.
+type Meter struct {
+	inner   Provider
+	modelID string
+	price   Pricing
+	priced  bool
+	budget  Budget
+
+	mu      sync.Mutex
+	total   model.LLMUsage
+	byStage map[string]*model.LLMUsage
+	byFunc  map[string]*model.LLMUsage
+	funcs   []string // first-seen order
+}
+
+// NewMeter creates a meter for calls to modelID through inner. A dollar
+// budget requires known pricing for the model.
+func NewMeter(inner Provider, modelID string, budget Budget) (*Meter, error) {
+	price, priced := PricingFor(modelID)
+	if budget.Dollars > 0 && !priced {
+		return nil, fmt.Errorf("no pricing known for model %q; use a token budget instead", modelID)
+	}
+	return &Meter{
+		inner:   inner,
+		modelID: modelID,
+		price:   price,
+		priced:  priced,
+		budget:  budget,
+		byStage: make(map[string]*model.LLMUsage),
+		byFunc:  make(map[string]*model.LLMUsage),
+	}, nil
+}
+
+func (m *Meter) Name() string { return m.inner.Name() }
+
+func (m *Meter) Complete(ctx context.Context, req Request) (*Response, error) {
+	if m.Exceeded() {
+		return nil, fmt.Errorf("%w (%s)", ErrBudgetExceeded, m.budget)
+	}
+
+	start := time.Now()
+	resp, err := m.inner.Complete(ctx, req)
+	latency := time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+
+	// Per-path configuration may route some calls to another model
+	price := m.price
+	if req.Model != "" && req.Model != m.modelID {
+		price, _ = PricingFor(req.Model)
+	}
+
+	l := labelFrom(ctx)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	add := func(u *model.LLMUsage) {
+		u.Calls++
+		u.InputTokens += resp.Usage.InputTokens
+		u.OutputTokens += resp.Usage.OutputTokens
+		u.CacheReadTokens += resp.Usage.CacheReadTokens
+		u.CacheWriteTokens += resp.Usage.CacheWriteTokens
+		u.CostUSD += price.Cost(resp.Usage)
+		u.Latency += latency
+	}
+	add(&m.total)
+	if m.byStage[l.stage] == nil {
+		m.byStage[l.stage] = &model.LLMUsage{}
+	}
+	add(m.byStage[l.stage])
+	if l.funcName != "" {
+		if m.byFunc[l.funcName] == nil {
+			m.byFunc[l.funcName] = &model.LLMUsage{}
+			m.funcs = append(m.funcs, l.funcName)
+		}
+		add(m.byFunc[l.funcName])
+	}
+	return resp, nil
+}
+
+// Exceeded reports whether the budget has been spent.
+func (m *Meter) Exceeded() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.exceeded()
+}
+
+func (m *Meter) exceeded() bool {
+	return (m.budget.Tokens > 0 && m.total.TotalTokens() >= m.budget.Tokens) ||
+		(m.budget.Dollars > 0 && m.total.CostUSD >= m.budget.Dollars)
+}
+
+// Report returns the usage so far, broken down by stage and by function.
+func (m *Meter) Report() model.CostReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := model.CostReport{
+		Model:          m.modelID,
+		Priced:         m.priced,
+		Total:          m.total,
+		Budget:         m.budget.String(),
+		BudgetExceeded: m.exceeded(),
+	}
+	// Stages in pipeline order, then anything else alphabetically
+	order := map[string]int{StageGenerate: 0, StageRepair: 1, StageJudge: 2}
+	stages := make([]string, 0, len(m.byStage))
+	for s := range m.byStage {
+		stages = append(stages, s)
+	}
+	sort.Slice(stages, func(i, j int) bool {
+		oi, iok := order[stages[i]]
+		oj, jok := order[stages[j]]
+		if iok != jok {
+			return iok
+		}
+		if iok {
+			return oi < oj
+		}
+		return stages[i] < stages[j]
+	})
+	for _, s := range stages {
+		r.ByStage = append(r.ByStage, model.StageUsage{Stage: s, LLMUsage: *m.byStage[s]})
+	}
+	for _, f := range m.funcs {
+		r.ByFunc = append(r.ByFunc, model.FuncUsage{FuncName: f, LLMUsage: *m.byFunc[f]})
+	}
+	return r
+}
@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// usageProvider reports fixed usage for every call.
+type usageProvider struct{ usage Usage }
+
+func (p *usageProvider) Name() string { return "usage" }
+
+func (p *usageProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	return &Response{Text: "ok", Usage: p.usage}, nil
+}
+
+func TestMeter_Breakdown(t *testing.T) {
+	m, err := NewMeter(&usageProvider{usage: Usage{InputTokens: 1000, OutputTokens: 200}}, "claude-sonnet-4-5", Budget{})
+	if err != nil {
+		t.Fatalf("NewMeter: %v", err)
+	}
+	ctx := context.Background()
+	m.Complete(WithLabel(ctx, StageGenerate, "A.Run"), Request{})
+	m.Complete(WithLabel(ctx, StageJudge, "A.Run"), Request{})
+	m.Complete(WithLabel(ctx, StageGenerate, "B.Stop"), Request{})
+
+	r := m.Report()
+	if r.Total.Calls != 3 || r.Total.InputTokens != 3000 || r.Total.OutputTokens != 600 {
+		t.Errorf("Total = %+v", r.Total)
+	}
+	// 3 x (1000 x $3 + 200 x $15) / 1M
+	if want := 0.018; r.Total.CostUSD < want-1e-9 || r.Total.CostUSD > want+1e-9 {
+		t.Errorf("CostUSD = %v, want %v", r.Total.CostUSD, want)
+	}
+	if len(r.ByStage) != 2 || r.ByStage[0].Stage != StageGenerate || r.ByStage[0].Calls != 2 || r.ByStage[1].Stage != StageJudge {
+		t.Errorf("ByStage = %+v", r.ByStage)
+	}
+	if len(r.ByFunc) != 2 || r.ByFunc[0].FuncName != "A.Run" || r.ByFunc[0].Calls != 2 {
+		t.Errorf("ByFunc = %+v", r.ByFunc)
+	}
+}
+
+func TestMeter_TokenBudget(t *testing.T) {
+	m, _ := NewMeter(&usageProvider{usage: Usage{InputTokens: 600}}, "local-model", Budget{Tokens: 1000})
+	ctx := context.Background()
+	if _, err := m.Complete(ctx, Request{}); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if _, err := m.Complete(ctx, Request{}); err != nil {
+		t.Fatalf("second call (budget not yet spent): %v", err)
+	}
+	if _, err := m.Complete(ctx, Request{}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("third call err = %v, want ErrBudgetExceeded", err)
+	}
+	if !m.Report().BudgetExceeded {
+		t.Error("report should flag the exceeded budget")
+	}
+}
+
+func TestMeter_DollarBudgetNeedsPricing(t *testing.T) {
+	if _, err := NewMeter(&usageProvider{}, "llama3", Budget{Dollars: 1}); err == nil {
+		t.Fatal("expected error for a dollar budget on an unpriced model")
+	}
+}
+
+func TestParseBudget(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Budget
+	}{
+		{"", Budget{}},
+		{"500000", Budget{Tokens: 500000}},
+		{"500k", Budget{Tokens: 500000}},
+		{"2M", Budget{Tokens: 2000000}},
+		{"$5", Budget{Dollars: 5}},
+		{"2.5usd", Budget{Dollars: 2.5}},
+	}
+	for _, tt := range tests {
+		got, err := ParseBudget(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseBudget(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"lots", "$", "-5", "0"} {
+		if _, err := ParseBudget(bad); err == nil {
+			t.Errorf("ParseBudget(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// DefaultOpenAIBaseURL is used when neither --base-url nor OPENAI_BASE_URL is set.
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAI talks to any server implementing the OpenAI chat completions API,
+// including local model servers such as Ollama and vLLM.
+type OpenAI struct {
+	baseURL  string
+	apiKey   string
+	http     *http.Client
+	throttle *Throttle
+}
+
+// NewOpenAI creates a provider for an OpenAI-compatible endpoint. Empty
+// baseURL and apiKey fall back to OPENAI_BASE_URL and OPENAI_API_KEY; the
+// key may be empty for local servers that don't check it.
+func NewOpenAI(baseURL, apiKey string, throttle *Throttle) *OpenAI {
+	if baseURL == "" {
+		baseURL = os.Getenv("OPENAI_BASE_URL")
+	}
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	return &OpenAI{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		apiKey:   apiKey,
+		http:     http.DefaultClient,
+		throttle: throttle,
+	}
+}
+
+func (o *OpenAI) Name() string { return ProviderOpenAI }
+
+type chatMessage struct {
+	Role      string         `json:"role"`
+	Content   string         `json:"content"`
+	ToolCalls []chatToolCall `json:"tool_calls,omitempty"`
+}
+
+type chatToolCall struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON-encoded
+	} `json:"function"`
+}
+
+type chatFunction struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Parameters  *Schema `json:"parameters"`
+}
+
+type chatTool struct {
+	Type     string       `json:"type"`
+	Function chatFunction `json:"function"`
+}
+
+type chatToolChoice struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+type chatRequest struct {
+	Model      string          `json:"model"`
+	Messages   []chatMessage   `json:"messages"`
+	MaxTokens  int             `json:"max_tokens,omitempty"`
+	Tools      []chatTool      `json:"tools,omitempty"`
+	ToolChoice *chatToolChoice `json:"tool_choice,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens        int64 `json:"prompt_tokens"`
+		CompletionTokens    int64 `json:"completion_tokens"`
+		PromptTokensDetails struct {
+			CachedTokens int64 `json:"cached_tokens"`
+		} `json:"prompt_tokens_details"`
+	} `json:"usage"`
+}
+
+func (o *OpenAI) Complete(ctx context.Context, req Request) (*Response, error) {
+	// OpenAI-compatible servers cache shared prompt prefixes automatically
+	chat := chatRequest{
+		Model:     req.Model,
+		Messages:  []chatMessage{{Role: "user", Content: req.Prefix + req.Prompt}},
+		MaxTokens: req.MaxTokens,
+	}
+	if req.Tool != nil {
+		chat.Tools = []chatTool{{
+			Type:     "function",
+			Function: chatFunction{Name: req.Tool.Name, Description: req.Tool.Description, Parameters: req.Tool.Schema},
+		}}
+		chat.ToolChoice = &chatToolChoice{Type: "function"}
+		chat.ToolChoice.Function.Name = req.Tool.Name
+	}
+	body, err := json.Marshal(chat)
+	if err != nil {
+		return nil, fmt.Errorf("encoding request: %w", err)
+	}
+
+	// bytes.Reader bodies get a GetBody, so the throttle can replay them
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	var res *http.Response
+	if o.throttle != nil {
+		res, err = o.throttle.Middleware(httpReq, o.http.Do)
+	} else {
+		res, err = o.http.Do(httpReq)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI-compatible API call: %w", err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenAI-compatible API call: %s: %.500s", res.Status, data)
+	}
+
+	var reply chatResponse
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	// OpenAI counts cached tokens as part of the prompt
+	usage := Usage{
+		InputTokens:     reply.Usage.PromptTokens - reply.Usage.PromptTokensDetails.CachedTokens,
+		OutputTokens:    reply.Usage.CompletionTokens,
+		CacheReadTokens: reply.Usage.PromptTokensDetails.CachedTokens,
+	}
+	if len(reply.Choices) == 0 {
+		return &Response{Usage: usage}, nil
+	}
+	msg := reply.Choices[0].Message
+	out := &Response{Text: msg.Content, Usage: usage}
+	for _, call := range msg.ToolCalls {
+		if req.Tool != nil && call.Function.Name == req.Tool.Name {
+			out.ToolInput = json.RawMessage(call.Function.Arguments)
+			break
+		}
+	}
+	return out, nil
+}
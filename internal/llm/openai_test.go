@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOpenAI_Complete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %q, want /v1/chat/completions", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want Bearer secret", got)
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		if req.Model != "llama3" || len(req.Messages) != 1 || req.Messages[0].Content != "hello" {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi there"}}]}`))
+	}))
+	defer srv.Close()
+
+	p := NewOpenAI(srv.URL+"/v1/", "secret", nil)
+	resp, err := p.Complete(context.Background(), Request{Model: "llama3", Prompt: "hello", MaxTokens: 100})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Text != "hi there" {
+		t.Errorf("Text = %q, want %q", resp.Text, "hi there")
+	}
+}
+
+func TestOpenAI_RetriesThroughThrottle(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer srv.Close()
+
+	th := NewThrottle(0, 2)
+	th.baseDelay = time.Millisecond
+	resp, err := NewOpenAI(srv.URL, "", th).Complete(context.Background(), Request{Model: "m", Prompt: "p"})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Text != "ok" || calls != 2 {
+		t.Errorf("Text = %q after %d calls, want \"ok\" after 2", resp.Text, calls)
+	}
+}
+
+func TestOpenAI_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	if _, err := NewOpenAI(srv.URL, "", nil).Complete(context.Background(), Request{Model: "m", Prompt: "p"}); err == nil {
+		t.Fatal("expected error for 404 response")
+	}
+}
+
+func TestNew_UnknownProvider(t *testing.T) {
+	if _, err := New(context.Background(), Config{Provider: "gemini"}, nil); err == nil {
+		t.Fatal("expected error for unknown provider")
+	}
+}
+
+func TestOpenAI_ToolCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		if len(req.Tools) != 1 || req.ToolChoice == nil || req.ToolChoice.Function.Name != "submit_verdict" {
+			t.Errorf("tool not forced: %+v", req)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"type":"function","function":{"name":"submit_verdict","arguments":"{\"score\":1,\"reason\":\"bug\"}"}}]}}]}`))
+	}))
+	defer srv.Close()
+
+	resp, err := NewOpenAI(srv.URL, "", nil).Complete(context.Background(), Request{Model: "m", Prompt: "p", Tool: verdictTool})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if string(resp.ToolInput) != `{"score":1,"reason":"bug"}` {
+		t.Errorf("ToolInput = %s", resp.ToolInput)
+	}
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Provider sends prompts to a language model. Generation and judging only
+// depend on this interface, so any backend that can answer a single-turn
+// prompt can drive the pipeline.
+type Provider interface {
+	// Name identifies the backend in verbose output (e.g. "anthropic").
+	Name() string
+	// Complete sends a single user prompt and returns the model's reply.
+	Complete(ctx context.Context, req Request) (*Response, error)
+}
+
+// Request is a single-turn completion request.
+type Request struct {
+	Model     string
+	Prefix    string // leading part of the prompt shared across calls; cached where supported
+	Prompt    string // the rest of the prompt (the whole prompt if Prefix is empty)
+	MaxTokens int
+	Tool      *Tool // if set, the model must answer by calling this tool
+}
+
+// Response is the model's reply to a Request.
+type Response struct {
+	Text      string
+	ToolInput json.RawMessage // arguments of the forced tool call, if any
+	Usage     Usage
+}
+
+// Usage counts the tokens billed for one call. InputTokens excludes tokens
+// read from or written to the prompt cache.
+type Usage struct {
+	InputTokens      int64 `json:"input_tokens"`
+	OutputTokens     int64 `json:"output_tokens"`
+	CacheReadTokens  int64 `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int64 `json:"cache_write_tokens,omitempty"`
+}
+
+// Supported provider names.
+const (
+	ProviderAnthropic = "anthropic"
+	ProviderBedrock   = "bedrock"
+	ProviderOpenAI    = "openai" // any OpenAI-compatible server: OpenAI, Ollama, vLLM, ...
+)
+
+// Config selects and configures a provider.
+type Config struct {
+	Provider string // one of the Provider* constants ("" = anthropic)
+	BaseURL  string // OpenAI-compatible endpoint (openai only)
+	APIKey   string // bearer token (openai only; optional for local servers)
+}
+
+// New creates the provider named in cfg. A non-nil throttle rate-limits and
+// retries every request the provider makes.
+func New(ctx context.Context, cfg Config, throttle *Throttle) (Provider, error) {
+	switch cfg.Provider {
+	case "", ProviderAnthropic:
+		return NewAnthropic(throttle), nil
+	case ProviderBedrock:
+		return NewBedrock(ctx, throttle), nil
+	case ProviderOpenAI:
+		return NewOpenAI(cfg.BaseURL, cfg.APIKey, throttle), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q (want %s, %s or %s)", cfg.Provider, ProviderAnthropic, ProviderBedrock, ProviderOpenAI)
+	}
+}
@@ -0,0 +1,137 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Schema is the subset of JSON Schema used to describe tool inputs. It is
+// sent to the model as the tool's input schema and used to validate replies.
+type Schema struct {
+	Type        string             `json:"type"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	MinItems    int                `json:"minItems,omitempty"`
+	MinLength   int                `json:"minLength,omitempty"`
+}
+
+// Tool is a function the model must call to deliver a structured answer.
+type Tool struct {
+	Name        string
+	Description string
+	Schema      *Schema // must have Type "object"
+}
+
+// Validate checks a JSON document against the schema and returns one
+// human-readable problem per violation (empty if the document is valid).
+func (s *Schema) Validate(data []byte) []string {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return []string{fmt.Sprintf("not valid JSON: %v", err)}
+	}
+	var problems []string
+	s.validate(v, "", &problems)
+	return problems
+}
+
+func (s *Schema) validate(v any, path string, problems *[]string) {
+	where := path
+	if where == "" {
+		where = "input"
+	}
+	fail := func(format string, args ...any) {
+		*problems = append(*problems, where+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("expected an object")
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				*problems = append(*problems, joinPath(path, name)+": missing required field")
+			}
+		}
+		// Sorted for stable problem lists
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if fv, ok := obj[name]; ok {
+				s.Properties[name].validate(fv, joinPath(path, name), problems)
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			fail("expected an array")
+			return
+		}
+		if len(arr) < s.MinItems {
+			fail("expected at least %d items, got %d", s.MinItems, len(arr))
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			fail("expected a string")
+			return
+		}
+		if len(str) < s.MinLength {
+			fail("must not be empty")
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, str) {
+			fail("must be one of %v", s.Enum)
+		}
+	case "number", "integer":
+		n, ok := v.(float64)
+		if !ok {
+			fail("expected a number")
+			return
+		}
+		if s.Type == "integer" && n != float64(int64(n)) {
+			fail("expected an integer")
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			fail("must be >= %g", *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			fail("must be <= %g", *s.Maximum)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("expected a boolean")
+		}
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
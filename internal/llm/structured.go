@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CompleteJSON asks the model to answer by calling req.Tool and decodes the
+// validated tool input into a T. Input that violates the tool's schema, or
+// for which check reports problems, gets one targeted repair request listing
+// exactly what was wrong. check may be nil.
+func CompleteJSON[T any](ctx context.Context, p Provider, req Request, check func(*T) []string) (*T, error) {
+	if req.Tool == nil {
+		return nil, fmt.Errorf("CompleteJSON requires a tool")
+	}
+
+	out, raw, problems, err := completeOnce(ctx, p, req, check)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) == 0 {
+		return out, nil
+	}
+
+	repair := req
+	repair.Prompt = repairPrompt(req.Prompt, req.Tool.Name, raw, problems)
+	out, _, problems, err = completeOnce(ctx, p, repair, check)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid %s input after repair: %s", req.Tool.Name, strings.Join(problems, "; "))
+	}
+	return out, nil
+}
+
+// completeOnce makes one call and returns the decoded value, the raw input
+// and any problems found with it.
+func completeOnce[T any](ctx context.Context, p Provider, req Request, check func(*T) []string) (*T, string, []string, error) {
+	resp, err := p.Complete(ctx, req)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	raw := resp.ToolInput
+	if len(raw) == 0 {
+		// Servers without tool support answer in text; accept bare JSON
+		raw = json.RawMessage(stripCodeFences(resp.Text))
+	}
+	if len(raw) == 0 {
+		return nil, "", []string{"no tool call or JSON in the response"}, nil
+	}
+
+	if problems := req.Tool.Schema.Validate(raw); len(problems) > 0 {
+		return nil, string(raw), problems, nil
+	}
+	var out T
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, string(raw), []string{fmt.Sprintf("cannot decode input: %v", err)}, nil
+	}
+	if check != nil {
+		if problems := check(&out); len(problems) > 0 {
+			return nil, string(raw), problems, nil
+		}
+	}
+	return &out, string(raw), nil, nil
+}
+
+func repairPrompt(prompt, tool, raw string, problems []string) string {
+	var sb strings.Builder
+	sb.WriteString(prompt)
+	sb.WriteString("\n\n## Your previous answer\n```json\n")
+	if len(raw) > 4000 {
+		raw = raw[:4000] + "\n... (truncated)"
+	}
+	sb.WriteString(raw)
+	sb.WriteString("\n```\n\n## Problems with it\n")
+	for _, p := range problems {
+		sb.WriteString("- " + p + "\n")
+	}
+	sb.WriteString("\nCall the " + tool + " tool again with the complete, corrected input. Keep everything that was correct and fix only these problems.\n")
+	return sb.String()
+}
+
+func stripCodeFences(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "```json") {
+		s = strings.TrimPrefix(s, "```json")
+	} else if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```")
+	}
+	if strings.HasSuffix(s, "```") {
+		s = strings.TrimSuffix(s, "```")
+	}
+	return strings.TrimSpace(s)
+}
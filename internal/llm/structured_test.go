@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var verdictTool = &Tool{
+	Name: "submit_verdict",
+	Schema: &Schema{
+		Type:     "object",
+		Required: []string{"score", "reason"},
+		Properties: map[string]*Schema{
+			"score":  {Type: "number", Minimum: floatPtr(-1), Maximum: floatPtr(1)},
+			"reason": {Type: "string", MinLength: 1},
+			"tags":   {Type: "array", Items: &Schema{Type: "string"}},
+		},
+	},
+}
+
+type verdict struct {
+	Score  float64  `json:"score"`
+	Reason string   `json:"reason"`
+	Tags   []string `json:"tags"`
+}
+
+func floatPtr(f float64) *float64 { return &f }
+
+func TestSchema_Validate(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{`{"score": 0.5, "reason": "ok"}`, nil},
+		{`{"score": 0.5}`, []string{"reason: missing required field"}},
+		{`{"score": 2, "reason": "ok"}`, []string{"score: must be <= 1"}},
+		{`{"score": "high", "reason": ""}`, []string{"reason: must not be empty", "score: expected a number"}},
+		{`{"score": 0, "reason": "ok", "tags": ["a", 3]}`, []string{"tags[1]: expected a string"}},
+		{`[]`, []string{"input: expected an object"}},
+	}
+	for _, tt := range tests {
+		got := verdictTool.Schema.Validate([]byte(tt.input))
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("Validate(%s) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// toolProvider answers each call with the next canned tool input.
+type toolProvider struct {
+	inputs  []string
+	prompts []string
+}
+
+func (p *toolProvider) Name() string { return "tool" }
+
+func (p *toolProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	p.prompts = append(p.prompts, req.Prompt)
+	in := p.inputs[0]
+	p.inputs = p.inputs[1:]
+	return &Response{ToolInput: json.RawMessage(in)}, nil
+}
+
+func TestCompleteJSON_RepairsInvalidInput(t *testing.T) {
+	p := &toolProvider{inputs: []string{`{"score": 3}`, `{"score": 0.8, "reason": "boundary"}`}}
+	v, err := CompleteJSON[verdict](context.Background(), p, Request{Prompt: "judge this", Tool: verdictTool}, nil)
+	if err != nil {
+		t.Fatalf("CompleteJSON: %v", err)
+	}
+	if v.Score != 0.8 || v.Reason != "boundary" {
+		t.Errorf("got %+v", v)
+	}
+	if len(p.prompts) != 2 {
+		t.Fatalf("calls = %d, want 2", len(p.prompts))
+	}
+	for _, want := range []string{"judge this", "reason: missing required field", "score: must be <= 1", "submit_verdict"} {
+		if !strings.Contains(p.prompts[1], want) {
+			t.Errorf("repair prompt missing %q", want)
+		}
+	}
+}
+
+func TestCompleteJSON_CheckProblems(t *testing.T) {
+	p := &toolProvider{inputs: []string{`{"score": 0, "reason": "x"}`, `{"score": 0, "reason": "x"}`}}
+	check := func(v *verdict) []string {
+		if v.Score == 0 {
+			return []string{"score: must not be exactly 0"}
+		}
+		return nil
+	}
+	_, err := CompleteJSON(context.Background(), p, Request{Prompt: "p", Tool: verdictTool}, check)
+	if err == nil || !strings.Contains(err.Error(), "must not be exactly 0") {
+		t.Fatalf("err = %v, want the check problem after a failed repair", err)
+	}
+}
+
+func TestCompleteJSON_AcceptsFencedText(t *testing.T) {
+	p := &echoJSONProvider{text: "```json\n{\"score\": -0.5, \"reason\": \"intended\"}\n```"}
+	v, err := CompleteJSON[verdict](context.Background(), p, Request{Prompt: "p", Tool: verdictTool}, nil)
+	if err != nil {
+		t.Fatalf("CompleteJSON: %v", err)
+	}
+	if v.Score != -0.5 {
+		t.Errorf("Score = %v, want -0.5", v.Score)
+	}
+}
+
+// echoJSONProvider models a server without tool support that replies in text.
+type echoJSONProvider struct{ text string }
+
+func (p *echoJSONProvider) Name() string { return "text" }
+
+func (p *echoJSONProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	return &Response{Text: p.text}, nil
+}
@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Throttle limits the rate of requests to an LLM API and retries transient
+// failures (rate limiting, overload, 5xx, connection errors) with exponential
+// backoff. It is installed as HTTP middleware so every call through a client
+// — generation and judging alike — shares the same budget.
+type Throttle struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+
+	mu       sync.Mutex
+	interval time.Duration // minimum spacing between requests (0 = unlimited)
+	next     time.Time     // earliest time the next request may start
+}
+
+// NewThrottle creates a throttle allowing rpm requests per minute (0 = unlimited)
+// and up to maxRetries retries per request.
+func NewThrottle(rpm int, maxRetries int) *Throttle {
+	t := &Throttle{
+		maxRetries: maxRetries,
+		baseDelay:  time.Second,
+		maxDelay:   time.Minute,
+	}
+	if rpm > 0 {
+		t.interval = time.Minute / time.Duration(rpm)
+	}
+	return t
+}
+
+// Wait blocks until the rate limit allows another request.
+func (t *Throttle) Wait(ctx context.Context) error {
+	t.mu.Lock()
+	now := time.Now()
+	start := t.next
+	if start.Before(now) {
+		start = now
+	}
+	t.next = start.Add(t.interval)
+	t.mu.Unlock()
+
+	return sleep(ctx, time.Until(start))
+}
+
+// Middleware rate-limits and retries an HTTP request. Its signature matches
+// the anthropic SDK's option.Middleware.
+func (t *Throttle) Middleware(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if err := t.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		// Each attempt gets a fresh copy: downstream middleware (e.g. Bedrock
+		// signing) may rewrite the request in place
+		try := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			try.Body = body
+		}
+
+		res, err := next(try)
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+		canRetry := req.Body == nil || req.GetBody != nil
+		if !canRetry || attempt >= t.maxRetries || !retryable(res, err) {
+			return res, err
+		}
+
+		delay := t.backoff(res, attempt)
+		if res != nil && res.Body != nil {
+			res.Body.Close()
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryable reports whether a response (or transport error) is transient.
+func retryable(res *http.Response, err error) bool {
+	if err != nil {
+		// Connection-level failure
+		return true
+	}
+	switch res.StatusCode {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+		529: // Anthropic: overloaded
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before retry number attempt+1: the server's
+// Retry-After if given, otherwise exponential backoff with jitter.
+func (t *Throttle) backoff(res *http.Response, attempt int) time.Duration {
+	if res != nil {
+		if secs, err := strconv.ParseFloat(res.Header.Get("Retry-After"), 64); err == nil && secs >= 0 {
+			if d := time.Duration(secs * float64(time.Second)); d <= t.maxDelay {
+				return d
+			}
+			return t.maxDelay
+		}
+	}
+
+	delay := t.baseDelay << attempt
+	if delay > t.maxDelay || delay <= 0 {
+		delay = t.maxDelay
+	}
+	// Up to 25% jitter so concurrent workers don't retry in lockstep
+	jitter := time.Duration(rand.Int63n(int64(delay)/4 + 1))
+	return delay - jitter
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newRequest(t *testing.T) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest("POST", "https://api.example.com/v1/messages", bytes.NewReader([]byte(`{"x":1}`)))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	return req
+}
+
+func respond(status int) *http.Response {
+	return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))}
+}
+
+func TestThrottle_RetriesOverloaded(t *testing.T) {
+	th := NewThrottle(0, 3)
+	th.baseDelay = time.Millisecond
+
+	statuses := []int{429, 529, 200}
+	var bodies []string
+	res, err := th.Middleware(newRequest(t), func(r *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		status := statuses[0]
+		statuses = statuses[1:]
+		return respond(status), nil
+	})
+	if err != nil {
+		t.Fatalf("Middleware: %v", err)
+	}
+	if res.StatusCode != 200 {
+		t.Errorf("StatusCode = %d, want 200", res.StatusCode)
+	}
+	if len(bodies) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(bodies))
+	}
+	for i, b := range bodies {
+		if b != `{"x":1}` {
+			t.Errorf("attempt %d body = %q, want the original body", i, b)
+		}
+	}
+}
+
+func TestThrottle_GivesUpAfterMaxRetries(t *testing.T) {
+	th := NewThrottle(0, 2)
+	th.baseDelay = time.Millisecond
+
+	attempts := 0
+	res, err := th.Middleware(newRequest(t), func(r *http.Request) (*http.Response, error) {
+		attempts++
+		return respond(429), nil
+	})
+	if err != nil {
+		t.Fatalf("Middleware: %v", err)
+	}
+	if res.StatusCode != 429 {
+		t.Errorf("StatusCode = %d, want 429", res.StatusCode)
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3 (1 + 2 retries)", attempts)
+	}
+}
+
+func TestThrottle_NoRetryOnClientError(t *testing.T) {
+	th := NewThrottle(0, 5)
+	attempts := 0
+	th.Middleware(newRequest(t), func(r *http.Request) (*http.Response, error) {
+		attempts++
+		return respond(400), nil
+	})
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestThrottle_RateLimit(t *testing.T) {
+	th := NewThrottle(600, 0) // one request per 100ms
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		th.Middleware(newRequest(t), func(r *http.Request) (*http.Response, error) {
+			return respond(200), nil
+		})
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("3 requests at 600 rpm took %s, want >= 200ms", elapsed)
+	}
+}
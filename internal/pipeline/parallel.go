@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// forEachOrdered calls fn for every index in [0, n) on up to jobs goroutines.
+// Each call writes its output to a private buffer; buffers are flushed to out
+// in index order as soon as all earlier calls have finished, so output from
+// concurrent calls never interleaves and is the same as a sequential run.
+func forEachOrdered(n, jobs int, out io.Writer, fn func(i int, w io.Writer)) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	if jobs > n {
+		jobs = n
+	}
+
+	var (
+		mu   sync.Mutex
+		bufs = make([]*bytes.Buffer, n)
+		next int
+		wg   sync.WaitGroup
+	)
+	indices := make(chan int)
+
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				var buf bytes.Buffer
+				fn(i, &buf)
+
+				mu.Lock()
+				bufs[i] = &buf
+				for next < n && bufs[next] != nil {
+					out.Write(bufs[next].Bytes())
+					bufs[next] = nil
+					next++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachOrdered_OutputInIndexOrder(t *testing.T) {
+	const n = 20
+	var out bytes.Buffer
+	results := make([]int, n)
+
+	forEachOrdered(n, 4, &out, func(i int, w io.Writer) {
+		// Later indices finish first to exercise reordering
+		time.Sleep(time.Duration(n-i) * time.Millisecond)
+		results[i] = i * i
+		fmt.Fprintf(w, "job %d start\n", i)
+		fmt.Fprintf(w, "job %d end\n", i)
+	})
+
+	var want bytes.Buffer
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&want, "job %d start\njob %d end\n", i, i)
+		if results[i] != i*i {
+			t.Errorf("results[%d] = %d, want %d", i, results[i], i*i)
+		}
+	}
+	if out.String() != want.String() {
+		t.Errorf("output not in index order:\n%s", out.String())
+	}
+}
+
+func TestForEachOrdered_BoundsConcurrency(t *testing.T) {
+	var running, peak int32
+	forEachOrdered(10, 3, io.Discard, func(i int, w io.Writer) {
+		cur := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if cur <= p || atomic.CompareAndSwapInt32(&peak, p, cur) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+	})
+
+	if peak > 3 {
+		t.Errorf("peak concurrency = %d, want <= 3", peak)
+	}
+}
@@ -3,15 +3,19 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/yiyuanh/snare/internal/analysis"
 	"github.com/yiyuanh/snare/internal/assess"
+	"github.com/yiyuanh/snare/internal/config"
 	"github.com/yiyuanh/snare/internal/diff"
 	"github.com/yiyuanh/snare/internal/lang"
+	"github.com/yiyuanh/snare/internal/llm"
 	"github.com/yiyuanh/snare/internal/runner"
 	"github.com/yiyuanh/snare/internal/telemetry"
 	"github.com/yiyuanh/snare/internal/testgen"
@@ -20,18 +24,62 @@ import (
 
 // Options configures the pipeline.
 type Options struct {
-	Dir           string
-	Staged        bool
-	Commit        string
-	Model         string
-	MaxTests      int
-	Verbose       bool
-	DryRun        bool
-	Timeout       time.Duration
-	APIKey        string
-	Bedrock       bool
-	CommitMessage string // populated during pipeline run
-	TelemetryDB   string // path to telemetry SQLite database
+	Dir            string
+	Staged         bool
+	Commit         string
+	Base           string // diff HEAD against its merge-base with this ref (PR mode)
+	Range          string // diff a revision range: A..B or A...B
+	Patch          string // read the diff from this patch file ("-" for stdin)
+	PatchParent    string // revision or directory holding the pre-patch sources
+	Model          string
+	MaxTests       int
+	Verbose        bool
+	DryRun         bool
+	Timeout        time.Duration
+	APIKey         string
+	Provider       string     // LLM backend: anthropic (default), bedrock or openai
+	BaseURL        string     // endpoint for the openai provider (e.g. a local Ollama or vLLM server)
+	Record         string     // cassette directory to record LLM calls into
+	Replay         string     // cassette directory to answer LLM calls from (no network)
+	CacheDir       string     // generation cache location ("" = user cache dir)
+	NoCache        bool       // always regenerate, ignoring cached results
+	RepairAttempts int        // times to regenerate a test that fails on parent code
+	Budget         llm.Budget // stop making LLM calls once this is spent
+	CommitMessage  string     // populated during pipeline run
+	TelemetryDB    string     // path to telemetry SQLite database
+	Jobs           int        // number of test executions to run concurrently
+	GenJobs        int        // number of functions to generate tests for concurrently
+	RPM            int        // LLM requests per minute (0 = unlimited)
+	MaxRetries     int        // retries for rate-limited or failed LLM requests
+	Mode           string     // "catching" (default) or "suite"
+	SuiteTimeout   time.Duration
+	NoJudge        bool           // score with the rule-based assessors only
+	LikelyBug      float64        // minimum assessment for a weak catch to be a likely bug
+	Config         *config.Config // per-path settings from .snare.yaml (may be nil)
+	JSRunner       string         // jest, vitest, node or auto (detect from package.json)
+
+	// File and function filters
+	Include           []string       // only analyze files matching one of these globs
+	Exclude           []string       // skip files matching any of these globs
+	NoDefaultExcludes bool           // don't add diff.DefaultExcludes to Exclude
+	IncludeFunc       *regexp.Regexp // only analyze functions whose qualified name matches
+	ExcludeFunc       *regexp.Regexp // skip functions whose qualified name matches
+}
+
+// Pipeline modes.
+const (
+	ModeCatching = "catching" // generated tests: parent vs mutant vs new
+	ModeSuite    = "suite"    // project's own tests vs each mutant
+)
+
+// genResult holds the LLM output for a single changed function.
+type genResult struct {
+	fn      model.ChangedFunc
+	lang    lang.Language
+	intent  string
+	risks   []model.Risk
+	mutants []model.Mutant
+	tests   []model.GeneratedTest
 }
 
 // Pipeline orchestrates the 5-stage JiT catching test process.
@@ -47,7 +95,10 @@ func New(opts Options) *Pipeline {
 // Run executes the full pipeline.
 func (p *Pipeline) Run(ctx context.Context) (*model.PipelineResult, error) {
 	start := time.Now()
-	result := &model.PipelineResult{}
+	result := &model.PipelineResult{LikelyBugThreshold: p.opts.LikelyBug}
+	if p.opts.Mode == ModeSuite {
+		result.Mode = ModeSuite
+	}
 
 	// Resolve working directory to absolute path
 	dir, err := filepath.Abs(p.opts.Dir)
@@ -56,34 +107,83 @@ func (p *Pipeline) Run(ctx context.Context) (*model.PipelineResult, error) {
 	}
 
 	// Find project root (directory containing go.mod or setup.py/pyproject.toml)
-	moduleDir, err := findProjectRoot(dir)
+	moduleDir, err := FindProjectRoot(dir)
 	if err != nil {
 		return nil, fmt.Errorf("finding project root: %w", err)
 	}
+	if p.opts.Verbose && p.opts.Config != nil {
+		fmt.Printf("Using config %s\n", p.opts.Config.Path)
+	}
 
 	// Stage 1: Diff Extraction (with parent source retrieval)
 	if p.opts.Verbose {
 		fmt.Println("Stage 1: Extracting diffs and parent sources...")
 	}
 	extractor := diff.NewExtractor(moduleDir)
-	fileDiffs, err := extractor.Extract(p.opts.Staged, p.opts.Commit)
-	if err != nil {
-		return nil, fmt.Errorf("extracting diffs: %w", err)
+	extractor.Include = p.opts.Include
+	extractor.Exclude = p.opts.Exclude
+	if !p.opts.NoDefaultExcludes {
+		extractor.Defaults = diff.DefaultExcludes
 	}
+	var fileDiffs []model.FileDiff
+	var commitMsg string
+	switch {
+	case p.opts.Patch != "":
+		raw, err := readPatch(p.opts.Patch)
+		if err != nil {
+			return nil, fmt.Errorf("reading patch: %w", err)
+		}
+		parent := p.opts.PatchParent
+		if parent == "" {
+			parent = "HEAD"
+		}
+		if info, err := os.Stat(parent); err == nil && info.IsDir() {
+			if parent, err = filepath.Abs(parent); err != nil {
+				return nil, fmt.Errorf("resolving patch parent: %w", err)
+			}
+		}
+		if p.opts.Verbose {
+			fmt.Printf("  Reading patch %s (parent sources from %s)\n", p.opts.Patch, parent)
+		}
+		fileDiffs, err = extractor.ExtractPatch(raw, parent)
+		if err != nil {
+			return nil, fmt.Errorf("extracting diffs: %w", err)
+		}
+		commitMsg = diff.GetPatchMessage(raw)
+	case p.opts.Base != "" || p.opts.Range != "":
+		from, to, err := p.resolveRange(extractor)
+		if err != nil {
+			return nil, fmt.Errorf("resolving revisions: %w", err)
+		}
+		if p.opts.Verbose {
+			fmt.Printf("  Diffing %s..%s\n", from, to)
+		}
+		fileDiffs, err = extractor.ExtractRange(from, to)
+		if err != nil {
+			return nil, fmt.Errorf("extracting diffs: %w", err)
+		}
+		commitMsg, err = extractor.GetRangeMessage(from, to)
+		if err != nil && p.opts.Verbose {
+			fmt.Printf("  Warning: could not get commit messages: %v\n", err)
+		}
+	default:
+		fileDiffs, err = extractor.Extract(p.opts.Staged, p.opts.Commit)
+		if err != nil {
+			return nil, fmt.Errorf("extracting diffs: %w", err)
+		}
+		// Fetch commit message for context
+		commitMsg, err = extractor.GetCommitMessage(p.opts.Commit)
+		if err != nil && p.opts.Verbose {
+			fmt.Printf("  Warning: could not get commit message: %v\n", err)
+		}
+	}
+	result.Skipped = extractor.Skipped
 	if len(fileDiffs) == 0 {
 		fmt.Println("No source file changes detected.")
 		result.Duration = time.Since(start)
 		return result, nil
 	}
 	result.FilesAnalyzed = len(fileDiffs)
-
-	// Fetch commit message for context
-	commitMsg, err := extractor.GetCommitMessage(p.opts.Commit)
-	if err != nil {
-		if p.opts.Verbose {
-			fmt.Printf("  Warning: could not get commit message: %v\n", err)
-		}
-	}
 	p.opts.CommitMessage = commitMsg
 
 	if p.opts.Verbose {
@@ -96,10 +196,27 @@ func (p *Pipeline) Run(ctx context.Context) (*model.PipelineResult, error) {
 		}
 	}
 
-	// Detect language from file diffs
-	language := detectLanguage(fileDiffs)
-	if p.opts.Verbose {
-		fmt.Printf("  Detected language: %s\n", language.Name())
+	// Each language analyzes, generates and runs tests for its own files
+	groups := groupByLanguage(fileDiffs)
+	langs := make(map[string]lang.Language) // file path -> language
+	var langNames []string
+	for i, g := range groups {
+		if pa, ok := g.lang.(lang.ProjectAware); ok {
+			g.lang = pa.ForProject(moduleDir, lang.Options{JSRunner: p.opts.JSRunner})
+			groups[i] = g
+		}
+		for _, fd := range g.diffs {
+			langs[fd.NewName] = g.lang
+		}
+		langNames = append(langNames, g.lang.Name())
+		if p.opts.Verbose {
+			fmt.Printf("  Detected language: %s (%d files)\n", g.lang.Name(), len(g.diffs))
+		}
+	}
+	if len(groups) == 0 {
+		fmt.Println("No source file changes detected.")
+		result.Duration = time.Since(start)
+		return result, nil
 	}
 
 	// Stage 2: AST Analysis (dual-version: parent + new)
@@ -108,18 +225,30 @@ func (p *Pipeline) Run(ctx context.Context) (*model.PipelineResult, error) {
 	}
 
 	var changedFuncs []model.ChangedFunc
-	if language.Name() == "go" {
-		// Use Go-specific AST analysis (backward compatible)
-		changedFuncs, err = analysis.MapChangedFuncs(fileDiffs)
-	} else {
-		// Use language-agnostic analysis via Language interface
-		changedFuncs, err = analysis.MapChangedFuncsWithLang(fileDiffs, language)
+	for _, g := range groups {
+		var funcs []model.ChangedFunc
+		var skipped []model.SkippedFile
+		if g.lang.Name() == "go" {
+			// Use Go-specific AST analysis (backward compatible)
+			funcs, skipped, err = analysis.MapChangedFuncs(g.diffs)
+		} else {
+			// Use language-agnostic analysis via Language interface
+			funcs, skipped, err = analysis.MapChangedFuncsWithLang(g.diffs, g.lang, g.lang.Prompt().Comments)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("analyzing %s changes: %w", g.lang.Name(), err)
+		}
+		changedFuncs = append(changedFuncs, funcs...)
+		result.Skipped = append(result.Skipped, skipped...)
 	}
-	if err != nil {
-		return nil, fmt.Errorf("analyzing changes: %w", err)
+	if p.opts.Verbose {
+		for _, sf := range result.Skipped {
+			fmt.Printf("  Skipped %s: %s\n", sf.FilePath, sf.Reason)
+		}
 	}
+	changedFuncs = analysis.FilterFuncs(changedFuncs, p.opts.IncludeFunc, p.opts.ExcludeFunc)
 	if len(changedFuncs) == 0 {
-		fmt.Printf("No changed functions detected in %s files.\n", language.Name())
+		fmt.Printf("No changed functions detected in %s files.\n", strings.Join(langNames, "/"))
 		result.Duration = time.Since(start)
 		return result, nil
 	}
@@ -147,43 +276,93 @@ func (p *Pipeline) Run(ctx context.Context) (*model.PipelineResult, error) {
 			if fn.TelemetryContext != "" {
 				telemetryInfo = ", with telemetry"
 			}
-			fmt.Printf("  %s.%s (lines %d-%d, %s%s)\n", fn.Package, fn.Name, fn.StartLine, fn.EndLine, hasParent, telemetryInfo)
+			fmt.Printf("  %s.%s (lines %d-%d, %s%s)\n", fn.Package, fn.ID(), fn.StartLine, fn.EndLine, hasParent, telemetryInfo)
 		}
 	}
 
+	// Build file diff lookup for parent source
+	fileDiffMap := make(map[string]model.FileDiff)
+	for _, fd := range fileDiffs {
+		fileDiffMap[fd.NewName] = fd
+	}
+
 	// Stage 3: Intent-Aware Generation
+	provider, err := p.newProvider(ctx, moduleDir)
+	if err != nil {
+		return nil, err
+	}
+	meter, err := llm.NewMeter(provider, p.opts.Model, p.opts.Budget)
+	if err != nil {
+		return nil, err
+	}
+	// Every return from here on reports what the LLM calls cost
+	defer func() {
+		cost := meter.Report()
+		result.Cost = &cost
+	}()
 	if p.opts.Verbose {
-		fmt.Println("Stage 3: Generating intent-aware catching tests via Claude...")
+		fmt.Printf("Stage 3: Generating intent-aware catching tests via %s...\n", meter.Name())
 	}
-	gen := testgen.NewGenerator(ctx, p.opts.Model, language, p.opts.MaxTests, p.opts.Verbose, p.opts.Bedrock)
-
-	type genResult struct {
-		fn      model.ChangedFunc
-		intent  string
-		risks   []model.Risk
-		mutants []model.Mutant
-		tests   []model.GeneratedTest
+	gen := testgen.NewGenerator(meter, p.opts.Model, groups[0].lang, p.opts.MaxTests, p.opts.Verbose)
+	if cache := p.openCache(); cache != nil {
+		gen = gen.WithCache(cache)
 	}
-	var generated []genResult
 
-	for _, fn := range changedFuncs {
+	// Generate concurrently; results land in per-function slots so the
+	// order (and everything downstream) matches changedFuncs
+	outcomes := make([]genResult, len(changedFuncs))
+	genErrs := make([]error, len(changedFuncs))
+	forEachOrdered(len(changedFuncs), p.opts.GenJobs, os.Stdout, func(i int, w io.Writer) {
+		fn := changedFuncs[i]
 		if p.opts.Verbose {
-			fmt.Printf("  Generating for %s...\n", fn.Name)
+			fmt.Fprintf(w, "  Generating for %s...\n", fn.ID())
+		}
+		fnGen := p.generatorFor(gen, moduleDir, fn.FilePath, langs[fn.FilePath])
+		// Type-check against the source mutants will be applied to: the
+		// parent for catching tests, the new code for the suite
+		if fd, ok := fileDiffMap[fn.FilePath]; ok {
+			if p.opts.Mode == ModeSuite {
+				if newSource, err := newSourceFor(fd, fn.FilePath); err == nil {
+					fnGen = fnGen.WithTypeCheck(moduleDir, fn.FilePath, newSource, false)
+				}
+			} else {
+				fnGen = fnGen.WithTypeCheck(moduleDir, fn.FilePath, fd.ParentSource, true)
+			}
 		}
-		intent, risks, mutants, tests, err := gen.Generate(ctx, fn, p.opts.CommitMessage)
+		intent, risks, mutants, tests, err := fnGen.Generate(ctx, fn, p.opts.CommitMessage)
 		if err != nil {
-			fmt.Printf("  Warning: generation failed for %s: %v\n", fn.Name, err)
-			continue
+			fmt.Fprintf(w, "  Warning: generation failed for %s: %v\n", fn.ID(), err)
+			genErrs[i] = err
+			return
 		}
-		result.MutantsGenerated += len(mutants)
-		result.TestsGenerated += len(tests)
-		result.RisksIdentified += len(risks)
-		generated = append(generated, genResult{fn: fn, intent: intent, risks: risks, mutants: mutants, tests: tests})
-
+		outcomes[i] = genResult{fn: fn, lang: langs[fn.FilePath], intent: intent, risks: risks, mutants: mutants, tests: tests}
 		if p.opts.Verbose {
-			fmt.Printf("  Intent: %s\n", intent)
-			fmt.Printf("  Generated %d risks, %d mutants, %d tests for %s\n", len(risks), len(mutants), len(tests), fn.Name)
+			fmt.Fprintf(w, "  Intent: %s\n", intent)
+			fmt.Fprintf(w, "  Generated %d risks, %d mutants, %d tests for %s\n", len(risks), len(mutants), len(tests), fn.ID())
 		}
+	})
+
+	if p.opts.Verbose && gen.CacheHits() > 0 {
+		fmt.Printf("  Reused cached results for %d of %d functions\n", gen.CacheHits(), len(changedFuncs))
+	}
+	if p.opts.Verbose {
+		printPromptCacheRate(meter.Report(), llm.StageGenerate)
+	}
+
+	var generated []genResult
+	for i, g := range outcomes {
+		if err := genErrs[i]; err != nil {
+			result.GenerationErrors = append(result.GenerationErrors, model.GenerationError{
+				FuncName: changedFuncs[i].ID(),
+				FilePath: changedFuncs[i].FilePath,
+				Error:    err.Error(),
+			})
+			continue
+		}
+		result.MutantsGenerated += len(g.mutants)
+		result.TestsGenerated += len(g.tests)
+		result.RisksIdentified += len(g.risks)
+		generated = append(generated, g)
 	}
 
 	if len(generated) == 0 {
@@ -203,12 +382,6 @@ func (p *Pipeline) Run(ctx context.Context) (*model.PipelineResult, error) {
 		result.Intent = intents[0]
 	}
 
-	// Build file diff lookup for parent source
-	fileDiffMap := make(map[string]model.FileDiff)
-	for _, fd := range fileDiffs {
-		fileDiffMap[fd.NewName] = fd
-	}
-
 	// Stage 4: Catching Execution (skip if dry-run)
 	if p.opts.DryRun {
 		if p.opts.Verbose {
@@ -235,10 +408,28 @@ func (p *Pipeline) Run(ctx context.Context) (*model.PipelineResult, error) {
 		return result, nil
 	}
 
+	if p.opts.Mode == ModeSuite {
+		p.runSuite(moduleDir, generated, fileDiffMap, result)
+		result.Duration = time.Since(start)
+		return result, nil
+	}
+
 	if p.opts.Verbose {
-		fmt.Println("Stage 4: Executing catching tests (parent vs new)...")
+		fmt.Println("Stage 4: Executing catching tests (parent, mutant, new)...")
 	}
-	executor := runner.NewExecutor(moduleDir, language, p.opts.Timeout, p.opts.Verbose)
+	executor := runner.NewExecutor(moduleDir, groups[0].lang, p.opts.Timeout, p.opts.Verbose)
+	defer executor.Close()
+
+	// Collect test/mutant pairs first, then run them on the worker pool
+	type catchingJob struct {
+		fn           model.ChangedFunc
+		lang         lang.Language
+		test         model.GeneratedTest
+		mutant       model.Mutant
+		parentSource []byte
+		newSource    []byte
+	}
+	var jobs []catchingJob
 
 	for _, g := range generated {
 		// Get parent source from the file diff
@@ -248,17 +439,10 @@ func (p *Pipeline) Run(ctx context.Context) (*model.PipelineResult, error) {
 			continue
 		}
 
-		// Get new source: from the commit (if available) or from disk
-		var newSource []byte
-		if len(fd.NewSource) > 0 {
-			newSource = fd.NewSource
-		} else {
-			var err error
-			newSource, err = os.ReadFile(g.fn.FilePath)
-			if err != nil {
-				fmt.Printf("  Warning: cannot read %s: %v\n", g.fn.FilePath, err)
-				continue
-			}
+		newSource, err := newSourceFor(fd, g.fn.FilePath)
+		if err != nil {
+			fmt.Printf("  Warning: cannot read %s: %v\n", g.fn.FilePath, err)
+			continue
 		}
 
 		mutantMap := make(map[string]model.Mutant)
@@ -272,33 +456,84 @@ func (p *Pipeline) Run(ctx context.Context) (*model.PipelineResult, error) {
 				fmt.Printf("  Warning: test %s references unknown mutant %s\n", t.TestName, t.MutantID)
 				continue
 			}
+			jobs = append(jobs, catchingJob{fn: g.fn, lang: g.lang, test: t, mutant: mutant, parentSource: fd.ParentSource, newSource: newSource})
+		}
+	}
 
-			result.TestsRun++
-			tr, err := executor.ExecuteCatching(t, mutant, g.fn.FilePath, fd.ParentSource, newSource)
-			if err != nil {
-				fmt.Printf("  Warning: execution failed for %s: %v\n", t.TestName, err)
-				tr.FilteredReason = fmt.Sprintf("execution error: %v", err)
+	// Each job runs in its own temp dirs; results keep job order
+	results := make([]model.TestResult, len(jobs))
+	forEachOrdered(len(jobs), p.opts.Jobs, os.Stdout, func(i int, w io.Writer) {
+		job := jobs[i]
+		exec := executor.WithOutput(w).WithLanguage(job.lang).WithTimeout(p.settingsFor(moduleDir, job.fn.FilePath).timeout)
+		jobGen := p.generatorFor(gen, moduleDir, job.fn.FilePath, job.lang).WithTypeCheck(moduleDir, job.fn.FilePath, job.parentSource, true)
+		tr, err := exec.ExecuteCatching(job.test, job.mutant, job.fn.FilePath, job.parentSource, job.newSource)
+
+		// Feed parent failures (usually compile errors) back to the model
+		test := job.test
+		for attempt := 1; err == nil && !tr.PassParent && attempt <= p.opts.RepairAttempts && !meter.Exceeded(); attempt++ {
+			if p.opts.Verbose {
+				fmt.Fprintf(w, "  Repairing %s (attempt %d/%d)...\n", test.TestName, attempt, p.opts.RepairAttempts)
 			}
-			// Pass through telemetry context for the judge
-			if g.fn.TelemetryContext != "" {
-				tr.TelemetryContext = g.fn.TelemetryContext
+			fixed, rerr := jobGen.Repair(ctx, job.fn, test, job.mutant, tr.ParentOutput)
+			if rerr != nil {
+				fmt.Fprintf(w, "  Warning: %v\n", rerr)
+				break
 			}
-			result.Results = append(result.Results, tr)
+			test = fixed
+			tr, err = exec.ExecuteCatching(test, job.mutant, job.fn.FilePath, job.parentSource, job.newSource)
+			tr.RepairAttempts = attempt
+		}
+
+		if err != nil {
+			fmt.Fprintf(w, "  Warning: execution failed for %s: %v\n", test.TestName, err)
+			tr.FilteredReason = fmt.Sprintf("execution error: %v", err)
+		}
+		// Pass through telemetry context for the judge
+		if job.fn.TelemetryContext != "" {
+			tr.TelemetryContext = job.fn.TelemetryContext
 		}
+		results[i] = tr
+	})
+	result.TestsRun += len(jobs)
+	result.Results = append(result.Results, results...)
+	if p.opts.Verbose {
+		printPromptCacheRate(meter.Report(), llm.StageRepair)
 	}
 
 	// Stage 5: Assessment (rule-based patterns + LLM-as-judge on weak catches)
 	if p.opts.Verbose {
 		fmt.Println("Stage 5: Assessing results (rule-based + LLM judge)...")
 	}
-	chain := assess.DefaultCatchingChain(gen.Client(), p.opts.Model, ctx, p.opts.Verbose, p.opts.CommitMessage)
-	result.Results = chain.Evaluate(result.Results)
+	// Judge settings can differ per path, so each result goes through the
+	// chain for its file; chains are shared by model
+	chains := make(map[string]*assess.Chain)
+	for i, job := range jobs {
+		set := p.settingsFor(moduleDir, job.fn.FilePath)
+		key := ""
+		if set.judge {
+			key = set.model
+		}
+		chain, ok := chains[key]
+		if !ok {
+			if set.judge {
+				chain = assess.DefaultCatchingChain(gen.Provider(), set.model, ctx, p.opts.Verbose, p.opts.CommitMessage)
+			} else {
+				chain = assess.DefaultRuleOnlyChain()
+			}
+			chains[key] = chain
+		}
+		chain.Evaluate(result.Results[i : i+1])
+		result.Results[i].LikelyBug = result.Results[i].IsCatching && result.Results[i].Assessment > set.likelyBug
+	}
+	if p.opts.Verbose {
+		printPromptCacheRate(meter.Report(), llm.StageJudge)
+	}
 
 	// Count weak/strong catches and filtered
 	for _, r := range result.Results {
 		if r.IsCatching {
 			result.WeakCatches++
-			if r.Assessment > 0.5 {
+			if r.LikelyBug {
 				result.StrongCatches++
 			}
 		}
@@ -311,19 +546,275 @@ func (p *Pipeline) Run(ctx context.Context) (*model.PipelineResult, error) {
 	return result, nil
 }
 
-// detectLanguage examines file diffs to determine the project language.
-// Returns Python if any .py files are present, otherwise Go.
-func detectLanguage(fileDiffs []model.FileDiff) lang.Language {
+// pathSettings are the settings in effect for one file: the run's options
+// with any .snare.yaml overrides for its path applied.
+type pathSettings struct {
+	model     string
+	timeout   time.Duration
+	maxTests  int
+	judge     bool
+	likelyBug float64
+}
+
+// settingsFor returns the settings for a file. Overrides match paths relative
+// to the project root, while changed functions carry absolute ones.
+func (p *Pipeline) settingsFor(moduleDir, filePath string) pathSettings {
+	s := pathSettings{
+		model:     p.opts.Model,
+		timeout:   p.opts.Timeout,
+		maxTests:  p.opts.MaxTests,
+		judge:     !p.opts.NoJudge,
+		likelyBug: p.opts.LikelyBug,
+	}
+	relPath, err := filepath.Rel(moduleDir, filePath)
+	if err != nil {
+		relPath = filePath
+	}
+	c := p.opts.Config.For(filepath.ToSlash(relPath))
+	if c.Model != nil {
+		s.model = *c.Model
+	}
+	if c.Timeout != nil {
+		s.timeout = *c.Timeout
+	}
+	if c.MaxTests != nil {
+		s.maxTests = *c.MaxTests
+	}
+	if c.Judge != nil {
+		s.judge = *c.Judge
+	}
+	if c.Thresholds.LikelyBug != nil {
+		s.likelyBug = *c.Thresholds.LikelyBug
+	}
+	return s
+}
+
+// generatorFor returns gen set up for a file: its language, and the model and
+// max tests in effect for its path, for generation and repairs alike.
+func (p *Pipeline) generatorFor(gen *testgen.Generator, moduleDir, filePath string, l lang.Language) *testgen.Generator {
+	gen = gen.WithLanguage(l)
+	if set := p.settingsFor(moduleDir, filePath); set.model != p.opts.Model || set.maxTests != p.opts.MaxTests {
+		gen = gen.WithSettings(set.model, set.maxTests)
+	}
+	return gen
+}
+
+// readPatch reads a patch from a file, or from stdin when path is "-".
+func readPatch(path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// newProvider creates the configured LLM backend behind a shared throttle,
+// so generation and judging draw on the same rate limit. With a cassette
+// configured, calls are recorded to or replayed from disk, keyed with paths
+// relative to root.
+func (p *Pipeline) newProvider(ctx context.Context, root string) (llm.Provider, error) {
+	if p.opts.Replay != "" {
+		return llm.NewReplayer(p.opts.Replay, root)
+	}
+
+	cfg := llm.Config{Provider: p.opts.Provider, BaseURL: p.opts.BaseURL}
+	throttle := llm.NewThrottle(p.opts.RPM, p.opts.MaxRetries)
+	provider, err := llm.New(ctx, cfg, throttle)
+	if err != nil {
+		return nil, fmt.Errorf("creating LLM provider: %w", err)
+	}
+	if p.opts.Record != "" {
+		return llm.NewRecorder(provider, p.opts.Record, root)
+	}
+	return provider, nil
+}
+
+// openCache opens the generation cache, or returns nil when caching is off.
+// Cassette runs bypass the cache so every prompt is actually recorded or replayed.
+func (p *Pipeline) openCache() *testgen.Cache {
+	if p.opts.NoCache || p.opts.Record != "" || p.opts.Replay != "" {
+		return nil
+	}
+	dir := p.opts.CacheDir
+	if dir == "" {
+		var err error
+		if dir, err = testgen.DefaultCacheDir(); err != nil {
+			if p.opts.Verbose {
+				fmt.Printf("  Warning: generation cache disabled: %v\n", err)
+			}
+			return nil
+		}
+	}
+	cache, err := testgen.NewCache(dir)
+	if err != nil {
+		if p.opts.Verbose {
+			fmt.Printf("  Warning: generation cache disabled: %v\n", err)
+		}
+		return nil
+	}
+	return cache
+}
+
+// printPromptCacheRate reports how much of a stage's prompt input was served
+// from the provider's prompt cache, in verbose output after each LLM stage
+// (generation, repair, judging). Stages whose calls wrote nothing to or read
+// nothing from the cache are left out.
+func printPromptCacheRate(cost model.CostReport, stage string) {
+	for _, s := range cost.ByStage {
+		if s.Stage != stage || s.CacheReadTokens+s.CacheWriteTokens == 0 {
+			continue
+		}
+		fmt.Printf("  Prompt cache: %.0f%% of %s input tokens read from cache (%d read, %d written)\n",
+			100*s.CacheHitRate(), stage, s.CacheReadTokens, s.CacheWriteTokens)
+	}
+}
+
+// resolveRange returns the from/to revisions for --base or --range.
+// With --base, the diff runs from the merge-base of the base ref and HEAD to HEAD.
+func (p *Pipeline) resolveRange(extractor *diff.Extractor) (from, to string, err error) {
+	if p.opts.Range != "" {
+		return extractor.ResolveRange(p.opts.Range)
+	}
+	from, err = extractor.MergeBase(p.opts.Base, "HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	return from, "HEAD", nil
+}
+
+// runSuite is Stage 4 in suite mode: each mutant is applied to the new source
+// and the project's own tests are run against it. Mutants that the suite does
+// not kill are the places where the real tests are weak.
+func (p *Pipeline) runSuite(moduleDir string, generated []genResult, fileDiffMap map[string]model.FileDiff, result *model.PipelineResult) {
+	if p.opts.Verbose {
+		fmt.Println("Stage 4: Running project test suite against each mutant...")
+	}
+	timeout := p.opts.SuiteTimeout
+	if timeout == 0 {
+		timeout = p.opts.Timeout
+	}
+	executor := runner.NewExecutor(moduleDir, generated[0].lang, timeout, p.opts.Verbose)
+	defer executor.Close()
+
+	// The suite must pass on the unmutated new source, once per file
+	baseline := make(map[string]string) // file path -> reason the baseline is unusable ("" if it passed)
+
+	type suiteJob struct {
+		funcIdx int
+		lang    lang.Language
+		mutant  model.Mutant
+		path    string
+		source  []byte
+		reason  string // set when the mutant can't be evaluated
+	}
+	var jobs []suiteJob
+	coverage := make([]model.FuncCoverage, 0, len(generated))
+
+	for _, g := range generated {
+		fd := fileDiffMap[g.fn.FilePath]
+		newSource, err := newSourceFor(fd, g.fn.FilePath)
+		if err != nil {
+			fmt.Printf("  Warning: cannot read %s: %v\n", g.fn.FilePath, err)
+			continue
+		}
+
+		reason, checked := baseline[g.fn.FilePath]
+		if !checked {
+			passed, _, err := executor.WithLanguage(g.lang).RunSuiteBaseline(g.fn.FilePath, newSource)
+			switch {
+			case err != nil:
+				reason = fmt.Sprintf("suite could not run: %v", err)
+			case !passed:
+				reason = "suite fails without mutation"
+			}
+			baseline[g.fn.FilePath] = reason
+			if reason != "" {
+				fmt.Printf("  Warning: %s for %s\n", reason, g.fn.FilePath)
+			}
+		}
+
+		coverage = append(coverage, model.FuncCoverage{FuncName: g.fn.ID()})
+		for _, m := range g.mutants {
+			jobs = append(jobs, suiteJob{funcIdx: len(coverage) - 1, lang: g.lang, mutant: m, path: g.fn.FilePath, source: newSource, reason: reason})
+		}
+	}
+
+	suiteResults := make([]model.SuiteResult, len(jobs))
+	forEachOrdered(len(jobs), p.opts.Jobs, os.Stdout, func(i int, w io.Writer) {
+		job := jobs[i]
+		if job.reason != "" {
+			suiteResults[i] = model.SuiteResult{Mutant: job.mutant, Error: job.reason}
+			return
+		}
+		sr, err := executor.WithOutput(w).WithLanguage(job.lang).ExecuteSuite(job.mutant, job.path, job.source)
+		if err != nil {
+			fmt.Fprintf(w, "  Warning: suite execution failed for %s: %v\n", job.mutant.ID, err)
+			sr.Error = fmt.Sprintf("execution error: %v", err)
+		}
+		suiteResults[i] = sr
+	})
+
+	for i, sr := range suiteResults {
+		fc := &coverage[jobs[i].funcIdx]
+		switch {
+		case sr.Error != "":
+			fc.NotEvaluated++
+		case sr.Killed:
+			fc.Killed++
+			result.MutantsKilled++
+		default:
+			fc.Survived++
+			result.MutantsSurvived++
+		}
+	}
+	result.SuiteResults = suiteResults
+	result.FuncCoverage = coverage
+}
+
+// newSourceFor returns the new version of a file: from the commit (if available) or from disk.
+func newSourceFor(fd model.FileDiff, filePath string) ([]byte, error) {
+	if len(fd.NewSource) > 0 {
+		return fd.NewSource, nil
+	}
+	return os.ReadFile(filePath)
+}
+
+// langGroup is the changed files handled by one language.
+type langGroup struct {
+	lang  lang.Language
+	diffs []model.FileDiff
+}
+
+// groupByLanguage splits file diffs by the registered language for their
+// extension, in order of each language's first file. Files no language
+// handles are dropped.
+func groupByLanguage(fileDiffs []model.FileDiff) []langGroup {
+	var groups []langGroup
+	index := make(map[string]int) // language name -> position in groups
 	for _, fd := range fileDiffs {
 		name := fd.NewName
 		if name == "" {
 			name = fd.OldName
 		}
-		if strings.HasSuffix(name, ".py") {
-			return lang.NewPython()
+		l := lang.ForFile(name)
+		if l == nil {
+			continue
 		}
+		i, ok := index[l.Name()]
+		if !ok {
+			i = len(groups)
+			index[l.Name()] = i
+			groups = append(groups, langGroup{lang: l})
+		}
+		groups[i].diffs = append(groups[i].diffs, fd)
 	}
-	return lang.NewGo()
+	return groups
 }
 
 // enrichWithTelemetry opens the telemetry database and enriches changed functions
@@ -336,10 +827,16 @@ func (p *Pipeline) enrichWithTelemetry(changedFuncs []model.ChangedFunc) error {
 	defer reader.Close()
 
 	for i := range changedFuncs {
-		ft, err := reader.GetFunctionTelemetry(changedFuncs[i].Name, changedFuncs[i].FilePath)
+		fn := changedFuncs[i]
+		// Look up by qualified identity first; fall back to the plain name
+		// for telemetry sources that record unqualified function names
+		ft, err := reader.GetFunctionTelemetry(fn.ID(), fn.FilePath)
+		if err == nil && ft == nil && fn.ID() != fn.Name {
+			ft, err = reader.GetFunctionTelemetry(fn.Name, fn.FilePath)
+		}
 		if err != nil {
 			if p.opts.Verbose {
-				fmt.Printf("  Warning: telemetry lookup failed for %s: %v\n", changedFuncs[i].Name, err)
+				fmt.Printf("  Warning: telemetry lookup failed for %s: %v\n", fn.ID(), err)
 			}
 			continue
 		}
@@ -350,10 +847,10 @@ func (p *Pipeline) enrichWithTelemetry(changedFuncs []model.ChangedFunc) error {
 	return nil
 }
 
-// findProjectRoot walks up from dir until it finds a project root marker.
-// Supports go.mod (Go), setup.py, pyproject.toml, or requirements.txt (Python).
-func findProjectRoot(dir string) (string, error) {
-	markers := []string{"go.mod", "setup.py", "pyproject.toml", "requirements.txt"}
+// FindProjectRoot walks up from dir until it finds a project root marker of
+// any registered language (go.mod, pyproject.toml, ...).
+func FindProjectRoot(dir string) (string, error) {
+	markers := lang.RootMarkers()
 	current := dir
 	for {
 		for _, marker := range markers {
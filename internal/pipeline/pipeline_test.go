@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yiyuanh/snare/internal/config"
+	"github.com/yiyuanh/snare/internal/lang"
+	"github.com/yiyuanh/snare/internal/llm"
+	"github.com/yiyuanh/snare/internal/testgen"
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+func TestGroupByLanguage_MixedDiff(t *testing.T) {
+	diffs := []model.FileDiff{
+		{NewName: "/repo/svc/handler.go"},
+		{NewName: "/repo/scripts/report.py"},
+		{NewName: "/repo/svc/store.go"},
+		{NewName: "/repo/README.md"},
+	}
+
+	groups := groupByLanguage(diffs)
+	if len(groups) != 2 {
+		t.Fatalf("len(groups) = %d, want 2", len(groups))
+	}
+	if groups[0].lang.Name() != "go" || len(groups[0].diffs) != 2 || groups[0].diffs[1].NewName != "/repo/svc/store.go" {
+		t.Errorf("go group = %s %v", groups[0].lang.Name(), groups[0].diffs)
+	}
+	if groups[1].lang.Name() != "python" || len(groups[1].diffs) != 1 {
+		t.Errorf("python group = %s %v", groups[1].lang.Name(), groups[1].diffs)
+	}
+}
+
+func TestSettingsFor_AbsoluteFilePath(t *testing.T) {
+	billingModel, timeout := "billing-model", 5*time.Minute
+	cfg := &config.Config{Overrides: []config.Override{
+		{Path: "internal/billing/", Settings: config.Settings{Model: &billingModel, Timeout: &timeout}},
+	}}
+	p := New(Options{Model: "default-model", Timeout: time.Minute, Config: cfg})
+
+	// The extractor joins diff paths onto the project root
+	fd := model.FileDiff{NewName: "/root/proj/internal/billing/invoice.go"}
+	if s := p.settingsFor("/root/proj", fd.NewName); s.model != billingModel || s.timeout != timeout {
+		t.Errorf("billing settings = %+v, want the override", s)
+	}
+	if s := p.settingsFor("/root/proj", "/root/proj/internal/shipping/rate.go"); s.model != "default-model" || s.timeout != time.Minute {
+		t.Errorf("shipping settings = %+v, want the defaults", s)
+	}
+}
+
+// modelRecorder answers every call with a fixed test and records the model
+// each call asked for.
+type modelRecorder struct {
+	models []string
+}
+
+func (m *modelRecorder) Name() string { return "recorder" }
+
+func (m *modelRecorder) Complete(ctx context.Context, req llm.Request) (*llm.Response, error) {
+	m.models = append(m.models, req.Model)
+	return &llm.Response{Text: `{"test_name": "TestInvoice", "test_code": "package billing\n\nimport \"testing\"\n\nfunc TestInvoice(t *testing.T) {}\n"}`}, nil
+}
+
+func TestGeneratorFor_RepairUsesPathModel(t *testing.T) {
+	billingModel := "billing-model"
+	cfg := &config.Config{Overrides: []config.Override{
+		{Path: "internal/billing/", Settings: config.Settings{Model: &billingModel}},
+	}}
+	p := New(Options{Model: "default-model", Config: cfg})
+	provider := &modelRecorder{}
+	gen := testgen.NewGenerator(provider, "default-model", lang.NewGo(), 0, false)
+
+	for _, path := range []string{"/root/proj/internal/billing/invoice.go", "/root/proj/internal/shipping/rate.go"} {
+		fn := model.ChangedFunc{Name: "Invoice", Package: "billing", FilePath: path}
+		test := model.GeneratedTest{TestName: "TestInvoice", TestCode: "package billing"}
+		if _, err := p.generatorFor(gen, "/root/proj", path, lang.NewGo()).Repair(context.Background(), fn, test, model.Mutant{}, "undefined: x"); err != nil {
+			t.Fatalf("Repair: %v", err)
+		}
+	}
+	if len(provider.models) != 2 || provider.models[0] != billingModel || provider.models[1] != "default-model" {
+		t.Errorf("repair models = %q, want the billing override, then the default", provider.models)
+	}
+}
@@ -2,20 +2,23 @@ package runner
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/yiyuanh/snare/internal/lang"
 	"github.com/yiyuanh/snare/pkg/model"
 )
 
-// Executor runs generated tests against parent and new code to detect behavioral changes.
+// Executor runs generated tests against parent, mutated and new code to detect behavioral changes.
 type Executor struct {
 	moduleDir string
 	lang      lang.Language
 	timeout   time.Duration
 	verbose   bool
+	out       io.Writer   // verbose output destination
+	mirrors   *mirrorPool // shared by copies made with the With methods
 }
 
 // NewExecutor creates a new test executor.
@@ -25,13 +28,45 @@ func NewExecutor(moduleDir string, language lang.Language, timeout time.Duration
 		lang:      language,
 		timeout:   timeout,
 		verbose:   verbose,
+		out:       os.Stdout,
+		mirrors:   newMirrorPool(moduleDir),
 	}
 }
 
-// ExecuteCatching runs a test against parent (old) and new code to detect behavioral changes.
+// Close removes the project mirrors the executor's test runs reused.
+func (e *Executor) Close() {
+	e.mirrors.close()
+}
+
+// WithOutput returns a copy of the executor that writes verbose output to w.
+// Concurrent executions each use their own writer so output doesn't interleave.
+func (e *Executor) WithOutput(w io.Writer) *Executor {
+	c := *e
+	c.out = w
+	return &c
+}
+
+// WithLanguage returns a copy of the executor for files in another language,
+// e.g. the Python files of a diff that also touches Go.
+func (e *Executor) WithLanguage(l lang.Language) *Executor {
+	c := *e
+	c.lang = l
+	return &c
+}
+
+// WithTimeout returns a copy of the executor that uses a different per-test
+// timeout, e.g. for files with per-path configuration.
+func (e *Executor) WithTimeout(d time.Duration) *Executor {
+	c := *e
+	c.timeout = d
+	return &c
+}
+
+// ExecuteCatching runs a test against parent (old), mutated parent and new code to detect behavioral changes.
 // Flow:
 //  1. Run test with parent source — must pass (validates test correctness)
-//  2. Run test with new source — if fails, it's a weak catch (behavioral change detected)
+//  2. Run test with the mutant applied to parent source — failure means the test kills the mutant
+//  3. Run test with new source — if fails, it's a weak catch (behavioral change detected)
 func (e *Executor) ExecuteCatching(test model.GeneratedTest, mutant model.Mutant, filePath string, parentSource []byte, newSource []byte) (model.TestResult, error) {
 	result := model.TestResult{
 		Test:   test,
@@ -43,37 +78,11 @@ func (e *Executor) ExecuteCatching(test model.GeneratedTest, mutant model.Mutant
 		return result, fmt.Errorf("computing relative path: %w", err)
 	}
 
-	// Determine where to put the test file (same directory as the target file)
-	// Language-aware naming: test_snare_<name>.py for Python, snare_<name>_test.go for Go
-	var testFileName string
-	if strings.HasSuffix(relPath, ".py") {
-		// LLM-generated Python test names already have test_ prefix; strip it to avoid double prefix
-		name := strings.ToLower(test.TestName)
-		name = strings.TrimPrefix(name, "test_")
-		testFileName = fmt.Sprintf("test_snare_%s.py", name)
-	} else {
-		testFileName = fmt.Sprintf("snare_%s_test.go", strings.ToLower(test.TestName))
-	}
-	testRelPath := filepath.Join(filepath.Dir(relPath), testFileName)
+	// The language decides where the test file goes and how it is named
+	testRelPath := e.lang.TestFilePath(relPath, test.TestName)
 
 	// Step 1: Run test against parent (old) code — must pass
-	td, err := NewTempDir(e.moduleDir)
-	if err != nil {
-		return result, fmt.Errorf("creating temp dir: %w", err)
-	}
-	defer td.Cleanup()
-
-	// Overwrite the source file with parent version
-	if err := td.OverwriteFile(relPath, parentSource); err != nil {
-		return result, fmt.Errorf("writing parent source: %w", err)
-	}
-
-	// Write the test file
-	if err := td.OverwriteFile(testRelPath, []byte(test.TestCode)); err != nil {
-		return result, fmt.Errorf("writing test file: %w", err)
-	}
-
-	passed, output, err := e.lang.RunTest(td.Root, testRelPath, test.TestName, e.timeout)
+	passed, output, err := e.runWithSource(relPath, parentSource, testRelPath, test)
 	if err != nil {
 		return result, fmt.Errorf("running test on parent: %w", err)
 	}
@@ -81,7 +90,7 @@ func (e *Executor) ExecuteCatching(test model.GeneratedTest, mutant model.Mutant
 	result.ParentOutput = output
 
 	if e.verbose {
-		fmt.Printf("  [parent] %s: passed=%v\n", test.TestName, passed)
+		fmt.Fprintf(e.out, "  [parent] %s: passed=%v\n", test.TestName, passed)
 	}
 
 	if !passed {
@@ -90,24 +99,31 @@ func (e *Executor) ExecuteCatching(test model.GeneratedTest, mutant model.Mutant
 		return result, nil
 	}
 
-	// Step 2: Run test against new (diff) code — failure means behavioral change
-	td2, err := NewTempDir(e.moduleDir)
+	// Step 2: Run test against the mutated parent — failure means the test guards the risk
+	mutatedSource, site, err := e.lang.ApplyMutant(relPath, parentSource, mutant)
 	if err != nil {
-		return result, fmt.Errorf("creating temp dir for new code: %w", err)
-	}
-	defer td2.Cleanup()
-
-	// Overwrite the source file with new version
-	if err := td2.OverwriteFile(relPath, newSource); err != nil {
-		return result, fmt.Errorf("writing new source: %w", err)
-	}
+		// Not fatal — the catching verdict still stands without the mutant leg
+		result.MutantError = err.Error()
+		if e.verbose {
+			fmt.Fprintf(e.out, "  [mutant] %s: not applied (%v)\n", test.TestName, err)
+		}
+	} else {
+		passed, output, err = e.runWithSource(relPath, mutatedSource, testRelPath, test)
+		if err != nil {
+			return result, fmt.Errorf("running test on mutant: %w", err)
+		}
+		result.MutantApplied = true
+		result.MutantSite = site.String()
+		result.KillsMutant = !passed
+		result.MutantOutput = output
 
-	// Write the test file
-	if err := td2.OverwriteFile(testRelPath, []byte(test.TestCode)); err != nil {
-		return result, fmt.Errorf("writing test file for new code: %w", err)
+		if e.verbose {
+			fmt.Fprintf(e.out, "  [mutant] %s: passed=%v (kills=%v) at %s\n", test.TestName, passed, result.KillsMutant, result.MutantSite)
+		}
 	}
 
-	passed, output, err = e.lang.RunTest(td2.Root, testRelPath, test.TestName, e.timeout)
+	// Step 3: Run test against new (diff) code — failure means behavioral change
+	passed, output, err = e.runWithSource(relPath, newSource, testRelPath, test)
 	if err != nil {
 		return result, fmt.Errorf("running test on new code: %w", err)
 	}
@@ -116,8 +132,128 @@ func (e *Executor) ExecuteCatching(test model.GeneratedTest, mutant model.Mutant
 	result.IsCatching = result.PassParent && result.FailDiff
 
 	if e.verbose {
-		fmt.Printf("  [new]    %s: passed=%v (catching=%v)\n", test.TestName, passed, result.IsCatching)
+		fmt.Fprintf(e.out, "  [new]    %s: passed=%v (catching=%v)\n", test.TestName, passed, result.IsCatching)
+	}
+
+	return result, nil
+}
+
+// runWithSource runs a test with the file at relPath replaced by source and
+// the test file written next to it, or, for languages with inline tests,
+// embedded in source. Languages that support overlays run it in the module
+// itself; others in a mirror of the project (see mirror).
+func (e *Executor) runWithSource(relPath string, source []byte, testRelPath string, test model.GeneratedTest) (bool, string, error) {
+	if ov, ok := e.lang.(lang.OverlayRunner); ok {
+		overlay := map[string][]byte{relPath: source}
+		if inline, ok := e.lang.(lang.InlineTests); ok {
+			overlay[relPath] = inline.EmbedTest(source, []byte(test.TestCode))
+		} else {
+			overlay[testRelPath] = []byte(test.TestCode)
+		}
+		return ov.RunTestOverlay(e.moduleDir, overlay, testRelPath, test.TestName, e.timeout)
+	}
+
+	td, release, err := e.mirror()
+	if err != nil {
+		return false, "", fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer release(relPath, testRelPath)
+
+	inline, isInline := e.lang.(lang.InlineTests)
+	if isInline {
+		source = inline.EmbedTest(source, []byte(test.TestCode))
+	}
+	if err := td.OverwriteFile(relPath, source); err != nil {
+		return false, "", fmt.Errorf("writing source: %w", err)
+	}
+	if !isInline {
+		if err := td.OverwriteFile(testRelPath, []byte(test.TestCode)); err != nil {
+			return false, "", fmt.Errorf("writing test file: %w", err)
+		}
+	}
+
+	return e.lang.RunTest(td.Root, testRelPath, test.TestName, e.timeout)
+}
+
+// mirror returns a mirror of the project to run a test in, and a function
+// that releases it once the run is over, undoing changes to the given paths.
+// Languages with incremental builds (see lang.IncrementalBuild) borrow a
+// mirror that later runs reuse; others get a fresh one that release removes.
+func (e *Executor) mirror() (*TempDir, func(relPaths ...string), error) {
+	if _, ok := e.lang.(lang.IncrementalBuild); ok {
+		td, err := e.mirrors.get()
+		if err != nil {
+			return nil, nil, err
+		}
+		return td, func(relPaths ...string) { e.mirrors.put(td, relPaths...) }, nil
+	}
+	td, err := NewTempDir(e.moduleDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	return td, func(...string) { td.Cleanup() }, nil
+}
+
+// RunSuiteBaseline runs the project's own tests with source in place at filePath.
+// The suite must pass here for mutant kills to be meaningful.
+func (e *Executor) RunSuiteBaseline(filePath string, source []byte) (bool, string, error) {
+	relPath, err := filepath.Rel(e.moduleDir, filePath)
+	if err != nil {
+		return false, "", fmt.Errorf("computing relative path: %w", err)
+	}
+	return e.runSuiteWithSource(relPath, source)
+}
+
+// ExecuteSuite applies a mutant to source and runs the project's own tests.
+// The mutant is killed if the suite fails.
+func (e *Executor) ExecuteSuite(mutant model.Mutant, filePath string, source []byte) (model.SuiteResult, error) {
+	result := model.SuiteResult{Mutant: mutant}
+
+	relPath, err := filepath.Rel(e.moduleDir, filePath)
+	if err != nil {
+		return result, fmt.Errorf("computing relative path: %w", err)
+	}
+
+	mutatedSource, site, err := e.lang.ApplyMutant(relPath, source, mutant)
+	if err != nil {
+		result.Error = err.Error()
+		if e.verbose {
+			fmt.Fprintf(e.out, "  [suite] %s: not applied (%v)\n", mutant.ID, err)
+		}
+		return result, nil
+	}
+
+	passed, output, err := e.runSuiteWithSource(relPath, mutatedSource)
+	if err != nil {
+		return result, fmt.Errorf("running suite on mutant: %w", err)
+	}
+	result.Site = site.String()
+	result.Killed = !passed
+	result.Output = output
+
+	if e.verbose {
+		fmt.Fprintf(e.out, "  [suite] %s [%s]: killed=%v at %s\n", mutant.ID, mutant.FuncName, result.Killed, result.Site)
 	}
 
 	return result, nil
 }
+
+// runSuiteWithSource runs the project's tests with the file at relPath
+// replaced by source, in the module itself or a mirror (see runWithSource).
+func (e *Executor) runSuiteWithSource(relPath string, source []byte) (bool, string, error) {
+	if ov, ok := e.lang.(lang.OverlayRunner); ok {
+		return ov.RunSuiteOverlay(e.moduleDir, map[string][]byte{relPath: source}, relPath, e.timeout)
+	}
+
+	td, release, err := e.mirror()
+	if err != nil {
+		return false, "", fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer release(relPath)
+
+	if err := td.OverwriteFile(relPath, source); err != nil {
+		return false, "", fmt.Errorf("writing source: %w", err)
+	}
+
+	return e.lang.RunSuite(td.Root, relPath, e.timeout)
+}
@@ -0,0 +1,313 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yiyuanh/snare/internal/lang"
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+// fakeLang passes a test unless the source file under test contains "bug".
+type fakeLang struct {
+	srcRel string
+}
+
+func (f *fakeLang) Name() string             { return "fake" }
+func (f *fakeLang) FileExtensions() []string { return []string{".go"} }
+func (f *fakeLang) IsTestFile(string) bool   { return false }
+func (f *fakeLang) RootMarkers() []string    { return nil }
+func (f *fakeLang) Prompt() lang.PromptStyle { return lang.PromptStyle{} }
+func (f *fakeLang) TestFilePath(sourceFile, testName string) string {
+	return filepath.Join(filepath.Dir(sourceFile), "snare_"+testName+"_test.go")
+}
+func (f *fakeLang) IdentifyChangedFuncs(string, []byte, []model.Hunk) ([]model.ChangedFunc, error) {
+	return nil, nil
+}
+func (f *fakeLang) ApplyMutant(filePath string, src []byte, m model.Mutant) ([]byte, lang.Site, error) {
+	out := strings.Replace(string(src), m.Original, m.Mutated, 1)
+	if out == string(src) {
+		return nil, lang.Site{}, fmt.Errorf("original snippet not found in source")
+	}
+	return []byte(out), lang.Site{Line: 1, Match: lang.MatchExact}, nil
+}
+func (f *fakeLang) RunTest(dir, testFile, testFunc string, timeout time.Duration) (bool, string, error) {
+	src, err := os.ReadFile(filepath.Join(dir, f.srcRel))
+	if err != nil {
+		return false, "", err
+	}
+	if strings.Contains(string(src), "bug") {
+		return false, "FAIL", nil
+	}
+	return true, "ok", nil
+}
+func (f *fakeLang) RunSuite(dir, sourceFile string, timeout time.Duration) (bool, string, error) {
+	return f.RunTest(dir, "", "", timeout)
+}
+func (f *fakeLang) ValidateTestSyntax([]byte) error { return nil }
+
+func TestExecuteCatching_MutantLeg(t *testing.T) {
+	moduleDir := t.TempDir()
+	srcRel := filepath.Join("pkg", "file.go")
+	if err := os.MkdirAll(filepath.Join(moduleDir, "pkg"), 0o755); err != nil {
+		t.Fatalf("creating pkg dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(moduleDir, srcRel), []byte("fine"), 0o644); err != nil {
+		t.Fatalf("writing source: %v", err)
+	}
+
+	e := NewExecutor(moduleDir, &fakeLang{srcRel: srcRel}, time.Second, false)
+	defer e.Close()
+	test := model.GeneratedTest{TestName: "TestFoo", TestCode: "package pkg"}
+	filePath := filepath.Join(moduleDir, srcRel)
+
+	killed, err := e.ExecuteCatching(test, model.Mutant{Original: "x", Mutated: "bug"}, filePath, []byte("x"), []byte("bug"))
+	if err != nil {
+		t.Fatalf("ExecuteCatching: %v", err)
+	}
+	if !killed.MutantApplied || !killed.KillsMutant {
+		t.Errorf("MutantApplied=%v KillsMutant=%v, want both true", killed.MutantApplied, killed.KillsMutant)
+	}
+	if killed.MutantSite != "line 1" {
+		t.Errorf("MutantSite = %q, want the site ApplyMutant reported", killed.MutantSite)
+	}
+	if !killed.IsCatching {
+		t.Error("IsCatching should be true when test fails on new code")
+	}
+
+	missing, err := e.ExecuteCatching(test, model.Mutant{Original: "absent", Mutated: "bug"}, filePath, []byte("x"), []byte("x"))
+	if err != nil {
+		t.Fatalf("ExecuteCatching: %v", err)
+	}
+	if missing.MutantApplied || missing.MutantError == "" {
+		t.Errorf("MutantApplied=%v MutantError=%q, want mutant leg to be skipped with an error", missing.MutantApplied, missing.MutantError)
+	}
+	if missing.IsCatching {
+		t.Error("IsCatching should be false when test passes on new code")
+	}
+}
+
+func TestExecuteSuite(t *testing.T) {
+	moduleDir := t.TempDir()
+	srcRel := "file.go"
+	filePath := filepath.Join(moduleDir, srcRel)
+	if err := os.WriteFile(filePath, []byte("fine"), 0o644); err != nil {
+		t.Fatalf("writing source: %v", err)
+	}
+
+	e := NewExecutor(moduleDir, &fakeLang{srcRel: srcRel}, time.Second, false)
+	defer e.Close()
+
+	killed, err := e.ExecuteSuite(model.Mutant{ID: "m1", Original: "fine", Mutated: "bug"}, filePath, []byte("fine"))
+	if err != nil {
+		t.Fatalf("ExecuteSuite: %v", err)
+	}
+	if !killed.Killed || killed.Error != "" {
+		t.Errorf("Killed=%v Error=%q, want killed without error", killed.Killed, killed.Error)
+	}
+
+	survived, err := e.ExecuteSuite(model.Mutant{ID: "m2", Original: "fine", Mutated: "okay"}, filePath, []byte("fine"))
+	if err != nil {
+		t.Fatalf("ExecuteSuite: %v", err)
+	}
+	if survived.Killed {
+		t.Error("Killed should be false when the suite still passes")
+	}
+
+	missing, err := e.ExecuteSuite(model.Mutant{ID: "m3", Original: "absent", Mutated: "bug"}, filePath, []byte("fine"))
+	if err != nil {
+		t.Fatalf("ExecuteSuite: %v", err)
+	}
+	if missing.Error == "" {
+		t.Error("Error should be set when the mutant cannot be applied")
+	}
+}
+
+// inlineLang embeds tests in the source file, like Rust; a test passes if it
+// was embedded.
+type inlineLang struct {
+	fakeLang
+}
+
+func (f *inlineLang) TestFilePath(sourceFile, testName string) string { return sourceFile }
+func (f *inlineLang) EmbedTest(source, testCode []byte) []byte {
+	return append(append(source, '\n'), testCode...)
+}
+func (f *inlineLang) RunTest(dir, testFile, testFunc string, timeout time.Duration) (bool, string, error) {
+	src, err := os.ReadFile(filepath.Join(dir, testFile))
+	if err != nil {
+		return false, "", err
+	}
+	return string(src) == "fine\n#[test] fn t() {}", string(src), nil
+}
+
+func TestExecuteCatching_InlineTests(t *testing.T) {
+	moduleDir := t.TempDir()
+	srcRel := "lib.rs"
+	filePath := filepath.Join(moduleDir, srcRel)
+	if err := os.WriteFile(filePath, []byte("fine"), 0o644); err != nil {
+		t.Fatalf("writing source: %v", err)
+	}
+
+	e := NewExecutor(moduleDir, &inlineLang{fakeLang{srcRel: srcRel}}, time.Second, false)
+	defer e.Close()
+	test := model.GeneratedTest{TestName: "t", TestCode: "#[test] fn t() {}"}
+	result, err := e.ExecuteCatching(test, model.Mutant{Original: "fine", Mutated: "bug"}, filePath, []byte("fine"), []byte("fine"))
+	if err != nil {
+		t.Fatalf("ExecuteCatching: %v", err)
+	}
+	if !result.PassParent {
+		t.Errorf("test was not embedded in the source: %s", result.ParentOutput)
+	}
+	if got, _ := os.ReadFile(filePath); string(got) != "fine" {
+		t.Errorf("original source modified: %q", got)
+	}
+}
+
+// overlayLang runs tests on overlays; a test passes unless the overlaid
+// source contains "bug".
+type overlayLang struct {
+	fakeLang
+	overlays []map[string][]byte
+}
+
+func (f *overlayLang) RunTestOverlay(root string, overlay map[string][]byte, testFile, testFunc string, timeout time.Duration) (bool, string, error) {
+	f.overlays = append(f.overlays, overlay)
+	return !strings.Contains(string(overlay[f.srcRel]), "bug"), "", nil
+}
+func (f *overlayLang) RunSuiteOverlay(root string, overlay map[string][]byte, sourceFile string, timeout time.Duration) (bool, string, error) {
+	return f.RunTestOverlay(root, overlay, "", "", timeout)
+}
+
+func TestExecuteCatching_Overlay(t *testing.T) {
+	moduleDir := t.TempDir()
+	srcRel := "file.go"
+	l := &overlayLang{fakeLang: fakeLang{srcRel: srcRel}}
+	e := NewExecutor(moduleDir, l, time.Second, false)
+	test := model.GeneratedTest{TestName: "TestFoo", TestCode: "package pkg"}
+
+	result, err := e.ExecuteCatching(test, model.Mutant{Original: "x", Mutated: "bug"}, filepath.Join(moduleDir, srcRel), []byte("x"), []byte("bug"))
+	if err != nil {
+		t.Fatalf("ExecuteCatching: %v", err)
+	}
+	if !result.PassParent || !result.KillsMutant || !result.IsCatching {
+		t.Errorf("PassParent=%v KillsMutant=%v IsCatching=%v, want all true", result.PassParent, result.KillsMutant, result.IsCatching)
+	}
+	if len(l.overlays) != 3 {
+		t.Fatalf("got %d overlay runs, want 3", len(l.overlays))
+	}
+	if got := string(l.overlays[0]["snare_TestFoo_test.go"]); got != "package pkg" {
+		t.Errorf("test file in overlay = %q", got)
+	}
+}
+
+// dirLang records the directories tests run in, and what they saw there. Its
+// builds are incremental, so it gets reused mirrors.
+type dirLang struct {
+	fakeLang
+	dirs    []string
+	sources []string
+}
+
+func (f *dirLang) IncrementalBuild() {}
+
+func (f *dirLang) RunTest(dir, testFile, testFunc string, timeout time.Duration) (bool, string, error) {
+	f.dirs = append(f.dirs, dir)
+	src, err := os.ReadFile(filepath.Join(dir, f.srcRel))
+	if err != nil {
+		return false, "", err
+	}
+	f.sources = append(f.sources, string(src))
+	return f.fakeLang.RunTest(dir, testFile, testFunc, timeout)
+}
+
+func TestExecuteCatching_ReusesMirror(t *testing.T) {
+	moduleDir := t.TempDir()
+	srcRel := filepath.Join("pkg", "file.go")
+	if err := os.MkdirAll(filepath.Join(moduleDir, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(moduleDir, srcRel), []byte("fine"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l := &dirLang{fakeLang: fakeLang{srcRel: srcRel}}
+	e := NewExecutor(moduleDir, l, time.Second, false)
+	test := model.GeneratedTest{TestName: "TestFoo", TestCode: "package pkg"}
+
+	for range 2 {
+		if _, err := e.ExecuteCatching(test, model.Mutant{Original: "fine", Mutated: "bug"}, filepath.Join(moduleDir, srcRel), []byte("fine"), []byte("new")); err != nil {
+			t.Fatalf("ExecuteCatching: %v", err)
+		}
+	}
+	if len(l.dirs) != 6 {
+		t.Fatalf("got %d runs, want 6", len(l.dirs))
+	}
+	for _, dir := range l.dirs[1:] {
+		if dir != l.dirs[0] {
+			t.Fatalf("runs used dirs %q, want one reused mirror", l.dirs)
+		}
+	}
+	if got := strings.Join(l.sources, ","); got != "fine,bug,new,fine,bug,new" {
+		t.Errorf("sources seen = %s", got)
+	}
+
+	mirror := l.dirs[0]
+	if got, _ := os.ReadFile(filepath.Join(mirror, srcRel)); string(got) != "fine" {
+		t.Errorf("mirror source not restored: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(mirror, "pkg", "snare_TestFoo_test.go")); !os.IsNotExist(err) {
+		t.Error("test file left in the mirror")
+	}
+	e.Close()
+	if _, err := os.Stat(mirror); !os.IsNotExist(err) {
+		t.Error("mirror not removed by Close")
+	}
+}
+
+// pythonLang runs a test by importing the source under test with python3,
+// which caches bytecode that only records the source's mtime in seconds and
+// its size.
+type pythonLang struct {
+	fakeLang
+}
+
+func (f *pythonLang) RunTest(dir, testFile, testFunc string, timeout time.Duration) (bool, string, error) {
+	cmd := exec.Command("python3", "-c", "import order, sys; sys.exit(0 if order.lt(1, 2) else 1)")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "PYTHONDONTWRITEBYTECODE=")
+	out, err := cmd.CombinedOutput()
+	if _, ok := err.(*exec.ExitError); ok {
+		return false, string(out), nil
+	}
+	return err == nil, string(out), err
+}
+
+func TestExecuteCatching_FreshMirrorPerRun(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+	moduleDir := t.TempDir()
+	srcRel := "order.py"
+	parent := []byte("def lt(a, b):\n    return a < b\n")
+	if err := os.WriteFile(filepath.Join(moduleDir, srcRel), parent, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e := NewExecutor(moduleDir, &pythonLang{fakeLang{srcRel: srcRel}}, time.Minute, false)
+	defer e.Close()
+
+	// The mutant has the same size as the parent and is written within the
+	// same second, so a reused mirror would run the parent's bytecode
+	test := model.GeneratedTest{TestName: "test_lt", TestCode: ""}
+	result, err := e.ExecuteCatching(test, model.Mutant{Original: "a < b", Mutated: "a > b"}, filepath.Join(moduleDir, srcRel), parent, parent)
+	if err != nil {
+		t.Fatalf("ExecuteCatching: %v", err)
+	}
+	if !result.PassParent || !result.MutantApplied || !result.KillsMutant {
+		t.Errorf("PassParent=%v MutantApplied=%v KillsMutant=%v, want all true\n%s", result.PassParent, result.MutantApplied, result.KillsMutant, result.MutantOutput)
+	}
+}
@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"sync"
+)
+
+// mirrorPool keeps temp dir mirrors of a project for reuse by languages with
+// incremental builds (see lang.IncrementalBuild). Each test run borrows one
+// and gives it back with its changes undone, so a build starts from the
+// outputs the last build there left behind (Maven's target/, Gradle's
+// build/) and only redoes what the run changed, instead of rebuilding the
+// module and everything it depends on from scratch. Mirrors are only copied
+// once, too. Concurrent runs each borrow their own mirror.
+type mirrorPool struct {
+	moduleDir string
+
+	mu   sync.Mutex
+	idle []*TempDir
+	all  []*TempDir
+}
+
+func newMirrorPool(moduleDir string) *mirrorPool {
+	return &mirrorPool{moduleDir: moduleDir}
+}
+
+// get borrows an idle mirror, or creates one.
+func (p *mirrorPool) get() (*TempDir, error) {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		td := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return td, nil
+	}
+	p.mu.Unlock()
+
+	td, err := NewTempDir(p.moduleDir)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.all = append(p.all, td)
+	p.mu.Unlock()
+	return td, nil
+}
+
+// put gives a mirror back after restoring the files at relPaths, which the
+// run changed. A mirror that can't be restored is removed instead.
+func (p *mirrorPool) put(td *TempDir, relPaths ...string) {
+	err := td.Restore(relPaths...)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		td.Cleanup()
+		for i, other := range p.all {
+			if other == td {
+				p.all = append(p.all[:i], p.all[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+	p.idle = append(p.idle, td)
+}
+
+// close removes every mirror.
+func (p *mirrorPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, td := range p.all {
+		td.Cleanup()
+	}
+	p.idle, p.all = nil, nil
+}
@@ -34,17 +34,66 @@ func NewTempDir(moduleDir string) (*TempDir, error) {
 	return td, nil
 }
 
-// symlinkContents creates symlinks for all top-level entries in the module dir.
+// buildOutputs maps a root marker to the build output directories it implies.
+// These are left out of the mirror: a build in the temp dir would otherwise
+// write into the project's own outputs (and contend for their locks).
+var buildOutputs = map[string][]string{
+	"Cargo.toml":       {"target"},
+	"pom.xml":          {"target"},
+	"build.gradle":     {"build", ".gradle"},
+	"build.gradle.kts": {"build", ".gradle"},
+}
+
+// moduleBuilds are root markers of builds that also write outputs inside
+// each module's directory (Maven's and Gradle's multi-module builds). Their
+// directories are copied (see copyDir) rather than symlinked, so those
+// outputs land in the mirror.
+var moduleBuilds = map[string]bool{
+	"pom.xml":             true,
+	"build.gradle":        true,
+	"build.gradle.kts":    true,
+	"settings.gradle":     true,
+	"settings.gradle.kts": true,
+}
+
+// outputDirs returns the build output directories implied by entries.
+func outputDirs(entries []os.DirEntry) map[string]bool {
+	skip := make(map[string]bool)
+	for _, entry := range entries {
+		for _, dir := range buildOutputs[entry.Name()] {
+			skip[dir] = true
+		}
+	}
+	return skip
+}
+
+// symlinkContents creates symlinks for all top-level entries in the module
+// dir, except build outputs.
 func (td *TempDir) symlinkContents() error {
 	entries, err := os.ReadDir(td.ModuleDir)
 	if err != nil {
 		return fmt.Errorf("reading module dir: %w", err)
 	}
 
+	skip := outputDirs(entries)
+	copyDirs := false
+	for _, entry := range entries {
+		copyDirs = copyDirs || moduleBuilds[entry.Name()]
+	}
+
 	for _, entry := range entries {
 		name := entry.Name()
+		if skip[name] && entry.IsDir() {
+			continue
+		}
 		src := filepath.Join(td.ModuleDir, name)
 		dst := filepath.Join(td.Root, name)
+		if copyDirs && entry.IsDir() && !strings.HasPrefix(name, ".") {
+			if err := copyDir(src, dst); err != nil {
+				return fmt.Errorf("copying %s: %w", name, err)
+			}
+			continue
+		}
 		if err := os.Symlink(src, dst); err != nil {
 			return fmt.Errorf("symlinking %s: %w", name, err)
 		}
@@ -117,12 +166,38 @@ func (td *TempDir) OverwriteFile(relPath string, content []byte) error {
 	return os.WriteFile(target, content, 0o644)
 }
 
+// Restore undoes OverwriteFile for each path relative to the module root:
+// files the module has get its content back, others are removed. Restored
+// files are written rather than symlinked again, so they are newer than any
+// build output made from the overwritten content, and mtime-based builds
+// like Maven's recompile them.
+func (td *TempDir) Restore(relPaths ...string) error {
+	for _, relPath := range relPaths {
+		target := filepath.Join(td.Root, relPath)
+		content, err := os.ReadFile(filepath.Join(td.ModuleDir, relPath))
+		if os.IsNotExist(err) {
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("removing %s: %w", relPath, err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("reading original %s: %w", relPath, err)
+		}
+		if err := td.OverwriteFile(relPath, content); err != nil {
+			return fmt.Errorf("restoring %s: %w", relPath, err)
+		}
+	}
+	return nil
+}
+
 // Cleanup removes the temporary directory.
 func (td *TempDir) Cleanup() {
 	os.RemoveAll(td.Root)
 }
 
 // copyDir recursively copies a directory, symlinking files for efficiency.
+// Build outputs of nested modules are left out, as at the top level.
 func copyDir(src, dst string) error {
 	if err := os.MkdirAll(dst, 0o755); err != nil {
 		return err
@@ -133,11 +208,15 @@ func copyDir(src, dst string) error {
 		return err
 	}
 
+	skip := outputDirs(entries)
 	for _, entry := range entries {
 		srcPath := filepath.Join(src, entry.Name())
 		dstPath := filepath.Join(dst, entry.Name())
 
 		if entry.IsDir() {
+			if skip[entry.Name()] {
+				continue
+			}
 			if err := copyDir(srcPath, dstPath); err != nil {
 				return err
 			}
@@ -56,3 +56,60 @@ func TestNewTempDir_and_OverwriteFile(t *testing.T) {
 		t.Error("temp dir was not cleaned up")
 	}
 }
+
+func TestNewTempDir_SkipsBuildOutputs(t *testing.T) {
+	moduleDir := t.TempDir()
+	for _, dir := range []string{"src", "target"} {
+		if err := os.Mkdir(filepath.Join(moduleDir, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(moduleDir, "Cargo.toml"), []byte("[package]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	td, err := NewTempDir(moduleDir)
+	if err != nil {
+		t.Fatalf("NewTempDir: %v", err)
+	}
+	defer td.Cleanup()
+
+	if _, err := os.Lstat(filepath.Join(td.Root, "target")); !os.IsNotExist(err) {
+		t.Error("target/ of a cargo project was mirrored")
+	}
+	if _, err := os.Lstat(filepath.Join(td.Root, "src")); err != nil {
+		t.Errorf("src/ not mirrored: %v", err)
+	}
+}
+
+func TestNewTempDir_CopiesMavenModules(t *testing.T) {
+	moduleDir := t.TempDir()
+	for _, dir := range []string{"core/src", "core/target/classes"} {
+		if err := os.MkdirAll(filepath.Join(moduleDir, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"pom.xml", "core/pom.xml", "core/src/Cart.java"} {
+		if err := os.WriteFile(filepath.Join(moduleDir, file), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	td, err := NewTempDir(moduleDir)
+	if err != nil {
+		t.Fatalf("NewTempDir: %v", err)
+	}
+	defer td.Cleanup()
+
+	// The module is a real directory, so its build writes target/ in the mirror
+	info, err := os.Lstat(filepath.Join(td.Root, "core"))
+	if err != nil || !info.IsDir() {
+		t.Fatalf("core/ is not a copied directory: %v, %v", info, err)
+	}
+	if _, err := os.Lstat(filepath.Join(td.Root, "core", "target")); !os.IsNotExist(err) {
+		t.Error("core/target/ of a maven module was mirrored")
+	}
+	if _, err := os.Stat(filepath.Join(td.Root, "core", "src", "Cart.java")); err != nil {
+		t.Errorf("core/src/Cart.java not mirrored: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package testgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yiyuanh/snare/internal/lang"
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+// PromptVersion identifies the generation prompt. Bump it whenever
+// BuildCatchingPrompt or the response format changes so that cached results
+// produced by an older prompt are not reused.
+const PromptVersion = 5
+
+// Cache stores generation results on disk, addressed by a hash of everything
+// that determines them, so unchanged functions are not regenerated.
+type Cache struct {
+	dir string
+}
+
+// NewCache opens (creating if needed) a generation cache in dir.
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// DefaultCacheDir returns the per-user cache location, e.g. ~/.cache/snare/generate.
+func DefaultCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "snare", "generate"), nil
+}
+
+// CacheKey returns the content address of a function's generation result.
+// It covers everything the prompt is built from (the file path and package,
+// the parent and new code, the diff context, the file's imports and type
+// definitions, the telemetry context and the commit message), the language's
+// prompt style (which names the test framework, e.g. the project's JavaScript
+// runner), the model and the prompt version.
+func CacheKey(fn model.ChangedFunc, style lang.PromptStyle, modelID, commitMessage string) string {
+	h := sha256.New()
+	// Marshaling a struct of strings can't fail
+	styleJSON, _ := json.Marshal(style)
+	parts := []string{
+		fmt.Sprintf("v%d", PromptVersion),
+		modelID,
+		fn.FilePath,
+		fn.Package,
+		fn.ParentSignature,
+		fn.ParentBody,
+		fn.Signature,
+		fn.Body,
+		fn.DiffContext,
+		fn.TelemetryContext,
+		commitMessage,
+		string(styleJSON),
+	}
+	// Counted, so imports can't shift into type definitions
+	parts = append(parts, fmt.Sprint(len(fn.Imports)))
+	parts = append(parts, fn.Imports...)
+	parts = append(parts, fmt.Sprint(len(fn.TypeDefs)))
+	parts = append(parts, fn.TypeDefs...)
+	for _, part := range parts {
+		// Length-prefix each part so boundaries can't shift between fields
+		fmt.Fprintf(h, "%d:%s\n", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the cached result for key, if any. Unreadable entries count as misses.
+func (c *Cache) Get(key string) (*model.CatchingLLMResponse, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var resp model.CatchingLLMResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, false
+	}
+	return &resp, true
+}
+
+// Put stores a result under key.
+func (c *Cache) Put(key string, resp *model.CatchingLLMResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("encoding cache entry: %w", err)
+	}
+	// Write-then-rename so concurrent workers never read a partial entry
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
@@ -2,73 +2,117 @@ package testgen
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"strings"
+	"sync/atomic"
 
-	"github.com/anthropics/anthropic-sdk-go"
-	bedrockpkg "github.com/anthropics/anthropic-sdk-go/bedrock"
 	"github.com/yiyuanh/snare/internal/lang"
+	"github.com/yiyuanh/snare/internal/llm"
 	"github.com/yiyuanh/snare/pkg/model"
 )
 
-// Generator uses Claude to generate mutants and tests.
+// Generator uses an LLM to generate mutants and tests.
 type Generator struct {
-	client   *anthropic.Client
+	provider llm.Provider
 	model    string
 	lang     lang.Language
 	maxTests int
 	verbose  bool
+	cache    *Cache
+
+	typeCheck *typeCheck // set by WithTypeCheck
+
+	cacheHits *atomic.Int64 // shared by copies made with the With methods
 }
 
 // NewGenerator creates a new LLM-based test generator.
-// When bedrock is true, the client uses AWS credentials via the default config chain
-// instead of ANTHROPIC_API_KEY.
-func NewGenerator(ctx context.Context, modelID string, language lang.Language, maxTests int, verbose bool, bedrock bool) *Generator {
-	var client anthropic.Client
-	if bedrock {
-		client = anthropic.NewClient(bedrockpkg.WithLoadDefaultConfig(ctx))
-	} else {
-		client = anthropic.NewClient()
-	}
+func NewGenerator(provider llm.Provider, modelID string, language lang.Language, maxTests int, verbose bool) *Generator {
 	return &Generator{
-		client:   &client,
-		model:    modelID,
-		lang:     language,
-		maxTests: maxTests,
-		verbose:  verbose,
+		provider:  provider,
+		model:     modelID,
+		lang:      language,
+		maxTests:  maxTests,
+		verbose:   verbose,
+		cacheHits: new(atomic.Int64),
 	}
 }
 
-// NewGeneratorWithClient creates a generator with a pre-configured client.
-// Used by the LLM judge and other components that share a client.
-func NewGeneratorWithClient(client *anthropic.Client, modelID string, language lang.Language, maxTests int, verbose bool) *Generator {
-	return &Generator{
-		client:   client,
-		model:    modelID,
-		lang:     language,
-		maxTests: maxTests,
-		verbose:  verbose,
-	}
+// WithCache returns a generator that reuses and stores results in c.
+func (g *Generator) WithCache(c *Cache) *Generator {
+	cp := *g
+	cp.cache = c
+	return &cp
 }
 
-// Client returns the underlying anthropic client for reuse by other components.
-func (g *Generator) Client() *anthropic.Client {
-	return g.client
+// WithSettings returns a generator that uses a different model and test
+// limit, e.g. for files with per-path configuration.
+func (g *Generator) WithSettings(modelID string, maxTests int) *Generator {
+	cp := *g
+	cp.model = modelID
+	cp.maxTests = maxTests
+	return &cp
+}
+
+// WithLanguage returns a generator that validates tests as another language,
+// for diffs that touch several. It drops any type checking set up by
+// WithTypeCheck, which belongs to the previous language.
+func (g *Generator) WithLanguage(l lang.Language) *Generator {
+	cp := *g
+	cp.lang = l
+	cp.typeCheck = nil
+	return &cp
+}
+
+// CacheHits returns how many Generate calls were answered from the cache.
+func (g *Generator) CacheHits() int {
+	return int(g.cacheHits.Load())
+}
+
+// Provider returns the underlying LLM provider for reuse by other components.
+func (g *Generator) Provider() llm.Provider {
+	return g.provider
 }
 
 // Generate produces intent, risks, mutants and tests for a changed function.
-// It makes a single API call per function and includes one retry on parse failure.
+// It makes a single tool-use call per function; an answer that fails schema or
+// syntax validation gets one targeted repair request.
+// With a cache attached, a function whose code and diff are unchanged since an
+// earlier run reuses that run's result without calling the model, provided
+// the result still type-checks (see WithTypeCheck).
 func (g *Generator) Generate(ctx context.Context, fn model.ChangedFunc, commitMessage ...string) (string, []model.Risk, []model.Mutant, []model.GeneratedTest, error) {
-	prompt := BuildCatchingPrompt(fn, commitMessage...)
+	var intent string
+	var risks []model.Risk
+	var mutants []model.Mutant
+	var tests []model.GeneratedTest
+
+	var key string
+	cached := false
+	if g.cache != nil {
+		var msg string
+		if len(commitMessage) > 0 {
+			msg = commitMessage[0]
+		}
+		key = CacheKey(fn, g.lang.Prompt(), g.model, msg)
+		if resp, ok := g.cache.Get(key); ok && g.compiles(resp, fn) {
+			g.cacheHits.Add(1)
+			cached = true
+			intent, risks, mutants, tests = resp.Intent, resp.Risks, resp.Mutants, resp.Tests
+			setFuncName(mutants, tests, fn.ID())
+		}
+	}
 
-	intent, risks, mutants, tests, err := g.callAndParse(ctx, prompt, fn)
-	if err != nil {
-		// One retry with error context
-		retryPrompt := prompt + "\n\n## Previous attempt failed\nError: " + err.Error() + "\nPlease fix the issue and try again. Remember to output ONLY valid JSON."
-		intent, risks, mutants, tests, err = g.callAndParse(ctx, retryPrompt, fn)
+	if !cached {
+		ctx = llm.WithLabel(ctx, llm.StageGenerate, fn.ID())
+		prefix, prompt := buildCatchingPromptParts(fn, g.lang.Prompt(), commitMessage...)
+
+		var err error
+		intent, risks, mutants, tests, err = g.callAndParse(ctx, prefix, prompt, fn)
 		if err != nil {
-			return "", nil, nil, nil, fmt.Errorf("generation failed after retry: %w", err)
+			return "", nil, nil, nil, fmt.Errorf("generation failed: %w", err)
+		}
+
+		if g.cache != nil {
+			// Best effort: a failed write only costs a regeneration next time
+			_ = g.cache.Put(key, &model.CatchingLLMResponse{Intent: intent, Risks: risks, Mutants: mutants, Tests: tests})
 		}
 	}
 
@@ -92,74 +136,105 @@ func (g *Generator) Generate(ctx context.Context, fn model.ChangedFunc, commitMe
 	return intent, risks, mutants, tests, nil
 }
 
-func (g *Generator) callAndParse(ctx context.Context, prompt string, fn model.ChangedFunc) (string, []model.Risk, []model.Mutant, []model.GeneratedTest, error) {
-	resp, err := g.client.Messages.New(ctx, anthropic.MessageNewParams{
-		Model:     anthropic.Model(g.model),
-		MaxTokens: 4096,
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
+// catchingTool is the tool the model calls to submit its answer; its schema
+// mirrors model.CatchingLLMResponse.
+var catchingTool = &llm.Tool{
+	Name:        "submit_catching_tests",
+	Description: "Submit the inferred intent, risks, risk mutants and catching tests for the changed function.",
+	Schema: &llm.Schema{
+		Type:     "object",
+		Required: []string{"intent", "risks", "mutants", "tests"},
+		Properties: map[string]*llm.Schema{
+			"intent": {Type: "string", MinLength: 1, Description: "What the code change is trying to accomplish"},
+			"risks": {Type: "array", MinItems: 1, Items: &llm.Schema{
+				Type:     "object",
+				Required: []string{"id", "description"},
+				Properties: map[string]*llm.Schema{
+					"id":          {Type: "string", MinLength: 1},
+					"description": {Type: "string", MinLength: 1},
+				},
+			}},
+			"mutants": {Type: "array", MinItems: 1, Items: &llm.Schema{
+				Type:     "object",
+				Required: []string{"id", "risk_id", "description", "original", "mutated"},
+				Properties: map[string]*llm.Schema{
+					"id":          {Type: "string", MinLength: 1},
+					"risk_id":     {Type: "string", MinLength: 1},
+					"description": {Type: "string", MinLength: 1},
+					"original":    {Type: "string", MinLength: 1, Description: "Exact substring of the PARENT function body"},
+					"mutated":     {Type: "string", Description: "Buggy replacement for original"},
+				},
+			}},
+			"tests": {Type: "array", MinItems: 1, Items: &llm.Schema{
+				Type:     "object",
+				Required: []string{"id", "mutant_id", "test_name", "test_code"},
+				Properties: map[string]*llm.Schema{
+					"id":        {Type: "string", MinLength: 1},
+					"mutant_id": {Type: "string", MinLength: 1},
+					"test_name": {Type: "string", MinLength: 1},
+					"test_code": {Type: "string", MinLength: 1, Description: "Complete, self-contained test file"},
+				},
+			}},
 		},
-	})
-	if err != nil {
-		return "", nil, nil, nil, fmt.Errorf("Claude API call: %w", err)
-	}
+	},
+}
 
-	// Extract text from response
-	var text string
-	for _, block := range resp.Content {
-		if block.Type == "text" {
-			text = block.Text
-			break
+func (g *Generator) callAndParse(ctx context.Context, prefix, prompt string, fn model.ChangedFunc) (string, []model.Risk, []model.Mutant, []model.GeneratedTest, error) {
+	check := func(resp *model.CatchingLLMResponse) []string {
+		// Only type-check code that parses
+		if problems := g.check(resp); len(problems) > 0 || g.typeCheck == nil {
+			return problems
 		}
+		return g.typeCheck.problems(g.lang, resp, fn.ID())
 	}
-
-	if text == "" {
-		return "", nil, nil, nil, fmt.Errorf("empty response from Claude")
+	llmResp, err := llm.CompleteJSON(ctx, g.provider, llm.Request{
+		Model:     g.model,
+		Prefix:    prefix,
+		Prompt:    prompt,
+		MaxTokens: 4096,
+		Tool:      catchingTool,
+	}, check)
+	if err != nil {
+		return "", nil, nil, nil, err
 	}
 
-	// Strip markdown code fences if present
-	text = stripCodeFences(text)
-
-	// Parse JSON response
-	var llmResp model.CatchingLLMResponse
-	if err := json.Unmarshal([]byte(text), &llmResp); err != nil {
-		return "", nil, nil, nil, fmt.Errorf("parsing LLM response JSON: %w (response: %.500s)", err, text)
-	}
+	// Set the qualified func name on all mutants and tests
+	setFuncName(llmResp.Mutants, llmResp.Tests, fn.ID())
 
-	if len(llmResp.Mutants) == 0 {
-		return "", nil, nil, nil, fmt.Errorf("no mutants generated")
-	}
-	if len(llmResp.Tests) == 0 {
-		return "", nil, nil, nil, fmt.Errorf("no tests generated")
-	}
+	return llmResp.Intent, llmResp.Risks, llmResp.Mutants, llmResp.Tests, nil
+}
 
-	// Set func name on all mutants and tests
-	for i := range llmResp.Mutants {
-		llmResp.Mutants[i].FuncName = fn.Name
-	}
-	for i := range llmResp.Tests {
-		llmResp.Tests[i].FuncName = fn.Name
+// check reports problems the schema can't express: dangling references and
+// test code that doesn't parse.
+func (g *Generator) check(resp *model.CatchingLLMResponse) []string {
+	var problems []string
+	risks := make(map[string]bool)
+	for _, r := range resp.Risks {
+		risks[r.ID] = true
+	}
+	mutants := make(map[string]bool)
+	for i, m := range resp.Mutants {
+		mutants[m.ID] = true
+		if !risks[m.RiskID] {
+			problems = append(problems, fmt.Sprintf("mutants[%d].risk_id: %q is not the id of any risk", i, m.RiskID))
+		}
 	}
-
-	// Validate test syntax
-	for _, t := range llmResp.Tests {
+	for i, t := range resp.Tests {
+		if !mutants[t.MutantID] {
+			problems = append(problems, fmt.Sprintf("tests[%d].mutant_id: %q is not the id of any mutant", i, t.MutantID))
+		}
 		if err := g.lang.ValidateTestSyntax([]byte(t.TestCode)); err != nil {
-			return "", nil, nil, nil, fmt.Errorf("test %s has syntax error: %w\ncode:\n%s", t.TestName, err, t.TestCode)
+			problems = append(problems, fmt.Sprintf("tests[%d].test_code (%s): syntax error: %v", i, t.TestName, err))
 		}
 	}
-
-	return llmResp.Intent, llmResp.Risks, llmResp.Mutants, llmResp.Tests, nil
+	return problems
 }
 
-func stripCodeFences(s string) string {
-	s = strings.TrimSpace(s)
-	if strings.HasPrefix(s, "```json") {
-		s = strings.TrimPrefix(s, "```json")
-	} else if strings.HasPrefix(s, "```") {
-		s = strings.TrimPrefix(s, "```")
+func setFuncName(mutants []model.Mutant, tests []model.GeneratedTest, name string) {
+	for i := range mutants {
+		mutants[i].FuncName = name
 	}
-	if strings.HasSuffix(s, "```") {
-		s = strings.TrimSuffix(s, "```")
+	for i := range tests {
+		tests[i].FuncName = name
 	}
-	return strings.TrimSpace(s)
 }
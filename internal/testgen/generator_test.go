@@ -0,0 +1,262 @@
+package testgen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yiyuanh/snare/internal/lang"
+	"github.com/yiyuanh/snare/internal/llm"
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+// scriptedProvider answers each call with the next canned reply.
+type scriptedProvider struct {
+	replies []string
+	prompts []string
+}
+
+func (s *scriptedProvider) Name() string { return "scripted" }
+
+func (s *scriptedProvider) Complete(ctx context.Context, req llm.Request) (*llm.Response, error) {
+	s.prompts = append(s.prompts, req.Prompt)
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	return &llm.Response{Text: reply}, nil
+}
+
+const validReply = "```json\n" + `{
+  "intent": "Clamp negative values to zero",
+  "risks": [{"id": "r1", "description": "negative input not clamped"}],
+  "mutants": [{"id": "m1", "description": "drop clamp", "original": "if x < 0", "mutated": "if x < -1", "risk_id": "r1"}],
+  "tests": [{"id": "t1", "mutant_id": "m1", "test_name": "TestClamp_Negative", "test_code": "package foo\n\nimport \"testing\"\n\nfunc TestClamp_Negative(t *testing.T) {}\n"}]
+}` + "\n```"
+
+func TestGenerate_UsesProvider(t *testing.T) {
+	provider := &scriptedProvider{replies: []string{validReply}}
+	gen := NewGenerator(provider, "test-model", lang.NewGo(), 0, false)
+
+	fn := model.ChangedFunc{Name: "Clamp", QualifiedName: "Clamp", Package: "foo", Body: "func Clamp(x int) int { if x < 0 { return 0 }; return x }"}
+	intent, risks, mutants, tests, err := gen.Generate(context.Background(), fn)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if intent != "Clamp negative values to zero" || len(risks) != 1 || len(mutants) != 1 || len(tests) != 1 {
+		t.Fatalf("unexpected result: intent=%q risks=%d mutants=%d tests=%d", intent, len(risks), len(mutants), len(tests))
+	}
+	if mutants[0].FuncName != "Clamp" || tests[0].FuncName != "Clamp" {
+		t.Errorf("FuncName not set: mutant=%q test=%q", mutants[0].FuncName, tests[0].FuncName)
+	}
+}
+
+func TestGenerate_RepairsInvalidAnswer(t *testing.T) {
+	missingTests := `{"intent": "x", "risks": [{"id": "r1", "description": "d"}], "mutants": [{"id": "m1", "risk_id": "r1", "description": "d", "original": "a", "mutated": "b"}]}`
+	provider := &scriptedProvider{replies: []string{missingTests, validReply}}
+	gen := NewGenerator(provider, "test-model", lang.NewGo(), 0, false)
+
+	fn := model.ChangedFunc{Name: "Clamp", Package: "foo"}
+	if _, _, _, _, err := gen.Generate(context.Background(), fn); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(provider.prompts) != 2 {
+		t.Fatalf("calls = %d, want 2", len(provider.prompts))
+	}
+	if !strings.Contains(provider.prompts[1], "tests: missing required field") {
+		t.Errorf("repair prompt should name the missing field:\n%s", provider.prompts[1])
+	}
+}
+
+func TestGenerate_RepairsDanglingMutantID(t *testing.T) {
+	dangling := strings.Replace(validReply, `"mutant_id": "m1"`, `"mutant_id": "m9"`, 1)
+	provider := &scriptedProvider{replies: []string{dangling, dangling}}
+	gen := NewGenerator(provider, "test-model", lang.NewGo(), 0, false)
+
+	_, _, _, _, err := gen.Generate(context.Background(), model.ChangedFunc{Name: "Clamp", Package: "foo"})
+	if err == nil {
+		t.Fatal("expected error when the repair is still invalid")
+	}
+	if !strings.Contains(provider.prompts[1], `tests[0].mutant_id: "m9" is not the id of any mutant`) {
+		t.Errorf("repair prompt should name the dangling reference:\n%s", provider.prompts[1])
+	}
+}
+
+func TestGenerate_RepairsCompileErrors(t *testing.T) {
+	root, source := writeClampModule(t)
+	badCall := strings.Replace(validReply, `func TestClamp_Negative(t *testing.T) {}`, `func TestClamp_Negative(t *testing.T) { Clamp(-1, 2) }`, 1)
+	badMutant := strings.Replace(badCall, `"mutated": "if x < -1"`, `"mutated": "if x < \"-1\""`, 1)
+	provider := &scriptedProvider{replies: []string{badMutant, validReply}}
+	gen := NewGenerator(provider, "test-model", lang.NewGo(), 0, false).
+		WithTypeCheck(root, filepath.Join(root, "foo", "clamp.go"), source, true)
+
+	fn := model.ChangedFunc{Name: "Clamp", QualifiedName: "Clamp", Package: "foo", FilePath: filepath.Join(root, "foo", "clamp.go")}
+	if _, _, _, _, err := gen.Generate(context.Background(), fn); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(provider.prompts) != 2 {
+		t.Fatalf("calls = %d, want 2", len(provider.prompts))
+	}
+	for _, want := range []string{"mutants[0].mutated: the mutated code does not compile", "tests[0].test_code (TestClamp_Negative): does not compile"} {
+		if !strings.Contains(provider.prompts[1], want) {
+			t.Errorf("repair prompt should contain %q:\n%s", want, provider.prompts[1])
+		}
+	}
+}
+
+func TestGenerate_ReusesCache(t *testing.T) {
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	provider := &scriptedProvider{replies: []string{validReply}}
+	gen := NewGenerator(provider, "test-model", lang.NewGo(), 0, false).WithCache(cache)
+
+	fn := model.ChangedFunc{Name: "Clamp", Package: "foo", FilePath: "foo/clamp.go", Body: "func Clamp(x int) int { return x }"}
+	if _, _, _, _, err := gen.Generate(context.Background(), fn); err != nil {
+		t.Fatalf("first Generate: %v", err)
+	}
+	_, _, mutants, tests, err := gen.Generate(context.Background(), fn)
+	if err != nil {
+		t.Fatalf("second Generate: %v", err)
+	}
+	if len(provider.prompts) != 1 {
+		t.Errorf("provider calls = %d, want 1", len(provider.prompts))
+	}
+	if gen.CacheHits() != 1 {
+		t.Errorf("CacheHits = %d, want 1", gen.CacheHits())
+	}
+	if len(mutants) != 1 || len(tests) != 1 || tests[0].FuncName != "Clamp" {
+		t.Errorf("cached result not restored: %d mutants, %d tests", len(mutants), len(tests))
+	}
+}
+
+func TestCacheKey_ChangesWithInputs(t *testing.T) {
+	fn := model.ChangedFunc{Package: "foo", FilePath: "foo/a.go", Body: "func A() {}", ParentBody: "func A() { x() }"}
+	style := lang.NewGo().Prompt()
+	base := CacheKey(fn, style, "model-a", "")
+
+	if CacheKey(fn, style, "model-a", "") != base {
+		t.Error("key should be stable")
+	}
+	if CacheKey(fn, style, "model-b", "") == base {
+		t.Error("key should change with the model")
+	}
+	edited := fn
+	edited.Body = "func A() { y() }"
+	if CacheKey(edited, style, "model-a", "") == base {
+		t.Error("key should change with the new body")
+	}
+	moved := fn
+	moved.DiffContext = "@@ -1 +1 @@"
+	if CacheKey(moved, style, "model-a", "") == base {
+		t.Error("key should change with the diff context")
+	}
+	retyped := fn
+	retyped.TypeDefs = []string{"type T struct{ X int }"}
+	if CacheKey(retyped, style, "model-a", "") == base {
+		t.Error("key should change with the type definitions")
+	}
+	imported := fn
+	imported.Imports = []string{`"strings"`}
+	if CacheKey(imported, style, "model-a", "") == base {
+		t.Error("key should change with the imports")
+	}
+	observed := fn
+	observed.TelemetryContext = "p99 latency 120ms, 3 errors/min"
+	if CacheKey(observed, style, "model-a", "") == base {
+		t.Error("key should change with the telemetry context")
+	}
+	renamed := fn
+	renamed.FilePath = "foo/b.go"
+	if CacheKey(renamed, style, "model-a", "") == base {
+		t.Error("key should change with the file path")
+	}
+	if CacheKey(fn, style, "model-a", "Fix rounding") == base {
+		t.Error("key should change with the commit message")
+	}
+
+	script := model.ChangedFunc{Package: "cart", FilePath: "src/cart.ts", Body: "function add() {}"}
+	ts := lang.NewTypeScript()
+	jest := ts.ForProject(t.TempDir(), lang.Options{JSRunner: lang.RunnerJest})
+	if CacheKey(script, ts.Prompt(), "model-a", "") == CacheKey(script, jest.Prompt(), "model-a", "") {
+		t.Error("key should change with the JavaScript test runner")
+	}
+}
+
+func TestRepair_FeedsBackOutput(t *testing.T) {
+	fixed := `{"test_name": "TestClamp_Negative", "test_code": "package foo\n\nimport \"testing\"\n\nfunc TestClamp_Negative(t *testing.T) { _ = Clamp(-1) }\n"}`
+	provider := &scriptedProvider{replies: []string{fixed}}
+	gen := NewGenerator(provider, "test-model", lang.NewGo(), 0, false)
+
+	fn := model.ChangedFunc{Name: "Clamp", Package: "foo", FilePath: "foo/clamp.go", Signature: "func Clamp(x int) int", Body: "{ return x }"}
+	test := model.GeneratedTest{ID: "t1", MutantID: "m1", FuncName: "Clamp", TestName: "TestClamp_Negative", TestCode: "package foo\n\nimport \"fmt\"\n"}
+	mutant := model.Mutant{ID: "m1", Description: "drop clamp", Original: "x < 0", Mutated: "x < -1"}
+
+	repaired, err := gen.Repair(context.Background(), fn, test, mutant, `./snare_test.go:3:8: "fmt" imported and not used`)
+	if err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+	if !strings.Contains(provider.prompts[0], `"fmt" imported and not used`) {
+		t.Error("repair prompt should include the compiler output")
+	}
+	if !strings.Contains(provider.prompts[0], test.TestCode) {
+		t.Error("repair prompt should include the failing test")
+	}
+	if repaired.ID != "t1" || repaired.MutantID != "m1" || repaired.FuncName != "Clamp" {
+		t.Errorf("repair should keep IDs, got %+v", repaired)
+	}
+	if !strings.Contains(repaired.TestCode, "Clamp(-1)") {
+		t.Errorf("TestCode not replaced: %q", repaired.TestCode)
+	}
+}
+
+func TestGenerate_TypeChecksCachedResults(t *testing.T) {
+	root, source := writeClampModule(t)
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	badCall := strings.Replace(validReply, `func TestClamp_Negative(t *testing.T) {}`, `func TestClamp_Negative(t *testing.T) { Clamp(-1, 2) }`, 1)
+	provider := &scriptedProvider{replies: []string{badCall, validReply}}
+	gen := NewGenerator(provider, "test-model", lang.NewGo(), 0, false).WithCache(cache)
+	fn := model.ChangedFunc{Name: "Clamp", QualifiedName: "Clamp", Package: "foo", FilePath: filepath.Join(root, "foo", "clamp.go")}
+
+	// Cached without type checking, like results from an older run
+	if _, _, _, _, err := gen.Generate(context.Background(), fn); err != nil {
+		t.Fatalf("first Generate: %v", err)
+	}
+	checked := gen.WithTypeCheck(root, fn.FilePath, source, true)
+	_, _, _, tests, err := checked.Generate(context.Background(), fn)
+	if err != nil {
+		t.Fatalf("second Generate: %v", err)
+	}
+	if len(provider.prompts) != 2 || gen.CacheHits() != 0 {
+		t.Errorf("calls = %d, cache hits = %d; want a cached result that doesn't compile to be regenerated", len(provider.prompts), gen.CacheHits())
+	}
+	if len(tests) != 1 || strings.Contains(tests[0].TestCode, "Clamp(-1, 2)") {
+		t.Errorf("tests = %+v, want the regenerated test", tests)
+	}
+}
+
+// writeClampModule writes a module with package foo, holding Clamp, to a temp
+// dir, returning the dir and foo/clamp.go's source.
+func writeClampModule(t *testing.T) (string, []byte) {
+	t.Helper()
+	root := t.TempDir()
+	source := []byte("package foo\n\nfunc Clamp(x int) int {\n\tif x < 0 {\n\t\treturn 0\n\t}\n\treturn x\n}\n")
+	files := map[string][]byte{
+		"go.mod":       []byte("module example.com/foo\n\ngo 1.21\n"),
+		"foo/clamp.go": source,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root, source
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/yiyuanh/snare/internal/lang"
 	"github.com/yiyuanh/snare/pkg/model"
 )
 
@@ -11,46 +12,69 @@ import (
 // It takes a ChangedFunc with both parent and new code populated,
 // and an optional commit message for additional context.
 func BuildCatchingPrompt(fn model.ChangedFunc, commitMessage ...string) string {
-	// Detect language from file extension
-	isPython := strings.HasSuffix(fn.FilePath, ".py")
-	return buildCatchingPromptForLang(fn, isPython, commitMessage...)
+	prefix, suffix := BuildCatchingPromptParts(fn, commitMessage...)
+	return prefix + suffix
 }
 
-func buildCatchingPromptForLang(fn model.ChangedFunc, isPython bool, commitMessage ...string) string {
-	var sb strings.Builder
+// BuildCatchingPromptParts splits the catching prompt into a prefix shared by
+// every changed function in the same file (instructions, imports, type
+// definitions, commit message) and a per-function suffix. The prefix is
+// byte-identical across those functions so it can be served from the
+// provider's prompt cache.
+func BuildCatchingPromptParts(fn model.ChangedFunc, commitMessage ...string) (prefix, suffix string) {
+	return buildCatchingPromptParts(fn, promptStyle(fn), commitMessage...)
+}
+
+// buildCatchingPromptParts is BuildCatchingPromptParts with the wording of a
+// specific language, e.g. one configured for the project's test runner.
+func buildCatchingPromptParts(fn model.ChangedFunc, style lang.PromptStyle, commitMessage ...string) (prefix, suffix string) {
+	return buildCatchingPrefix(fn, style, commitMessage...), buildCatchingSuffix(fn, style)
+}
 
-	codeLang := "go"
-	testFramework := "Go test"
-	testExample := `"package pkg\n\nimport (\n\t\"testing\"\n)\n\nfunc TestFuncName_RiskDescription(t *testing.T) {\n\t// test body\n}"`
-	testNameExample := "TestFuncName_RiskDescription"
-	packageLabel := "Package"
-	if isPython {
-		codeLang = "python"
-		testFramework = "pytest"
-		testExample = `"import pytest\nfrom module import function\n\ndef test_func_name_risk_description():\n    # test body\n    assert result == expected"`
-		testNameExample = "test_func_name_risk_description"
-		packageLabel = "Module"
+// promptStyle returns the prompt wording for the function's language,
+// defaulting to Go.
+func promptStyle(fn model.ChangedFunc) lang.PromptStyle {
+	if l := lang.ForFile(fn.FilePath); l != nil {
+		return l.Prompt()
+	}
+	return lang.NewGo().Prompt()
+}
+
+// writeImports writes the file's imports as a fenced code block.
+func writeImports(sb *strings.Builder, fn model.ChangedFunc, style lang.PromptStyle) {
+	if len(fn.Imports) == 0 {
+		return
+	}
+	sb.WriteString("### Imports\n```" + style.CodeFence + "\n")
+	for _, imp := range fn.Imports {
+		sb.WriteString(style.ImportPrefix + imp + "\n")
 	}
+	sb.WriteString("```\n\n")
+}
+
+// writeFunc writes a function's source, with its signature unless the
+// language's body already includes it.
+func writeFunc(sb *strings.Builder, signature, body string, style lang.PromptStyle) {
+	if style.ShowSignature {
+		sb.WriteString(signature + " ")
+	}
+	sb.WriteString(body)
+}
+
+func buildCatchingPrefix(fn model.ChangedFunc, style lang.PromptStyle, commitMessage ...string) string {
+	var sb strings.Builder
+
+	codeLang := style.CodeFence
 
 	sb.WriteString(`You are a Just-in-Time catching test expert. Your goal is to find bugs introduced by a code change by generating tests that pass on the OLD code but might fail on the NEW code.
 
 `)
 
 	sb.WriteString("## Context\n\n")
-	sb.WriteString(fmt.Sprintf("%s: %s\n", packageLabel, fn.Package))
+	sb.WriteString(fmt.Sprintf("%s: %s\n", style.PackageLabel, fn.Package))
 	sb.WriteString(fmt.Sprintf("File: %s\n\n", fn.FilePath))
 
-	if len(fn.Imports) > 0 {
-		sb.WriteString("### Imports\n```" + codeLang + "\n")
-		for _, imp := range fn.Imports {
-			if isPython {
-				sb.WriteString(imp + "\n")
-			} else {
-				sb.WriteString(fmt.Sprintf("import %s\n", imp))
-			}
-		}
-		sb.WriteString("```\n\n")
-	}
+	writeImports(&sb, fn, style)
 
 	if len(fn.TypeDefs) > 0 {
 		sb.WriteString("### Type Definitions\n```" + codeLang + "\n")
@@ -67,51 +91,6 @@ func buildCatchingPromptForLang(fn model.ChangedFunc, isPython bool, commitMessa
 		sb.WriteString("\n\n")
 	}
 
-	// Telemetry context (if available)
-	if fn.TelemetryContext != "" {
-		sb.WriteString("### Production Telemetry\n")
-		sb.WriteString("The following production telemetry data provides context about how this function is used in practice:\n\n")
-		sb.WriteString(fn.TelemetryContext)
-		sb.WriteString("\n\n")
-		sb.WriteString("Use this telemetry to prioritize risks that affect production usage patterns. ")
-		sb.WriteString("High-traffic functions with known callers and endpoints deserve more thorough testing. ")
-		sb.WriteString("Known exceptions and incidents should inform risk identification.\n\n")
-	}
-
-	// Parent (OLD) function
-	sb.WriteString("### Parent (OLD) Function — this is the baseline, known-good code\n```" + codeLang + "\n")
-	if fn.ParentSignature != "" {
-		if isPython {
-			sb.WriteString(fn.ParentBody)
-		} else {
-			sb.WriteString(fn.ParentSignature + " ")
-			sb.WriteString(fn.ParentBody)
-		}
-	} else {
-		if isPython {
-			sb.WriteString(fn.Body)
-		} else {
-			sb.WriteString(fn.Signature + " ")
-			sb.WriteString(fn.Body)
-		}
-	}
-	sb.WriteString("\n```\n\n")
-
-	// Current (NEW) function
-	sb.WriteString("### Current (NEW) Function — this is the code change being tested\n```" + codeLang + "\n")
-	if isPython {
-		sb.WriteString(fn.Body)
-	} else {
-		sb.WriteString(fn.Signature + " ")
-		sb.WriteString(fn.Body)
-	}
-	sb.WriteString("\n```\n\n")
-
-	// Diff context
-	sb.WriteString("### Diff\n```diff\n")
-	sb.WriteString(fn.DiffContext)
-	sb.WriteString("\n```\n\n")
-
 	sb.WriteString(`## Instructions
 
 1. **Infer Intent**: Describe what this code change is trying to accomplish.
@@ -126,21 +105,18 @@ func buildCatchingPromptForLang(fn model.ChangedFunc, isPython bool, commitMessa
    - The "original" field must be an exact substring of the PARENT function body
    - The "mutated" field is the buggy replacement
 
-4. **Generate Catching Tests**: For each mutant, write a ` + testFramework + ` test that:
+4. **Generate Catching Tests**: For each mutant, write a ` + style.TestFramework + ` test that:
    - PASSES on the parent (old) code
    - FAILS on the mutant
    - Tests the specific risk scenario
    - Is a complete, self-contained test file`)
-
-	if !isPython {
-		sb.WriteString(" with proper package declaration and imports")
-	}
+	sb.WriteString(style.TestFileNote)
 
 	sb.WriteString(`
 
 ## Output Format
 
-Respond with ONLY a JSON object (no markdown fences, no explanation) in this exact format:
+Submit your answer by calling the submit_catching_tests tool with input in this exact format (if the tool is unavailable, respond with ONLY this JSON object, no markdown fences, no explanation):
 
 {
   "intent": "description of what the code change is trying to accomplish",
@@ -163,32 +139,118 @@ Respond with ONLY a JSON object (no markdown fences, no explanation) in this exa
     {
       "id": "t1",
       "mutant_id": "m1",
-      "test_name": "` + testNameExample + `",
-      "test_code": ` + testExample + `
+      "test_name": "` + style.TestNameExample + `",
+      "test_code": ` + style.TestCodeExample + `
     }
   ]
 }
 
 IMPORTANT:
-- The "original" field in each mutant MUST be an exact substring of the PARENT function body shown above`)
-
-	if isPython {
-		sb.WriteString(`
-- Each test must be a complete, self-contained Python test file
-- Import the function under test from the module "` + fn.Package + `"
-- Use pytest assertions (assert statements), not unittest
-- Ensure tests are deterministic (no randomness, no timing dependencies)
-- Each mutant must reference a risk via "risk_id"
+- The "original" field in each mutant MUST be an exact substring of the PARENT function body shown below`)
+
+	sb.WriteString("\n")
+	for _, rule := range style.Rules {
+		sb.WriteString("- " + strings.ReplaceAll(rule, "{package}", fn.Package) + "\n")
+	}
+	sb.WriteString(`- Ensure tests are deterministic (no randomness, no timing dependencies)
+- Each mutant must reference a risk via "risk_id", and each test a mutant via "mutant_id"
 `)
+
+	return sb.String()
+}
+
+// buildCatchingSuffix renders the per-function part of the prompt.
+func buildCatchingSuffix(fn model.ChangedFunc, style lang.PromptStyle) string {
+	var sb strings.Builder
+
+	codeLang := style.CodeFence
+
+	sb.WriteString("\n## Function Under Test\n\n")
+
+	// Telemetry context (if available)
+	if fn.TelemetryContext != "" {
+		sb.WriteString("### Production Telemetry\n")
+		sb.WriteString("The following production telemetry data provides context about how this function is used in practice:\n\n")
+		sb.WriteString(fn.TelemetryContext)
+		sb.WriteString("\n\n")
+		sb.WriteString("Use this telemetry to prioritize risks that affect production usage patterns. ")
+		sb.WriteString("High-traffic functions with known callers and endpoints deserve more thorough testing. ")
+		sb.WriteString("Known exceptions and incidents should inform risk identification.\n\n")
+	}
+
+	// Parent (OLD) function
+	sb.WriteString("### Parent (OLD) Function — this is the baseline, known-good code\n```" + codeLang + "\n")
+	if fn.ParentSignature != "" {
+		writeFunc(&sb, fn.ParentSignature, fn.ParentBody, style)
 	} else {
-		sb.WriteString(`
-- Each test must be a complete, self-contained Go test file
-- The package name in tests must be "` + fn.Package + `"
-- Do not use any external test frameworks — only the standard "testing" package
-- Ensure tests are deterministic (no randomness, no timing dependencies)
-- Each mutant must reference a risk via "risk_id"
-`)
+		writeFunc(&sb, fn.Signature, fn.Body, style)
 	}
+	sb.WriteString("\n```\n\n")
+
+	// Current (NEW) function
+	sb.WriteString("### Current (NEW) Function — this is the code change being tested\n```" + codeLang + "\n")
+	writeFunc(&sb, fn.Signature, fn.Body, style)
+	sb.WriteString("\n```\n\n")
+
+	// Diff context
+	sb.WriteString("### Diff\n```diff\n")
+	sb.WriteString(fn.DiffContext)
+	sb.WriteString("\n```\n\n")
 
 	return sb.String()
 }
+
+// BuildRepairPrompt constructs the prompt asking the model to fix a generated
+// test that fails on the parent (old) code, given the compiler or test output.
+func BuildRepairPrompt(fn model.ChangedFunc, test model.GeneratedTest, mutant model.Mutant, output string) string {
+	style := promptStyle(fn)
+	codeLang := style.CodeFence
+
+	var sb strings.Builder
+	sb.WriteString("You wrote a catching test for the function below, but it does not pass on the parent (old) code. ")
+	sb.WriteString("A catching test must compile and pass on the parent code, and fail when the mutant is applied.\n\n")
+
+	sb.WriteString("## Context\n\n")
+	sb.WriteString(fmt.Sprintf("%s: %s\n", style.PackageLabel, fn.Package))
+	sb.WriteString(fmt.Sprintf("File: %s\n\n", fn.FilePath))
+	writeImports(&sb, fn, style)
+	if len(fn.TypeDefs) > 0 {
+		sb.WriteString("### Type Definitions\n```" + codeLang + "\n")
+		for _, td := range fn.TypeDefs {
+			sb.WriteString(td + "\n\n")
+		}
+		sb.WriteString("```\n\n")
+	}
+
+	sb.WriteString("### Parent (OLD) Function — the test must pass against this code\n```" + codeLang + "\n")
+	signature, body := fn.ParentSignature, fn.ParentBody
+	if signature == "" {
+		signature, body = fn.Signature, fn.Body
+	}
+	writeFunc(&sb, signature, body, style)
+	sb.WriteString("\n```\n\n")
+
+	sb.WriteString("### Mutant the test must catch\n")
+	sb.WriteString(mutant.Description + "\n")
+	sb.WriteString("- original: `" + strings.TrimSpace(mutant.Original) + "`\n")
+	sb.WriteString("- mutated:  `" + strings.TrimSpace(mutant.Mutated) + "`\n\n")
+
+	sb.WriteString("## Failing Test\n```" + codeLang + "\n")
+	sb.WriteString(test.TestCode)
+	sb.WriteString("\n```\n\n")
+
+	sb.WriteString("## Output on Parent Code\n```\n")
+	if len(output) > 3000 {
+		output = output[:3000] + "\n... (truncated)"
+	}
+	sb.WriteString(output)
+	sb.WriteString("\n```\n\n")
+
+	sb.WriteString(`## Task
+
+Fix the test so it compiles and passes on the parent code. Common causes are missing or unused imports, calls to helpers that don't exist or aren't accessible from the test, and wrong expected values. Keep testing the same risk: do not weaken the assertions until the test could no longer fail on the mutant.
+
+Submit the complete corrected test file by calling the submit_fixed_test tool (if the tool is unavailable, respond with ONLY a JSON object with "test_name" and "test_code").
+`)
+	return sb.String()
+}
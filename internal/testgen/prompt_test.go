@@ -117,3 +117,43 @@ func TestBuildCatchingPrompt_NoParent(t *testing.T) {
 		t.Errorf("expected function body to appear at least twice (parent + new), got %d", count)
 	}
 }
+
+func TestBuildCatchingPromptParts_SharedPrefix(t *testing.T) {
+	base := model.ChangedFunc{
+		FilePath: "pkg/math/ops.go",
+		Package:  "math",
+		Imports:  []string{`"errors"`},
+		TypeDefs: []string{"type Number int"},
+	}
+	add, sub := base, base
+	add.Name, add.Signature, add.Body, add.DiffContext = "Add", "func Add(a, b int) int", "{ return a + b }", "+\treturn a + b"
+	sub.Name, sub.Signature, sub.Body, sub.DiffContext = "Sub", "func Sub(a, b int) int", "{ return a - b }", "+\treturn a - b"
+
+	addPrefix, addSuffix := BuildCatchingPromptParts(add, "refactor ops")
+	subPrefix, subSuffix := BuildCatchingPromptParts(sub, "refactor ops")
+
+	if addPrefix != subPrefix {
+		t.Error("functions in the same file should share an identical prefix")
+	}
+	for _, want := range []string{"Package: math", `"errors"`, "type Number int", "refactor ops", "Output Format"} {
+		if !strings.Contains(addPrefix, want) {
+			t.Errorf("prefix missing %q", want)
+		}
+	}
+	if strings.Contains(addPrefix, "return a + b") {
+		t.Error("prefix must not contain function-specific code")
+	}
+	if !strings.Contains(addSuffix, "return a + b") || !strings.Contains(subSuffix, "return a - b") {
+		t.Error("suffix should contain the function's code")
+	}
+}
+
+func TestBuildRepairPrompt_UsesLanguageWording(t *testing.T) {
+	fn := model.ChangedFunc{Name: "add", FilePath: "calc/ops.py", Package: "calc.ops", Body: "def add(a, b):\n    return a + b"}
+	test := model.GeneratedTest{TestName: "test_add", TestCode: "def test_add():\n    assert add(1, 2) == 3"}
+	prompt := BuildRepairPrompt(fn, test, model.Mutant{Original: "a + b", Mutated: "a - b"}, "NameError: name 'add' is not defined")
+
+	if !strings.Contains(prompt, "Module: calc.ops") || strings.Contains(prompt, "Package:") {
+		t.Errorf("repair prompt should use the language's package label:\n%s", prompt)
+	}
+}
@@ -0,0 +1,62 @@
+package testgen
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yiyuanh/snare/internal/llm"
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+// fixedTest is the model's answer to a repair request.
+type fixedTest struct {
+	TestName string `json:"test_name"`
+	TestCode string `json:"test_code"`
+}
+
+var repairTool = &llm.Tool{
+	Name:        "submit_fixed_test",
+	Description: "Submit the corrected, complete test file.",
+	Schema: &llm.Schema{
+		Type:     "object",
+		Required: []string{"test_name", "test_code"},
+		Properties: map[string]*llm.Schema{
+			"test_name": {Type: "string", MinLength: 1},
+			"test_code": {Type: "string", MinLength: 1, Description: "Complete, self-contained test file"},
+		},
+	},
+}
+
+// Repair asks the model to fix a test that fails on the parent code, given
+// the compiler or test runner output. The returned test keeps the original
+// IDs and mutant reference.
+func (g *Generator) Repair(ctx context.Context, fn model.ChangedFunc, test model.GeneratedTest, mutant model.Mutant, output string) (model.GeneratedTest, error) {
+	ctx = llm.WithLabel(ctx, llm.StageRepair, fn.ID())
+	prompt := BuildRepairPrompt(fn, test, mutant, output)
+	check := func(f *fixedTest) []string {
+		if err := g.lang.ValidateTestSyntax([]byte(f.TestCode)); err != nil {
+			return []string{fmt.Sprintf("test_code: syntax error: %v", err)}
+		}
+		if g.typeCheck != nil {
+			if errs := g.typeCheck.testErrors(g.lang, f.TestName, f.TestCode); len(errs) > 0 {
+				return []string{fmt.Sprintf("test_code: does not compile: %s", formatCompileErrors(errs))}
+			}
+		}
+		return nil
+	}
+
+	fixed, err := llm.CompleteJSON(ctx, g.provider, llm.Request{
+		Model:     g.model,
+		Prompt:    prompt,
+		MaxTokens: 4096,
+		Tool:      repairTool,
+	}, check)
+	if err != nil {
+		return test, fmt.Errorf("repairing %s: %w", test.TestName, err)
+	}
+
+	repaired := test
+	repaired.TestName = fixed.TestName
+	repaired.TestCode = fixed.TestCode
+	return repaired, nil
+}
@@ -0,0 +1,122 @@
+package testgen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/yiyuanh/snare/internal/lang"
+	"github.com/yiyuanh/snare/pkg/model"
+)
+
+// maxCompileErrors caps how many compile errors of one mutant or test are
+// sent back to the model.
+const maxCompileErrors = 5
+
+// typeCheck is what a generator needs to type-check mutants and tests
+// before accepting them (see WithTypeCheck).
+type typeCheck struct {
+	checker lang.TypeChecker
+	root    string
+	file    string // the function's file, relative to root
+	source  []byte // the version of the file mutants apply to and tests run against
+	tests   bool   // check tests too, not only mutants
+
+	once  sync.Once
+	clean bool // the unchanged package type-checks, so errors are the model's
+}
+
+// WithTypeCheck returns a generator that type-checks the mutants and tests
+// it generates or repairs, if its language implements lang.TypeChecker:
+// each mutant applied to source, the content of filePath, and, with tests,
+// each test against source. Compile errors are problems with the model's
+// answer, like syntax errors, so the model is asked to fix them before
+// anything runs. Call it after WithLanguage.
+func (g *Generator) WithTypeCheck(root, filePath string, source []byte, tests bool) *Generator {
+	cp := *g
+	cp.typeCheck = nil
+	checker, ok := g.lang.(lang.TypeChecker)
+	if !ok || len(source) == 0 {
+		return &cp
+	}
+	rel, err := filepath.Rel(root, filePath)
+	if err != nil {
+		return &cp
+	}
+	cp.typeCheck = &typeCheck{checker: checker, root: root, file: rel, source: source, tests: tests}
+	return &cp
+}
+
+// problems reports the mutants and tests in resp that don't compile. A
+// mutant that can't be applied is left for the executor to report.
+func (tc *typeCheck) problems(l lang.Language, resp *model.CatchingLLMResponse, funcID string) []string {
+	if !tc.usable() {
+		return nil
+	}
+	var problems []string
+	for i, m := range resp.Mutants {
+		m.FuncName = funcID
+		mutated, _, err := l.ApplyMutant(tc.file, tc.source, m)
+		if err != nil {
+			continue
+		}
+		if errs := tc.errors(mutated, "", nil); len(errs) > 0 {
+			problems = append(problems, fmt.Sprintf("mutants[%d].mutated: the mutated code does not compile: %s", i, formatCompileErrors(errs)))
+		}
+	}
+	if tc.tests {
+		for i, t := range resp.Tests {
+			if errs := tc.testErrors(l, t.TestName, t.TestCode); len(errs) > 0 {
+				problems = append(problems, fmt.Sprintf("tests[%d].test_code (%s): does not compile: %s", i, t.TestName, formatCompileErrors(errs)))
+			}
+		}
+	}
+	return problems
+}
+
+// compiles reports whether a cached result's mutants and tests type-check,
+// which is always true without WithTypeCheck. The code around the function
+// may have changed since the result was cached, or the result may predate
+// type checking; either way it is regenerated rather than run.
+func (g *Generator) compiles(resp *model.CatchingLLMResponse, fn model.ChangedFunc) bool {
+	return g.typeCheck == nil || len(g.typeCheck.problems(g.lang, resp, fn.ID())) == 0
+}
+
+// testErrors type-checks a test against source, where the executor will
+// write it.
+func (tc *typeCheck) testErrors(l lang.Language, testName, testCode string) []string {
+	if !tc.tests || !tc.usable() {
+		return nil
+	}
+	return tc.errors(tc.source, l.TestFilePath(tc.file, testName), []byte(testCode))
+}
+
+// usable reports whether the unchanged package type-checks; if it doesn't,
+// every check would fail for reasons the model can't fix.
+func (tc *typeCheck) usable() bool {
+	tc.once.Do(func() {
+		errs, err := tc.checker.TypeCheck(tc.root, tc.file, tc.source, "", nil)
+		tc.clean = err == nil && len(errs) == 0
+	})
+	return tc.clean
+}
+
+// errors type-checks the package with source in place of the file and an
+// optional test file. A package that can't be loaded yields no errors: the
+// test run will report what is wrong.
+func (tc *typeCheck) errors(source []byte, testFile string, testCode []byte) []string {
+	errs, err := tc.checker.TypeCheck(tc.root, tc.file, source, testFile, testCode)
+	if err != nil {
+		return nil
+	}
+	return errs
+}
+
+// formatCompileErrors joins the first few compile errors.
+func formatCompileErrors(errs []string) string {
+	if len(errs) > maxCompileErrors {
+		errs = append(errs[:maxCompileErrors:maxCompileErrors], fmt.Sprintf("and %d more", len(errs)-maxCompileErrors))
+	}
+	return strings.Join(errs, "; ")
+}
@@ -25,6 +25,7 @@ type ChangedFunc struct {
 	FilePath         string
 	Package          string
 	Name             string
+	QualifiedName    string // identity used for matching and reporting: Type.Method (Go), Class.method (Python)
 	Signature        string
 	Body             string
 	StartLine        int
@@ -37,6 +38,15 @@ type ChangedFunc struct {
 	TelemetryContext string // production telemetry context (if available)
 }
 
+// ID returns the function's qualified identity, falling back to its plain
+// name for languages that don't qualify names.
+func (f ChangedFunc) ID() string {
+	if f.QualifiedName != "" {
+		return f.QualifiedName
+	}
+	return f.Name
+}
+
 // Risk represents a potential bug risk identified by the LLM.
 type Risk struct {
 	ID          string `json:"id"`
@@ -77,14 +87,38 @@ type TestResult struct {
 	PassParent       bool          `json:"pass_parent"`
 	FailDiff         bool          `json:"fail_diff"`
 	IsCatching       bool          `json:"is_catching"`
+	MutantApplied    bool          `json:"mutant_applied"` // the mutant leg ran (mutant applied to parent source)
+	KillsMutant      bool          `json:"kills_mutant"`   // test fails on the mutated parent source
 	ParentOutput     string        `json:"parent_output,omitempty"`
 	DiffOutput       string        `json:"diff_output,omitempty"`
+	MutantOutput     string        `json:"mutant_output,omitempty"`
+	MutantError      string        `json:"mutant_error,omitempty"` // why the mutant could not be applied
+	MutantSite       string        `json:"mutant_site,omitempty"`  // where the mutant was applied, e.g. "line 42 in Cart.Add"
 	BehaviorChange   string        `json:"behavior_change,omitempty"`
 	Question         string        `json:"question,omitempty"`
 	Assessment       float64       `json:"assessment"`
 	Confidence       float64       `json:"confidence"`
 	FilteredReason   string        `json:"filtered_reason,omitempty"`
 	TelemetryContext string        `json:"telemetry_context,omitempty"`
+	RepairAttempts   int           `json:"repair_attempts,omitempty"` // times the test was regenerated after failing on parent code
+	LikelyBug        bool          `json:"likely_bug,omitempty"`      // catching, with an assessment above the likely-bug threshold for its path
+}
+
+// SuiteResult represents the outcome of running the project's own test suite against a mutant.
+type SuiteResult struct {
+	Mutant Mutant `json:"mutant"`
+	Killed bool   `json:"killed"` // the suite fails with the mutant applied
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"` // why the mutant could not be evaluated
+	Site   string `json:"site,omitempty"`  // where the mutant was applied, e.g. "line 42 in Cart.Add"
+}
+
+// FuncCoverage aggregates suite results for a single function.
+type FuncCoverage struct {
+	FuncName     string `json:"func_name"`
+	Killed       int    `json:"killed"`
+	Survived     int    `json:"survived"`
+	NotEvaluated int    `json:"not_evaluated"`
 }
 
 // CatchSummary aggregates test results for a single risk/mutant pair.
@@ -93,11 +127,73 @@ type CatchSummary struct {
 	Mutant         Mutant
 	Tests          []TestResult
 	IsWeakCatch    bool
+	IsLikelyBug    bool    // some test is a likely bug at its path's threshold
 	Assessment     float64 // aggregated -1 to 1
 	BehaviorChange string
 	Question       string // "Is it expected that..." question for the developer
 }
 
+// SkippedFile records a changed file that was deliberately not analyzed.
+type SkippedFile struct {
+	FilePath string `json:"file_path"`
+	Reason   string `json:"reason"`
+}
+
+// GenerationError records a changed function for which no tests could be generated.
+type GenerationError struct {
+	FuncName string `json:"func_name"`
+	FilePath string `json:"file_path"`
+	Error    string `json:"error"`
+}
+
+// LLMUsage aggregates token usage, cost and latency over a set of LLM calls.
+type LLMUsage struct {
+	Calls            int           `json:"calls"`
+	InputTokens      int64         `json:"input_tokens"`
+	OutputTokens     int64         `json:"output_tokens"`
+	CacheReadTokens  int64         `json:"cache_read_tokens"`
+	CacheWriteTokens int64         `json:"cache_write_tokens"`
+	CostUSD          float64       `json:"cost_usd"`
+	Latency          time.Duration `json:"latency"` // summed over calls
+}
+
+// TotalTokens returns all billed tokens, cached or not.
+func (u LLMUsage) TotalTokens() int64 {
+	return u.InputTokens + u.OutputTokens + u.CacheReadTokens + u.CacheWriteTokens
+}
+
+// CacheHitRate returns the fraction of prompt tokens served from the prompt cache.
+func (u LLMUsage) CacheHitRate() float64 {
+	prompt := u.InputTokens + u.CacheReadTokens + u.CacheWriteTokens
+	if prompt == 0 {
+		return 0
+	}
+	return float64(u.CacheReadTokens) / float64(prompt)
+}
+
+// StageUsage is the LLM usage of one pipeline stage (generate, repair, judge).
+type StageUsage struct {
+	Stage string `json:"stage"`
+	LLMUsage
+}
+
+// FuncUsage is the LLM usage spent on one changed function.
+type FuncUsage struct {
+	FuncName string `json:"func_name"`
+	LLMUsage
+}
+
+// CostReport breaks down what a run spent on LLM calls.
+type CostReport struct {
+	Model          string       `json:"model"`
+	Priced         bool         `json:"priced"` // false when pricing for the model is unknown (costs are 0)
+	Total          LLMUsage     `json:"total"`
+	ByStage        []StageUsage `json:"by_stage"`
+	ByFunc         []FuncUsage  `json:"by_func"`
+	Budget         string       `json:"budget,omitempty"`
+	BudgetExceeded bool         `json:"budget_exceeded,omitempty"`
+}
+
 // PipelineResult holds the overall result of a pipeline run.
 type PipelineResult struct {
 	FilesAnalyzed    int           `json:"files_analyzed"`
@@ -112,4 +208,23 @@ type PipelineResult struct {
 	Results          []TestResult  `json:"results"`
 	Duration         time.Duration `json:"duration"`
 	Intent           string        `json:"intent,omitempty"`
+
+	// Changed files not analyzed (generated code, snare:ignore)
+	Skipped []SkippedFile `json:"skipped,omitempty"`
+
+	// Functions dropped because generation failed (after retries)
+	GenerationErrors []GenerationError `json:"generation_errors,omitempty"`
+
+	// LLM token usage, cost and latency for the run
+	Cost *CostReport `json:"cost,omitempty"`
+
+	// Assessment above which a weak catch is a likely bug (.snare.yaml may override it per path)
+	LikelyBugThreshold float64 `json:"likely_bug_threshold,omitempty"`
+
+	// Suite mode: mutants evaluated against the project's own tests
+	Mode            string         `json:"mode,omitempty"`
+	MutantsKilled   int            `json:"mutants_killed,omitempty"`
+	MutantsSurvived int            `json:"mutants_survived,omitempty"`
+	SuiteResults    []SuiteResult  `json:"suite_results,omitempty"`
+	FuncCoverage    []FuncCoverage `json:"func_coverage,omitempty"`
 }